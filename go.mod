@@ -16,6 +16,7 @@ require (
 	github.com/pion/sdp/v3 v3.0.16
 	github.com/pion/srtp/v3 v3.0.8
 	github.com/pion/stun/v3 v3.0.1
+	github.com/pion/turn/v4 v4.1.3
 	github.com/pion/webrtc/v4 v4.1.6
 	github.com/rs/zerolog v1.34.0
 	github.com/sigurn/crc16 v0.0.0-20240131213347-83fcde1e29d1
@@ -38,7 +39,6 @@ require (
 	github.com/pion/randutil v0.1.0 // indirect
 	github.com/pion/sctp v1.8.40 // indirect
 	github.com/pion/transport/v3 v3.1.1 // indirect
-	github.com/pion/turn/v4 v4.1.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/wlynxg/anet v0.0.5 // indirect
 	golang.org/x/mod v0.30.0 // indirect
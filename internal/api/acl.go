@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/acl"
+)
+
+var accessList *acl.ACL
+var username, password string
+var pathTokens = map[string]string{} // path => static Bearer token
+
+type userKey struct{}
+
+// ACLEnabled - per-stream access control configured (acl users or sign key)
+func ACLEnabled() bool {
+	return accessList != nil
+}
+
+// Login - user by global credentials, ACL credentials or token, nil for unknown user
+func Login(user, pass, token string) *acl.User {
+	if username != "" && equal(user, username) && equal(pass, password) {
+		return acl.Admin
+	}
+	if accessList == nil {
+		return nil
+	}
+	if u := accessList.Login(user, pass); u != nil {
+		return u
+	}
+	return accessList.Token(token)
+}
+
+// AddToken - module Bearer token with full access to the path (ex. WHIP/WHEP token).
+// It is checked separately from the access control users and tokens.
+func AddToken(pattern, token string) {
+	if len(pattern) == 0 || pattern[0] != '/' {
+		pattern = basePath + "/" + pattern
+	}
+	pathTokens[pattern] = token
+}
+
+// User - authorized user of the request, nil if access control disabled
+func User(r *http.Request) *acl.User {
+	user, _ := r.Context().Value(userKey{}).(*acl.User)
+	return user
+}
+
+// Allow - check action for the stream, always true if access control disabled
+func Allow(r *http.Request, stream, action string) bool {
+	if user := User(r); user != nil {
+		return user.Allow(stream, action)
+	}
+	return true
+}
+
+func middlewareACL(localAuth bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user *acl.User
+
+		if !localAuth && isLoopback(r.RemoteAddr) {
+			user = acl.Admin
+		} else {
+			user = loginRequest(r)
+		}
+
+		if user == nil {
+			if !isPublic(r) {
+				w.Header().Set("Www-Authenticate", `Basic realm="go2rtc"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			user = &acl.User{} // guest without any access
+		} else if !allowRequest(user, r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userKey{}, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loginRequest - user from Basic auth, Bearer token or token query param
+func loginRequest(r *http.Request) *acl.User {
+	user, pass, _ := r.BasicAuth()
+
+	if validPathToken(r) {
+		return acl.Admin // token checked again by the path handler
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+
+	return Login(user, pass, token)
+}
+
+// equal - constant time compare for credentials and tokens
+func equal(s1, s2 string) bool {
+	return subtle.ConstantTimeCompare([]byte(s1), []byte(s2)) == 1
+}
+
+// validPathToken - request with the module Bearer token for its path
+func validPathToken(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	s := pathTokens[r.URL.Path]
+	return s != "" && equal(s, token)
+}
+
+// isPublic - player scripts for the stream.html page with signed URL
+// and HLS session requests (session ID is created by authorized request)
+func isPublic(r *http.Request) bool {
+	path, ok := strings.CutPrefix(r.URL.Path, basePath)
+	if !ok {
+		return false
+	}
+	switch path {
+	case "/video-stream.js", "/video-rtc.js":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/hls/") && r.URL.Query().Get("id") != ""
+}
+
+// allowRequest - admin user can do anything, other users can only view
+// the web interface and the streams from the ACL
+func allowRequest(user *acl.User, r *http.Request) bool {
+	if user.IsAdmin() || isPublic(r) {
+		return true
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, basePath)
+	if !ok {
+		return false
+	}
+
+	query := r.URL.Query()
+
+	switch path {
+	case "/api":
+		return true
+	case "/api/streams":
+		// only the list of stream names (filtered by the handler), because stream info
+		// has source URLs with credentials, other methods - add, edit or delete streams
+		return r.Method == "GET" && !query.Has("src")
+	case "/api/webrtc":
+		if r.Method == "PATCH" || r.Method == "DELETE" {
+			return true // WHIP/WHEP session, checked by the handler
+		}
+		return allowStreams(user, query)
+	case "/api/ws", "/api/stream", "/api/playback":
+		return allowStreams(user, query)
+	}
+
+	if strings.HasPrefix(path, "/api/stream.") || strings.HasPrefix(path, "/api/frame.") ||
+		strings.HasPrefix(path, "/api/playback") {
+		return allowStreams(user, query)
+	}
+
+	// static files of the web interface
+	if _, pattern := http.DefaultServeMux.Handler(r); pattern == basePath+"/" {
+		return true
+	}
+
+	return false
+}
+
+// allowStreams - request with at least one stream and access to all streams:
+// src - for view, dst - for publish
+func allowStreams(user *acl.User, query url.Values) bool {
+	if !query.Has("src") && !query.Has("dst") {
+		return false
+	}
+	for _, name := range query["src"] {
+		if !user.Allow(name, acl.ActionView) {
+			return false
+		}
+	}
+	for _, name := range query["dst"] {
+		if !user.Allow(name, acl.ActionPublish) {
+			return false
+		}
+	}
+	return true
+}
+
+// signHandler - signed URL token for view one stream, ttl in seconds (default 1 hour)
+func signHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	src := query.Get("src")
+	if src == "" {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	ttl := time.Hour
+	if s := query.Get("ttl"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i <= 0 {
+			http.Error(w, "wrong ttl", http.StatusBadRequest)
+			return
+		}
+		ttl = time.Duration(i) * time.Second
+	}
+
+	expires := time.Now().Add(ttl)
+
+	ResponseJSON(w, map[string]any{
+		"token":   accessList.Sign(src, expires),
+		"expires": expires.Unix(),
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/rs/zerolog"
 )
 
@@ -34,6 +35,9 @@ func Init() {
 			UnixListen string `yaml:"unix_listen"`
 
 			AllowPaths []string `yaml:"allow_paths"`
+
+			ACL     []*acl.User `yaml:"acl"`
+			SignKey string      `yaml:"sign_key"`
 		} `yaml:"api"`
 	}
 
@@ -65,7 +69,17 @@ func Init() {
 		Handler = middlewareCORS(Handler) // 3rd
 	}
 
-	if cfg.Mod.Username != "" {
+	username = cfg.Mod.Username
+	password = cfg.Mod.Password
+
+	if cfg.Mod.ACL != nil || cfg.Mod.SignKey != "" {
+		accessList = &acl.ACL{Users: cfg.Mod.ACL}
+		if cfg.Mod.SignKey != "" {
+			accessList.Key = []byte(cfg.Mod.SignKey)
+			HandleFunc("api/sign", signHandler)
+		}
+		Handler = middlewareACL(cfg.Mod.LocalAuth, Handler) // 2nd
+	} else if cfg.Mod.Username != "" {
 		Handler = middlewareAuth(cfg.Mod.Username, cfg.Mod.Password, cfg.Mod.LocalAuth, Handler) // 2nd
 	}
 
@@ -108,16 +122,18 @@ func listen(network, address string) {
 	}
 }
 
-func tlsListen(network, address, certFile, keyFile string) {
-	var cert tls.Certificate
-	var err error
+// LoadCertificate - from file paths or from PEM text content
+func LoadCertificate(certFile, keyFile string) (tls.Certificate, error) {
 	if strings.IndexByte(certFile, '\n') < 0 && strings.IndexByte(keyFile, '\n') < 0 {
 		// check if file path
-		cert, err = tls.LoadX509KeyPair(certFile, keyFile)
-	} else {
-		// if text file content
-		cert, err = tls.X509KeyPair([]byte(certFile), []byte(keyFile))
+		return tls.LoadX509KeyPair(certFile, keyFile)
 	}
+	// if text file content
+	return tls.X509KeyPair([]byte(certFile), []byte(keyFile))
+}
+
+func tlsListen(network, address, certFile, keyFile string) {
+	cert, err := LoadCertificate(certFile, keyFile)
 	if err != nil {
 		log.Error().Err(err).Caller().Send()
 		return
@@ -211,9 +227,10 @@ func isLoopback(remoteAddr string) bool {
 
 func middlewareAuth(username, password string, localAuth bool, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if localAuth || !isLoopback(r.RemoteAddr) {
+		// module token (ex. WHIP/WHEP) is checked again by the path handler
+		if (localAuth || !isLoopback(r.RemoteAddr)) && !validPathToken(r) {
 			user, pass, ok := r.BasicAuth()
-			if !ok || user != username || pass != password {
+			if !ok || !equal(user, username) || !equal(pass, password) {
 				w.Header().Set("Www-Authenticate", `Basic realm="go2rtc"`)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
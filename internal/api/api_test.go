@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AlexxIT/go2rtc/pkg/acl"
+	"github.com/stretchr/testify/require"
+)
+
+func testRequest(handler http.Handler, path, auth string) int {
+	r := httptest.NewRequest("POST", path, nil)
+	r.RemoteAddr = "192.168.1.123:12345"
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestPathTokenAuth(t *testing.T) {
+	AddToken("api/webrtc", "secret")
+	defer delete(pathTokens, "/api/webrtc")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := middlewareAuth("admin", "pass", false, next)
+
+	require.Equal(t, http.StatusOK, testRequest(handler, "/api/webrtc?dst=camera1", "Bearer secret"))
+	require.Equal(t, http.StatusUnauthorized, testRequest(handler, "/api/webrtc?dst=camera1", "Bearer wrong"))
+	require.Equal(t, http.StatusUnauthorized, testRequest(handler, "/api/streams", "Bearer secret"))
+	require.Equal(t, http.StatusOK, testRequest(handler, "/api/streams", "Basic YWRtaW46cGFzcw==")) // admin:pass
+}
+
+func TestPathTokenACL(t *testing.T) {
+	AddToken("api/webrtc", "secret")
+	defer delete(pathTokens, "/api/webrtc")
+
+	accessList = &acl.ACL{Users: []*acl.User{
+		{Token: "viewer", Streams: []string{"camera1"}, Actions: []string{acl.ActionView}},
+	}}
+	defer func() { accessList = nil }()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := middlewareACL(false, next)
+
+	require.Equal(t, http.StatusOK, testRequest(handler, "/api/webrtc?dst=camera1", "Bearer secret"))
+	require.Equal(t, http.StatusUnauthorized, testRequest(handler, "/api/webrtc?dst=camera1", "Bearer wrong"))
+	require.Equal(t, http.StatusForbidden, testRequest(handler, "/api/webrtc?dst=camera1", "Bearer viewer"))
+	require.Equal(t, http.StatusUnauthorized, testRequest(handler, "/api/streams", "Bearer secret"))
+}
@@ -1,8 +1,11 @@
 package doorbird
 
 import (
+	"net/http"
 	"net/url"
 
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/streams"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/doorbird"
@@ -33,4 +36,24 @@ func Init() {
 	streams.HandleFunc("doorbird", func(source string) (core.Producer, error) {
 		return doorbird.Dial(source)
 	})
+
+	api.HandleFunc("api/doorbird", apiDoorbird)
+}
+
+// apiDoorbird - endpoint for DoorBird HTTP calls (doorbell, motion), configured in the DoorBird app:
+// http://192.168.1.123:1984/api/doorbird?src=doorbird1&event=doorbell
+func apiDoorbird(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("src")
+	if streams.Get(name) == nil {
+		http.Error(w, api.StreamNotFound, http.StatusNotFound)
+		return
+	}
+
+	event := query.Get("event")
+	if event == "" {
+		event = "doorbell"
+	}
+
+	events.Publish(&events.Event{Type: "doorbird", Stream: name, State: event, Source: r.RemoteAddr})
 }
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/api/ws"
+	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
+	"github.com/rs/zerolog"
+)
+
+func Init() {
+	var cfg struct {
+		Mod struct {
+			Webhooks []*WebhookConfig `yaml:"webhooks"`
+			MQTT     *MQTTConfig      `yaml:"mqtt"`
+		} `yaml:"events"`
+	}
+
+	app.LoadConfig(&cfg)
+
+	log = app.GetLogger("events")
+
+	for _, conf := range cfg.Mod.Webhooks {
+		if conf.URL == "" {
+			continue
+		}
+		Subscribe(newWebhook(conf).Send)
+	}
+
+	if conf := cfg.Mod.MQTT; conf != nil && conf.URL != "" {
+		Subscribe(newMQTT(conf).Send)
+	}
+
+	ws.HandleFunc("events", wsEvents)
+}
+
+var log zerolog.Logger
+
+// Event - message about something that happened in some module
+type Event struct {
+	Type   string    `json:"type"`             // producer, consumer, rtsp, webrtc, homekit...
+	Stream string    `json:"stream,omitempty"` // stream name, if known
+	Source string    `json:"source,omitempty"` // source URL without password or remote address
+	State  string    `json:"state,omitempty"`  // online, offline, connected, detection...
+	Data   any       `json:"data,omitempty"`   // additional info, depends on type
+	Time   time.Time `json:"time"`
+}
+
+type Handler func(event *Event)
+
+type handler struct {
+	fn Handler
+}
+
+var handlers []*handler
+var handlersMu sync.Mutex
+
+var events chan *Event
+var eventsOnce sync.Once
+
+// Subscribe - add handler for all events, returns function for unsubscribe.
+// Handlers are called one by one from a separate goroutine, so they should be fast.
+func Subscribe(fn Handler) func() {
+	h := &handler{fn: fn}
+
+	handlersMu.Lock()
+	handlers = append(handlers, h)
+	handlersMu.Unlock()
+
+	return func() {
+		handlersMu.Lock()
+		if i := slices.Index(handlers, h); i >= 0 {
+			handlers = slices.Delete(handlers, i, i+1)
+		}
+		handlersMu.Unlock()
+	}
+}
+
+// Publish - send event to all subscribers, never blocks
+func Publish(event *Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	eventsOnce.Do(func() {
+		events = make(chan *Event, 100)
+		go worker()
+	})
+
+	select {
+	case events <- event:
+	default:
+		log.Warn().Msgf("[events] drop event type=%s", event.Type)
+	}
+}
+
+func worker() {
+	for event := range events {
+		log.Trace().Msgf("[events] type=%s stream=%s state=%s", event.Type, event.Stream, event.State)
+
+		handlersMu.Lock()
+		items := slices.Clone(handlers)
+		handlersMu.Unlock()
+
+		for _, h := range items {
+			h.fn(event)
+		}
+	}
+}
+
+// filter - check event type, empty list allows all types
+func filter(types []string, event *Event) bool {
+	return len(types) == 0 || slices.Contains(types, event.Type)
+}
+
+// allowEvent - user with access control gets only events of the streams it can view,
+// events without stream are only for admin
+func allowEvent(user *acl.User, event *Event) bool {
+	if user == nil || user.IsAdmin() {
+		return true
+	}
+	return event.Stream != "" && user.Allow(event.Stream, acl.ActionView)
+}
+
+// wsEvents - subscribe WebSocket client to events, optional value with list of types
+func wsEvents(tr *ws.Transport, msg *ws.Message) error {
+	var types []string
+	if msg.Value != nil {
+		if err := msg.Unmarshal(&types); err != nil {
+			return err
+		}
+	}
+
+	q := newQueue(func(event *Event) {
+		tr.Write(&ws.Message{Type: "event", Value: event})
+	})
+
+	var user *acl.User
+	if tr.Request != nil {
+		user = api.User(tr.Request)
+	}
+
+	unsubscribe := Subscribe(func(event *Event) {
+		if filter(types, event) && allowEvent(user, event) {
+			q.Send(event)
+		}
+	})
+
+	tr.OnClose(func() {
+		unsubscribe()
+		q.Close()
+	})
+
+	return nil
+}
+
+// queue - call handler from a separate goroutine, so slow sink doesn't block other sinks
+type queue struct {
+	ch     chan *Event
+	closed bool
+	mu     sync.Mutex
+}
+
+func newQueue(fn Handler) *queue {
+	q := &queue{ch: make(chan *Event, 100)}
+	go func() {
+		for event := range q.ch {
+			fn(event)
+		}
+	}()
+	return q
+}
+
+func (q *queue) Send(event *Event) {
+	q.mu.Lock()
+	if !q.closed {
+		select {
+		case q.ch <- event:
+		default:
+			log.Debug().Msgf("[events] sink is slow, drop event type=%s", event.Type)
+		}
+	}
+	q.mu.Unlock()
+}
+
+func (q *queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mu.Unlock()
+}
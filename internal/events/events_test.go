@@ -0,0 +1,175 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api/ws"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	require.True(t, filter(nil, &Event{Type: "producer"}))
+	require.True(t, filter([]string{"producer", "rtsp"}, &Event{Type: "rtsp"}))
+	require.False(t, filter([]string{"producer"}, &Event{Type: "consumer"}))
+}
+
+func TestAllowEvent(t *testing.T) {
+	user := &acl.User{Streams: []string{"camera1"}, Actions: []string{acl.ActionView}}
+
+	require.True(t, allowEvent(user, &Event{Type: "producer", Stream: "camera1"}))
+	require.False(t, allowEvent(user, &Event{Type: "producer", Stream: "camera2"}))
+	require.False(t, allowEvent(user, &Event{Type: "rtsp"})) // without stream
+
+	require.True(t, allowEvent(acl.Admin, &Event{Type: "rtsp"}))
+	require.True(t, allowEvent(nil, &Event{Type: "producer", Stream: "camera2"})) // access control disabled
+}
+
+func TestQueue(t *testing.T) {
+	release := make(chan struct{})
+	slow := newQueue(func(event *Event) {
+		<-release
+	})
+	defer slow.Close()
+
+	received := make(chan string, 10)
+	fast := newQueue(func(event *Event) {
+		received <- event.State
+	})
+	defer fast.Close()
+
+	for _, state := range []string{"1", "2", "3"} {
+		event := &Event{Type: "test", State: state}
+		slow.Send(event)
+		fast.Send(event)
+	}
+
+	// slow sink doesn't block fast sink and order is preserved
+	for _, state := range []string{"1", "2", "3"} {
+		select {
+		case s := <-received:
+			require.Equal(t, state, s)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timeout")
+		}
+	}
+
+	close(release)
+
+	// send after close is ignored
+	fast.Close()
+	fast.Send(&Event{Type: "test", State: "4"})
+	select {
+	case s := <-received:
+		require.FailNow(t, "event after close", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	received := make(chan *Event, 10)
+	unsubscribe := Subscribe(func(event *Event) {
+		received <- event
+	})
+
+	Publish(&Event{Type: "test", State: "1"})
+
+	select {
+	case event := <-received:
+		require.Equal(t, "1", event.State)
+		require.False(t, event.Time.IsZero())
+	case <-time.After(time.Second):
+		require.FailNow(t, "timeout")
+	}
+
+	unsubscribe()
+
+	Publish(&Event{Type: "test", State: "2"})
+
+	select {
+	case event := <-received:
+		require.FailNow(t, "event after unsubscribe", event.State)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWSEvents(t *testing.T) {
+	received := make(chan *Event, 10)
+
+	tr := &ws.Transport{}
+	tr.OnWrite(func(msg any) error {
+		received <- msg.(*ws.Message).Value.(*Event)
+		return nil
+	})
+
+	err := wsEvents(tr, &ws.Message{Type: "events", Value: []string{"producer"}})
+	require.Nil(t, err)
+
+	Publish(&Event{Type: "consumer", Stream: "camera1"})
+	Publish(&Event{Type: "producer", Stream: "camera1"})
+
+	select {
+	case event := <-received:
+		require.Equal(t, "producer", event.Type)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timeout")
+	}
+
+	tr.Close()
+
+	Publish(&Event{Type: "producer", Stream: "camera1"})
+
+	select {
+	case <-received:
+		require.FailNow(t, "event after close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: b}
+	}))
+	defer server.Close()
+
+	hook := newWebhook(&WebhookConfig{
+		URL:     server.URL,
+		Types:   []string{"producer"},
+		Headers: map[string]string{"X-Token": "secret"},
+	})
+	defer hook.queue.Close()
+
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	hook.Send(&Event{Type: "consumer", Stream: "camera1", Time: ts}) // filtered
+	hook.Send(&Event{Type: "producer", Stream: "camera1", State: "online", Time: ts})
+
+	select {
+	case req := <-requests:
+		require.Equal(t, "application/json", req.header.Get("Content-Type"))
+		require.Equal(t, "secret", req.header.Get("X-Token"))
+
+		var event Event
+		require.Nil(t, json.Unmarshal(req.body, &event))
+		require.Equal(t, Event{Type: "producer", Stream: "camera1", State: "online", Time: ts}, event)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timeout")
+	}
+
+	select {
+	case <-requests:
+		require.FailNow(t, "filtered event was sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
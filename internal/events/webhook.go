@@ -0,0 +1,64 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Types   []string          `yaml:"types"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+type webhook struct {
+	conf   *WebhookConfig
+	client *http.Client
+	queue  *queue
+}
+
+func newWebhook(conf *WebhookConfig) *webhook {
+	w := &webhook{
+		conf:   conf,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+	w.queue = newQueue(w.post)
+	return w
+}
+
+func (w *webhook) Send(event *Event) {
+	if filter(w.conf.Types, event) {
+		w.queue.Send(event)
+	}
+}
+
+func (w *webhook) post(event *Event) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest("POST", w.conf.URL, bytes.NewReader(b))
+	if err != nil {
+		log.Warn().Err(err).Msg("[events] webhook")
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		log.Warn().Err(err).Msg("[events] webhook")
+		return
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		log.Warn().Msgf("[events] webhook url=%s status=%s", w.conf.URL, res.Status)
+	}
+}
@@ -0,0 +1,129 @@
+package hls
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+)
+
+// handlerDASH - live MPEG-DASH manifest, uses same shared muxer and segments as LL-HLS.
+// Player reloads the manifest with the same URL, so each request just keeps the muxer alive.
+func handlerDASH(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/dash+xml")
+
+	if r.Method == "OPTIONS" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		return
+	}
+
+	query := r.URL.Query()
+
+	stream := streams.Get(query.Get("src"))
+	if stream == nil {
+		http.Error(w, api.StreamNotFound, http.StatusNotFound)
+		return
+	}
+
+	live, err := getLive(stream, mp4.ParseQuery(query), r)
+	if err != nil {
+		log.Error().Err(err).Caller().Send()
+		return
+	}
+
+	// DASH manifest can list only complete segments
+	if !live.wait(func() bool { return live.hasPart(live.sequence, -1) }) {
+		http.Error(w, "timeout", http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, err = w.Write(live.MPD()); err != nil {
+		log.Error().Err(err).Caller().Send()
+	}
+}
+
+// MPD - dynamic manifest with SegmentTimeline of complete segments.
+// Decode time zero is the start of the period.
+func (l *Live) MPD() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.id
+	now := time.Now()
+
+	b := make([]byte, 0, 1024+64*len(l.segments))
+	b = append(b, `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" availabilityStartTime="`...)
+	b = appendTime(b, l.start)
+	b = append(b, `" publishTime="`...)
+	b = appendTime(b, now)
+	b = append(b, `" minimumUpdatePeriod="`...)
+	b = appendDuration(b, l.segmentDuration)
+	b = append(b, `" minBufferTime="`...)
+	b = appendDuration(b, l.segmentDuration)
+	b = append(b, `" suggestedPresentationDelay="`...)
+	b = appendDuration(b, 2*l.segmentDuration)
+	b = append(b, `" timeShiftBufferDepth="`...)
+	b = appendDuration(b, l.window)
+	b = append(b, `">
+<Period id="0" start="PT0S">
+<AdaptationSet mimeType="`...)
+	b = append(b, mimeType(l.cons.Codecs())...)
+	b = append(b, `" segmentAlignment="true" startWithSAP="1">
+<Representation id="0" bandwidth="192000" codecs="`...)
+	b = append(b, mimeCodecs(l.cons)...)
+	b = append(b, `">
+<SegmentTemplate timescale="1000" initialization="hls/init.mp4?id=`...)
+	b = append(b, id...)
+	b = append(b, `" media="hls/segment.m4s?id=`...)
+	b = append(b, id...)
+	b = append(b, `&amp;n=$Number$" startNumber="`...)
+	b = strconv.AppendInt(b, int64(l.sequence), 10)
+	b = append(b, "\">\n<SegmentTimeline>\n"...)
+
+	// last segment is in progress
+	for _, segment := range l.segments[:len(l.segments)-1] {
+		b = append(b, `<S t="`...)
+		b = strconv.AppendInt(b, segment.start.Milliseconds(), 10)
+		b = append(b, `" d="`...)
+		b = strconv.AppendInt(b, (segment.start+segment.duration).Milliseconds()-segment.start.Milliseconds(), 10)
+		b = append(b, "\"/>\n"...)
+	}
+
+	b = append(b, `</SegmentTimeline>
+</SegmentTemplate>
+</Representation>
+</AdaptationSet>
+</Period>
+<UTCTiming schemeIdUri="urn:mpeg:dash:utc:direct:2014" value="`...)
+	b = appendTime(b, now)
+	b = append(b, "\"/>\n</MPD>\n"...)
+
+	return b
+}
+
+// mimeType - audio only streams should have audio type
+func mimeType(codecs []*core.Codec) string {
+	for _, codec := range codecs {
+		if codec.IsVideo() {
+			return "video/mp4"
+		}
+	}
+	return "audio/mp4"
+}
+
+func appendTime(b []byte, t time.Time) []byte {
+	return t.UTC().AppendFormat(b, "2006-01-02T15:04:05.000Z")
+}
+
+// appendDuration - ISO 8601 duration in seconds, ex. PT2.000S
+func appendDuration(b []byte, d time.Duration) []byte {
+	b = append(b, "PT"...)
+	b = strconv.AppendFloat(b, d.Seconds(), 'f', 3, 64)
+	return append(b, 'S')
+}
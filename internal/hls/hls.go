@@ -16,6 +16,24 @@ import (
 )
 
 func Init() {
+	var conf struct {
+		Mod struct {
+			SegmentDuration time.Duration `yaml:"segment_duration" json:"segment_duration"`
+			PartDuration    time.Duration `yaml:"part_duration" json:"part_duration"`
+			Window          time.Duration `yaml:"window" json:"window"`
+		} `yaml:"hls"`
+	}
+
+	conf.Mod.SegmentDuration = 2 * time.Second
+	conf.Mod.PartDuration = 500 * time.Millisecond
+	conf.Mod.Window = 30 * time.Second
+
+	app.LoadConfig(&conf)
+
+	segmentDuration = conf.Mod.SegmentDuration
+	partDuration = conf.Mod.PartDuration
+	window = conf.Mod.Window
+
 	log = app.GetLogger("hls")
 
 	api.HandleFunc("api/stream.m3u8", handlerStream)
@@ -27,6 +45,10 @@ func Init() {
 	// HLS (fMP4)
 	api.HandleFunc("api/hls/init.mp4", handlerInit)
 	api.HandleFunc("api/hls/segment.m4s", handlerSegmentMP4)
+	api.HandleFunc("api/hls/part.m4s", handlerPartMP4)
+
+	// MPEG-DASH (fMP4)
+	api.HandleFunc("api/stream.mpd", handlerDASH)
 
 	ws.HandleFunc("hls", handlerWSHLS)
 }
@@ -39,6 +61,12 @@ const keepalive = 5 * time.Second
 var sessions = map[string]*Session{}
 var sessionsMu sync.RWMutex
 
+// LL-HLS settings for fMP4
+var segmentDuration, partDuration, window time.Duration
+
+// lives - LL-HLS muxers, one per stream and codecs, protected by sessionsMu
+var lives = map[string]*Live{}
+
 func handlerStream(w http.ResponseWriter, r *http.Request) {
 	// CORS important for Chromecast
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -56,22 +84,24 @@ func handlerStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var cons core.Consumer
-
-	// use fMP4 with codecs filter and TS without
-	medias := mp4.ParseQuery(r.URL.Query())
-	if medias != nil {
-		c := mp4.NewConsumer(medias)
-		c.FormatName = "hls/fmp4"
-		c.WithRequest(r)
-		cons = c
-	} else {
-		c := mpegts.NewConsumer()
-		c.FormatName = "hls/mpegts"
-		c.WithRequest(r)
-		cons = c
+	// use LL-HLS (fMP4) with codecs filter and TS without
+	if medias := mp4.ParseQuery(r.URL.Query()); medias != nil {
+		live, err := getLive(stream, medias, r)
+		if err != nil {
+			log.Error().Err(err).Caller().Send()
+			return
+		}
+
+		if _, err = w.Write(live.Main()); err != nil {
+			log.Error().Err(err).Caller().Send()
+		}
+		return
 	}
 
+	cons := mpegts.NewConsumer()
+	cons.FormatName = "hls/mpegts"
+	cons.WithRequest(r)
+
 	if err := stream.AddConsumer(cons); err != nil {
 		log.Error().Err(err).Caller().Send()
 		return
@@ -107,6 +137,11 @@ func handlerPlaylist(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sid := r.URL.Query().Get("id")
+	if live := liveByID(sid); live != nil {
+		live.handlePlaylist(w, r)
+		return
+	}
+
 	sessionsMu.RLock()
 	session := sessions[sid]
 	sessionsMu.RUnlock()
@@ -161,16 +196,13 @@ func handlerInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sid := r.URL.Query().Get("id")
-	sessionsMu.RLock()
-	session := sessions[sid]
-	sessionsMu.RUnlock()
-	if session == nil {
+	live := liveByID(r.URL.Query().Get("id"))
+	if live == nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	data := session.Init()
+	data := live.Init()
 	if data == nil {
 		log.Warn().Msgf("[hls] can't get init %s", r.URL.RawQuery)
 		http.NotFound(w, r)
@@ -193,18 +225,13 @@ func handlerSegmentMP4(w http.ResponseWriter, r *http.Request) {
 
 	query := r.URL.Query()
 
-	sid := query.Get("id")
-	sessionsMu.RLock()
-	session := sessions[sid]
-	sessionsMu.RUnlock()
-	if session == nil {
+	live := liveByID(query.Get("id"))
+	if live == nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	session.alive.Reset(keepalive)
-
-	data := session.Segment()
+	data := live.Segment(core.Atoi(query.Get("n")))
 	if data == nil {
 		log.Warn().Msgf("[hls] can't get segment %s", r.URL.RawQuery)
 		http.NotFound(w, r)
@@ -215,3 +242,104 @@ func handlerSegmentMP4(w http.ResponseWriter, r *http.Request) {
 		log.Error().Err(err).Caller().Send()
 	}
 }
+
+func handlerPartMP4(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Content-Type", "video/iso.segment")
+
+	if r.Method == "OPTIONS" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		return
+	}
+
+	query := r.URL.Query()
+
+	live := liveByID(query.Get("id"))
+	if live == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := live.Part(core.Atoi(query.Get("n")), core.Atoi(query.Get("p")))
+	if data == nil {
+		log.Warn().Msgf("[hls] can't get part %s", r.URL.RawQuery)
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, err := w.Write(data); err != nil {
+		log.Error().Err(err).Caller().Send()
+	}
+}
+
+// getLive - return existing LL-HLS muxer for stream with same codecs or create new one
+func getLive(stream *streams.Stream, medias []*core.Media, r *http.Request) (*Live, error) {
+	var key string
+	for _, media := range medias {
+		key += media.String() + ";"
+	}
+
+	sessionsMu.Lock()
+	live := findLive(stream, key)
+	sessionsMu.Unlock()
+
+	if live != nil {
+		return live, nil
+	}
+
+	cons := mp4.NewConsumer(medias)
+	cons.FormatName = "hls/fmp4"
+	cons.WithRequest(r)
+
+	if err := stream.AddConsumer(cons); err != nil {
+		return nil, err
+	}
+
+	sessionsMu.Lock()
+	// check again, muxer may be created by concurrent request while adding consumer
+	if live = findLive(stream, key); live != nil {
+		sessionsMu.Unlock()
+
+		stream.RemoveConsumer(cons)
+		return live, nil
+	}
+
+	live = NewLive(stream, cons)
+	live.key = key
+	live.alive = time.AfterFunc(keepalive, func() {
+		// remove from map before stop, so no one can get stopped muxer
+		sessionsMu.Lock()
+		delete(lives, live.id)
+		sessionsMu.Unlock()
+
+		live.Stop()
+	})
+
+	lives[live.id] = live
+	sessionsMu.Unlock()
+
+	go live.Run()
+
+	return live, nil
+}
+
+// findLive - alive muxer for the stream and codecs, should be called under sessionsMu
+func findLive(stream *streams.Stream, key string) *Live {
+	for _, live := range lives {
+		if live.stream == stream && live.key == key && live.keepAlive() {
+			return live
+		}
+	}
+	return nil
+}
+
+// liveByID - alive muxer by ID, nil if not found or already stopping
+func liveByID(id string) *Live {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	if live := lives[id]; live != nil && live.keepAlive() {
+		return live
+	}
+	return nil
+}
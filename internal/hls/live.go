@@ -0,0 +1,353 @@
+package hls
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+)
+
+// Live - LL-HLS (fMP4) muxer, shared between all viewers of a stream with the same codecs.
+// Segments start on keyframes and split into parts by main track (video) time.
+type Live struct {
+	id     string
+	key    string
+	stream *streams.Stream
+	cons   *mp4.Consumer
+	seg    *mp4.Segmenter
+	alive  *time.Timer
+
+	segmentDuration time.Duration
+	partDuration    time.Duration
+	window          time.Duration
+
+	mu       sync.Mutex
+	update   chan struct{} // closed on each new part
+	init     []byte
+	segments []*liveSegment // last segment is in progress
+	sequence int            // media sequence number of the first segment
+	target   int            // target duration in seconds, can't be changed during playlist
+	start    time.Time      // wall clock time of zero decode time
+	closed   bool
+
+	// part in progress
+	buf         []byte
+	partStart   time.Duration
+	independent bool
+}
+
+type liveSegment struct {
+	start    time.Duration
+	duration time.Duration
+	parts    []*livePart
+}
+
+type livePart struct {
+	duration    time.Duration
+	independent bool
+	data        []byte
+}
+
+// partsSegments - how many last segments will be listed with parts in the playlist
+const partsSegments = 4
+
+func NewLive(stream *streams.Stream, cons *mp4.Consumer) *Live {
+	l := &Live{
+		id:     core.RandString(8, 62),
+		stream: stream,
+		cons:   cons,
+		update: make(chan struct{}),
+
+		segmentDuration: segmentDuration,
+		partDuration:    partDuration,
+		window:          window,
+	}
+
+	l.target = int(math.Ceil(l.segmentDuration.Seconds()))
+
+	l.seg = &mp4.Segmenter{
+		OnInit: func(init []byte) {
+			l.mu.Lock()
+			l.init = init
+			l.mu.Unlock()
+		},
+		OnFragment: l.onFragment,
+	}
+
+	return l
+}
+
+func (l *Live) Run() {
+	_, _ = l.cons.WriteTo(l.seg)
+}
+
+func (l *Live) Stop() {
+	l.stream.RemoveConsumer(l.cons)
+
+	l.mu.Lock()
+	l.closed = true
+	l.notify()
+	l.mu.Unlock()
+}
+
+func (l *Live) Main() []byte {
+	return mainPlaylist(l.cons, l.id)
+}
+
+func (l *Live) onFragment(fragment []byte, keyframe bool) error {
+	dts, duration, main := l.seg.Time(fragment)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.segments == nil {
+		if !main || !keyframe {
+			return nil // wait first keyframe
+		}
+
+		l.start = time.Now().Add(-dts)
+		l.segments = []*liveSegment{{start: dts}}
+		l.partStart = dts
+		l.independent = true
+	}
+
+	if main && len(l.buf) > 0 {
+		segment := l.segments[len(l.segments)-1]
+
+		// segment duration should not exceed target duration, even for long GOP
+		end := dts + duration - segment.start
+		if keyframe && end >= l.segmentDuration || end > time.Duration(l.target)*time.Second {
+			l.closePart(dts)
+			l.closeSegment(dts)
+			l.independent = keyframe
+			l.notify()
+		} else if dts+duration-l.partStart > l.partDuration {
+			// part duration should not exceed part target
+			l.closePart(dts)
+			l.independent = keyframe
+			l.notify()
+		}
+	}
+
+	l.buf = append(l.buf, fragment...)
+
+	return nil
+}
+
+func (l *Live) closePart(dts time.Duration) {
+	segment := l.segments[len(l.segments)-1]
+	segment.parts = append(segment.parts, &livePart{
+		duration:    dts - l.partStart,
+		independent: l.independent,
+		data:        l.buf,
+	})
+
+	l.buf = nil
+	l.partStart = dts
+}
+
+func (l *Live) closeSegment(dts time.Duration) {
+	segment := l.segments[len(l.segments)-1]
+	segment.duration = dts - segment.start
+
+	l.segments = append(l.segments, &liveSegment{start: dts})
+
+	// remove old segments outside DVR window, but keep minimum for player
+	var total time.Duration
+	for _, segment = range l.segments {
+		total += segment.duration
+	}
+
+	for len(l.segments) > partsSegments && total-l.segments[0].duration >= l.window {
+		total -= l.segments[0].duration
+		l.segments = l.segments[1:]
+		l.sequence++
+	}
+}
+
+func (l *Live) notify() {
+	close(l.update)
+	l.update = make(chan struct{})
+}
+
+// wait - until check return true, connection closed or timeout
+func (l *Live) wait(check func() bool) bool {
+	l.mu.Lock()
+	timeout := 3 * time.Duration(l.target) * time.Second
+	l.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		l.mu.Lock()
+		ok := check()
+		closed := l.closed
+		update := l.update
+		l.mu.Unlock()
+
+		if ok {
+			return true
+		}
+		if closed {
+			return false
+		}
+
+		// viewer is alive while waiting
+		sessionsMu.Lock()
+		alive := l.keepAlive()
+		sessionsMu.Unlock()
+
+		if !alive {
+			return false
+		}
+
+		select {
+		case <-update:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// keepAlive - postpone muxer stop, false if the timer already fired and muxer is stopping.
+// Should be called under sessionsMu, so concurrent calls can't re-arm fired timer.
+func (l *Live) keepAlive() bool {
+	if !l.alive.Stop() {
+		return false
+	}
+	l.alive.Reset(keepalive)
+	return true
+}
+
+// hasPart - check if segment msn has part, or segment is complete for negative part
+func (l *Live) hasPart(msn, part int) bool {
+	i := msn - l.sequence
+	if i < 0 || i < len(l.segments)-1 {
+		return true
+	}
+	if i >= len(l.segments) || part < 0 {
+		return false
+	}
+	return part < len(l.segments[i].parts)
+}
+
+func (l *Live) Playlist() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.id
+
+	playlist := &Playlist{
+		Version:        6,
+		TargetDuration: l.target,
+		MediaSequence:  l.sequence,
+		Map:            "init.mp4?id=" + id,
+		PartTarget:     l.partDuration.Seconds(),
+	}
+
+	for i, segment := range l.segments {
+		msn := strconv.Itoa(l.sequence + i)
+
+		s := &Segment{ProgramDateTime: l.start.Add(segment.start)}
+
+		if i >= len(l.segments)-partsSegments {
+			for j, part := range segment.parts {
+				s.Parts = append(s.Parts, &Part{
+					Duration:    part.duration.Seconds(),
+					URI:         "part.m4s?id=" + id + "&n=" + msn + "&p=" + strconv.Itoa(j),
+					Independent: part.independent,
+				})
+			}
+		}
+
+		if i < len(l.segments)-1 {
+			s.Duration = segment.duration.Seconds()
+			s.URI = "segment.m4s?id=" + id + "&n=" + msn
+		} else {
+			playlist.PreloadHint = "part.m4s?id=" + id + "&n=" + msn + "&p=" + strconv.Itoa(len(segment.parts))
+		}
+
+		playlist.Segments = append(playlist.Segments, s)
+	}
+
+	return playlist.Bytes()
+}
+
+func (l *Live) Init() []byte {
+	var init []byte
+	l.wait(func() bool {
+		// return init only when have some parts
+		if l.hasPart(l.sequence, 0) {
+			init = l.init
+		}
+		return init != nil
+	})
+	return init
+}
+
+func (l *Live) Segment(msn int) []byte {
+	var data []byte
+	l.wait(func() bool {
+		if i := msn - l.sequence; i >= 0 && i < len(l.segments)-1 {
+			for _, part := range l.segments[i].parts {
+				data = append(data, part.data...)
+			}
+			return true
+		}
+		return msn < l.sequence // segment already removed
+	})
+	return data
+}
+
+func (l *Live) Part(msn, part int) []byte {
+	var data []byte
+	l.wait(func() bool {
+		if i := msn - l.sequence; i >= 0 && i < len(l.segments) {
+			if parts := l.segments[i].parts; part < len(parts) {
+				data = parts[part].data
+				return true
+			}
+		}
+		return l.hasPart(msn, part) // segment removed or complete without this part
+	})
+	return data
+}
+
+func (l *Live) handlePlaylist(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// blocking playlist reload with _HLS_msn and _HLS_part params
+	if s := query.Get("_HLS_msn"); s != "" {
+		msn, part := core.Atoi(s), -1
+		if s = query.Get("_HLS_part"); s != "" {
+			part = core.Atoi(s)
+		}
+
+		l.mu.Lock()
+		last := l.sequence + len(l.segments) - 1
+		l.mu.Unlock()
+
+		if msn > last+2 {
+			http.Error(w, "msn is too far in the future", http.StatusBadRequest)
+			return
+		}
+
+		if !l.wait(func() bool { return l.hasPart(msn, part) }) {
+			http.Error(w, "timeout", http.StatusServiceUnavailable)
+			return
+		}
+	} else {
+		// wait first part for new viewers
+		l.wait(func() bool { return l.hasPart(l.sequence, 0) })
+	}
+
+	if _, err := w.Write(l.Playlist()); err != nil {
+		log.Error().Err(err).Caller().Send()
+	}
+}
@@ -0,0 +1,140 @@
+package hls
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLive(t *testing.T) {
+	segmentDuration = 2 * time.Second
+	partDuration = 500 * time.Millisecond
+	window = 4 * time.Second
+
+	l := NewLive(nil, mp4.NewConsumer(nil))
+	l.alive = time.NewTimer(time.Minute)
+
+	muxer := &mp4.Muxer{}
+	muxer.AddTrack(&core.Codec{Name: core.CodecH264, ClockRate: 90000})
+
+	init, err := muxer.GetInit()
+	require.Nil(t, err)
+	_, _ = l.seg.Write(init)
+
+	// 25 fps with keyframe every second, 12 seconds
+	for i := 1; i <= 300; i++ {
+		pkt := &rtp.Packet{Header: rtp.Header{Timestamp: uint32(i * 3600)}}
+		if i%25 == 1 {
+			pkt.Payload = []byte{0, 0, 0, 2, 0x65, 0}
+		} else {
+			pkt.Payload = []byte{0, 0, 0, 2, 0x41, 0}
+		}
+		_, _ = l.seg.Write(muxer.GetPayload(0, pkt))
+	}
+
+	// 5 complete segments and one in progress, keep minimum 4 segments for small window
+	require.Equal(t, 2, l.sequence)
+	require.Len(t, l.segments, 4)
+	require.Equal(t, 2*time.Second, l.segments[0].duration)
+
+	parts := l.segments[0].parts
+	require.Len(t, parts, 5)
+	require.Equal(t, 480*time.Millisecond, parts[0].duration)
+	require.True(t, parts[0].independent)
+	require.False(t, parts[1].independent)
+	require.Equal(t, 80*time.Millisecond, parts[4].duration)
+
+	require.True(t, l.hasPart(4, -1))
+	require.False(t, l.hasPart(5, -1))
+	require.False(t, l.hasPart(5, 4))
+	require.Nil(t, l.Part(1, 0)) // removed from window
+	require.NotNil(t, l.Segment(4))
+
+	playlist := string(l.Playlist())
+	require.Contains(t, playlist, "#EXT-X-TARGETDURATION:2\n")
+	require.Contains(t, playlist, "#EXT-X-PART-INF:PART-TARGET=0.500\n")
+	require.Contains(t, playlist, "#EXT-X-MEDIA-SEQUENCE:2\n")
+	require.Contains(t, playlist, "#EXTINF:2.000,\nsegment.m4s?id="+l.id+"&n=4\n")
+	require.True(t, strings.HasSuffix(playlist, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part.m4s?id="+l.id+"&n=5&p=4\""))
+
+	mpd := string(l.MPD())
+	require.Contains(t, mpd, `media="hls/segment.m4s?id=`+l.id+`&amp;n=$Number$" startNumber="2"`)
+	require.Contains(t, mpd, `<S t="4000" d="2000"/>`)
+	require.NotContains(t, mpd, `<S t="10000"`) // segment in progress
+}
+
+func TestLiveLongGOP(t *testing.T) {
+	segmentDuration = 2 * time.Second
+	partDuration = 500 * time.Millisecond
+	window = time.Minute
+
+	l := NewLive(nil, mp4.NewConsumer(nil))
+	l.alive = time.NewTimer(time.Minute)
+
+	muxer := &mp4.Muxer{}
+	muxer.AddTrack(&core.Codec{Name: core.CodecH264, ClockRate: 90000})
+
+	init, err := muxer.GetInit()
+	require.Nil(t, err)
+	_, _ = l.seg.Write(init)
+
+	// 25 fps with keyframe every 5 seconds, 12 seconds
+	for i := 1; i <= 300; i++ {
+		pkt := &rtp.Packet{Header: rtp.Header{Timestamp: uint32(i * 3600)}}
+		if i%125 == 1 {
+			pkt.Payload = []byte{0, 0, 0, 2, 0x65, 0}
+		} else {
+			pkt.Payload = []byte{0, 0, 0, 2, 0x41, 0}
+		}
+		_, _ = l.seg.Write(muxer.GetPayload(0, pkt))
+	}
+
+	// segments are split without keyframes, so target duration stays the same
+	require.Greater(t, len(l.segments), 5)
+	for _, segment := range l.segments[:len(l.segments)-1] {
+		require.LessOrEqual(t, segment.duration, 2*time.Second)
+	}
+	require.False(t, l.segments[1].parts[0].independent)
+
+	require.Contains(t, string(l.Playlist()), "#EXT-X-TARGETDURATION:2\n")
+}
+
+func TestMimeType(t *testing.T) {
+	aac := &core.Codec{Name: core.CodecAAC, ClockRate: 16000}
+	h264 := &core.Codec{Name: core.CodecH264, ClockRate: 90000}
+
+	require.Equal(t, "audio/mp4", mimeType([]*core.Codec{aac}))
+	require.Equal(t, "video/mp4", mimeType([]*core.Codec{h264, aac}))
+}
+
+func TestKeepAlive(t *testing.T) {
+	var stops atomic.Int32
+
+	l := NewLive(nil, mp4.NewConsumer(nil))
+	l.alive = time.AfterFunc(time.Millisecond, func() {
+		sessionsMu.Lock()
+		delete(lives, l.id)
+		sessionsMu.Unlock()
+
+		stops.Add(1)
+	})
+
+	sessionsMu.Lock()
+	lives[l.id] = l
+	sessionsMu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// fired timer can't be re-armed and stopped muxer can't be returned
+	require.Nil(t, liveByID(l.id))
+	require.False(t, l.wait(func() bool { return false }))
+
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(1), stops.Load())
+}
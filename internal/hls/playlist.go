@@ -0,0 +1,118 @@
+package hls
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	PlaylistTypeVOD   = "VOD"
+	PlaylistTypeEvent = "EVENT"
+)
+
+// Playlist - HLS media playlist
+type Playlist struct {
+	Version        int
+	TargetDuration int
+	MediaSequence  int
+	Type           string // VOD, EVENT or empty for live
+	Map            string // init URI for fMP4
+	Segments       []*Segment
+	End            bool
+
+	// LL-HLS, https://datatracker.ietf.org/doc/html/draft-pantos-hls-rfc8216bis
+	PartTarget  float64 // enables blocking reload and partial segments
+	PreloadHint string  // URI of the next part
+}
+
+type Segment struct {
+	Duration        float64
+	URI             string // empty for LL-HLS segment in progress, only parts will be listed
+	Discontinuity   bool
+	Map             string // new init URI after discontinuity
+	ProgramDateTime time.Time
+	Parts           []*Part
+}
+
+// Part - LL-HLS partial segment
+type Part struct {
+	Duration    float64
+	URI         string
+	Independent bool
+}
+
+func (p *Playlist) Bytes() []byte {
+	b := make([]byte, 0, 128+64*len(p.Segments))
+	b = append(b, "#EXTM3U\n#EXT-X-VERSION:"...)
+	b = strconv.AppendInt(b, int64(p.Version), 10)
+	b = append(b, "\n#EXT-X-TARGETDURATION:"...)
+	b = strconv.AppendInt(b, int64(p.TargetDuration), 10)
+
+	if p.PartTarget > 0 {
+		// recommended hold back is three part target durations
+		b = append(b, "\n#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK="...)
+		b = strconv.AppendFloat(b, 3*p.PartTarget, 'f', 3, 64)
+		b = append(b, "\n#EXT-X-PART-INF:PART-TARGET="...)
+		b = strconv.AppendFloat(b, p.PartTarget, 'f', 3, 64)
+	}
+
+	b = append(b, "\n#EXT-X-MEDIA-SEQUENCE:"...)
+	b = strconv.AppendInt(b, int64(p.MediaSequence), 10)
+
+	if p.Type != "" {
+		b = append(b, "\n#EXT-X-PLAYLIST-TYPE:"...)
+		b = append(b, p.Type...)
+	}
+
+	if p.Map != "" {
+		b = appendMap(b, p.Map)
+	}
+
+	for _, segment := range p.Segments {
+		if segment.Discontinuity {
+			b = append(b, "\n#EXT-X-DISCONTINUITY"...)
+		}
+		if segment.Map != "" {
+			b = appendMap(b, segment.Map)
+		}
+		if !segment.ProgramDateTime.IsZero() {
+			b = append(b, "\n#EXT-X-PROGRAM-DATE-TIME:"...)
+			b = segment.ProgramDateTime.UTC().AppendFormat(b, "2006-01-02T15:04:05.000Z07:00")
+		}
+		for _, part := range segment.Parts {
+			b = append(b, "\n#EXT-X-PART:DURATION="...)
+			b = strconv.AppendFloat(b, part.Duration, 'f', 3, 64)
+			b = append(b, ",URI=\""...)
+			b = append(b, part.URI...)
+			b = append(b, '"')
+			if part.Independent {
+				b = append(b, ",INDEPENDENT=YES"...)
+			}
+		}
+		if segment.URI == "" {
+			continue
+		}
+		b = append(b, "\n#EXTINF:"...)
+		b = strconv.AppendFloat(b, segment.Duration, 'f', 3, 64)
+		b = append(b, ",\n"...)
+		b = append(b, segment.URI...)
+	}
+
+	if p.PreloadHint != "" {
+		b = append(b, "\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\""...)
+		b = append(b, p.PreloadHint...)
+		b = append(b, '"')
+	}
+
+	if p.End {
+		b = append(b, "\n#EXT-X-ENDLIST"...)
+	}
+
+	return b
+}
+
+func appendMap(b []byte, uri string) []byte {
+	b = append(b, "\n#EXT-X-MAP:URI=\""...)
+	b = append(b, uri...)
+	return append(b, '"')
+}
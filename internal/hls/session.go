@@ -1,8 +1,8 @@
 package hls
 
 import (
-	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -11,10 +11,12 @@ import (
 	"github.com/AlexxIT/go2rtc/pkg/mp4"
 )
 
+// Session - HLS (TS) session, one consumer per viewer
 type Session struct {
 	cons     core.Consumer
 	id       string
-	template string
+	playlist *Playlist
+	segment  string
 	init     []byte
 	buffer   []byte
 	seq      int
@@ -28,27 +30,8 @@ func NewSession(cons core.Consumer) *Session {
 		cons: cons,
 	}
 
-	// two segments important for Chromecast
-	if _, ok := cons.(*mp4.Consumer); ok {
-		s.template = `#EXTM3U
-#EXT-X-VERSION:6
-#EXT-X-TARGETDURATION:1
-#EXT-X-MEDIA-SEQUENCE:%d
-#EXT-X-MAP:URI="init.mp4?id=` + s.id + `"
-#EXTINF:0.500,
-segment.m4s?id=` + s.id + `&n=%d
-#EXTINF:0.500,
-segment.m4s?id=` + s.id + `&n=%d`
-	} else {
-		s.template = `#EXTM3U
-#EXT-X-VERSION:3
-#EXT-X-TARGETDURATION:1
-#EXT-X-MEDIA-SEQUENCE:%d
-#EXTINF:0.500,
-segment.ts?id=` + s.id + `&n=%d
-#EXTINF:0.500,
-segment.ts?id=` + s.id + `&n=%d`
-	}
+	s.playlist = &Playlist{Version: 3}
+	s.segment = "segment.ts?id=" + s.id + "&n="
 
 	return s
 }
@@ -69,38 +52,19 @@ func (s *Session) Run() {
 }
 
 func (s *Session) Main() []byte {
-	type withCodecs interface {
-		Codecs() []*core.Codec
-	}
-
-	codecs := mp4.MimeCodecs(s.cons.(withCodecs).Codecs())
-	codecs = strings.Replace(codecs, mp4.MimeFlac, "fLaC", 1)
-
-	// bandwidth important for Safari, codecs useful for smooth playback
-	return []byte(`#EXTM3U
-#EXT-X-STREAM-INF:BANDWIDTH=192000,CODECS="` + codecs + `"
-hls/playlist.m3u8?id=` + s.id)
+	return mainPlaylist(s.cons, s.id)
 }
 
 func (s *Session) Playlist() []byte {
-	return []byte(fmt.Sprintf(s.template, s.seq, s.seq, s.seq+1))
-}
-
-func (s *Session) Init() (init []byte) {
-	for i := 0; i < 60 && init == nil; i++ {
-		if i > 0 {
-			time.Sleep(50 * time.Millisecond)
-		}
-
-		s.mu.Lock()
-		// return init only when have some buffer
-		if len(s.buffer) > 0 {
-			init = s.init
-		}
-		s.mu.Unlock()
+	playlist := *s.playlist
+	playlist.TargetDuration = 1
+	playlist.MediaSequence = s.seq
+	// two segments important for Chromecast
+	playlist.Segments = []*Segment{
+		{Duration: 0.5, URI: s.segment + strconv.Itoa(s.seq)},
+		{Duration: 0.5, URI: s.segment + strconv.Itoa(s.seq+1)},
 	}
-
-	return
+	return playlist.Bytes()
 }
 
 func (s *Session) Segment() (segment []byte) {
@@ -112,12 +76,8 @@ func (s *Session) Segment() (segment []byte) {
 		s.mu.Lock()
 		if len(s.buffer) > 0 {
 			segment = s.buffer
-			if _, ok := s.cons.(*mp4.Consumer); ok {
-				s.buffer = nil
-			} else {
-				// for TS important to start new segment with init
-				s.buffer = s.init
-			}
+			// for TS important to start new segment with init
+			s.buffer = s.init
 			s.seq++
 		}
 		s.mu.Unlock()
@@ -125,3 +85,19 @@ func (s *Session) Segment() (segment []byte) {
 
 	return
 }
+
+func mainPlaylist(cons core.Consumer, id string) []byte {
+	// bandwidth important for Safari, codecs useful for smooth playback
+	return []byte(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=192000,CODECS="` + mimeCodecs(cons) + `"
+hls/playlist.m3u8?id=` + id)
+}
+
+func mimeCodecs(cons core.Consumer) string {
+	type withCodecs interface {
+		Codecs() []*core.Codec
+	}
+
+	codecs := mp4.MimeCodecs(cons.(withCodecs).Codecs())
+	return strings.Replace(codecs, mp4.MimeFlac, "fLaC", 1)
+}
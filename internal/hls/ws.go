@@ -2,7 +2,6 @@ package hls
 
 import (
 	"errors"
-	"time"
 
 	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/api/ws"
@@ -11,41 +10,23 @@ import (
 )
 
 func handlerWSHLS(tr *ws.Transport, msg *ws.Message) error {
-	stream, _ := streams.GetOrPatch(tr.Request.URL.Query())
+	stream, _ := streams.GetOrPatchRequest(tr.Request)
 	if stream == nil {
 		return errors.New(api.StreamNotFound)
 	}
 
 	codecs := msg.String()
 	medias := mp4.ParseCodecs(codecs, true)
-	cons := mp4.NewConsumer(medias)
-	cons.FormatName = "hls/fmp4"
-	cons.WithRequest(tr.Request)
 
 	log.Trace().Msgf("[hls] new ws consumer codecs=%s", codecs)
 
-	if err := stream.AddConsumer(cons); err != nil {
+	live, err := getLive(stream, medias, tr.Request)
+	if err != nil {
 		log.Error().Err(err).Caller().Send()
 		return err
 	}
 
-	session := NewSession(cons)
-
-	session.alive = time.AfterFunc(keepalive, func() {
-		sessionsMu.Lock()
-		delete(sessions, session.id)
-		sessionsMu.Unlock()
-
-		stream.RemoveConsumer(cons)
-	})
-
-	sessionsMu.Lock()
-	sessions[session.id] = session
-	sessionsMu.Unlock()
-
-	go session.Run()
-
-	main := session.Main()
+	main := live.Main()
 	tr.Write(&ws.Message{Type: "hls", Value: string(main)})
 
 	return nil
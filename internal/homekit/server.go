@@ -16,6 +16,7 @@ import (
 	"sync"
 
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/ffmpeg"
 	srtp2 "github.com/AlexxIT/go2rtc/internal/srtp"
 	"github.com/AlexxIT/go2rtc/internal/streams"
@@ -149,6 +150,10 @@ func (s *server) AddConn(v any) {
 	s.mu.Lock()
 	s.conns = append(s.conns, v)
 	s.mu.Unlock()
+
+	if conn, ok := v.(*hap.Conn); ok {
+		s.sendEvent("connected", conn.RemoteAddr().String(), nil)
+	}
 }
 
 func (s *server) DelConn(v any) {
@@ -158,6 +163,15 @@ func (s *server) DelConn(v any) {
 		s.conns = slices.Delete(s.conns, i, i+1)
 	}
 	s.mu.Unlock()
+
+	if conn, ok := v.(*hap.Conn); ok {
+		s.sendEvent("disconnected", conn.RemoteAddr().String(), nil)
+	}
+}
+
+// sendEvent - HomeKit server events: connected, disconnected, paired, unpaired
+func (s *server) sendEvent(state, source string, data any) {
+	events.Publish(&events.Event{Type: "homekit", Stream: s.stream, State: state, Source: source, Data: data})
 }
 
 func (s *server) UpdateStatus() {
@@ -203,6 +217,8 @@ func (s *server) AddPair(id string, public []byte, permissions byte) {
 		s.PatchConfig()
 	}
 	s.mu.Unlock()
+
+	s.sendEvent("paired", "", map[string]any{"client_id": id})
 }
 
 func (s *server) DelPair(id string) {
@@ -215,6 +231,8 @@ func (s *server) DelPair(id string) {
 		s.PatchConfig()
 	}
 	s.mu.Unlock()
+
+	s.sendEvent("unpaired", "", map[string]any{"client_id": id})
 }
 
 func (s *server) PatchConfig() {
@@ -49,7 +49,7 @@ func handlerKeyframe(w http.ResponseWriter, r *http.Request) {
 
 	cons := mp4.NewKeyframe(nil)
 
-	if err := stream.AddConsumer(cons); err != nil {
+	if err := stream.AddConsumerQuery(cons, query); err != nil {
 		log.Error().Err(err).Caller().Send()
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -91,7 +91,7 @@ func handlerMP4(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stream, _ := streams.GetOrPatch(query)
+	stream, _ := streams.GetOrPatchRequest(r)
 	if stream == nil {
 		http.Error(w, api.StreamNotFound, http.StatusNotFound)
 		return
@@ -103,7 +103,7 @@ func handlerMP4(w http.ResponseWriter, r *http.Request) {
 	cons.Protocol = "http"
 	cons.WithRequest(r)
 
-	if err := stream.AddConsumer(cons); err != nil {
+	if err := stream.AddConsumerQuery(cons, query); err != nil {
 		log.Error().Err(err).Caller().Send()
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -11,7 +11,7 @@ import (
 )
 
 func handlerWSMSE(tr *ws.Transport, msg *ws.Message) error {
-	stream, _ := streams.GetOrPatch(tr.Request.URL.Query())
+	stream, _ := streams.GetOrPatchRequest(tr.Request)
 	if stream == nil {
 		return errors.New(api.StreamNotFound)
 	}
@@ -43,7 +43,7 @@ func handlerWSMSE(tr *ws.Transport, msg *ws.Message) error {
 }
 
 func handlerWSMP4(tr *ws.Transport, msg *ws.Message) error {
-	stream, _ := streams.GetOrPatch(tr.Request.URL.Query())
+	stream, _ := streams.GetOrPatchRequest(tr.Request)
 	if stream == nil {
 		return errors.New(api.StreamNotFound)
 	}
@@ -4,15 +4,23 @@ import (
 	"net/http"
 
 	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/app"
 	"github.com/AlexxIT/go2rtc/internal/streams"
 	"github.com/AlexxIT/go2rtc/pkg/mpegts"
+	"github.com/rs/zerolog"
 )
 
 func Init() {
+	log = app.GetLogger("mpegts")
+
 	api.HandleFunc("api/stream.ts", apiHandle)
 	api.HandleFunc("api/stream.aac", apiStreamAAC)
+
+	initUDP()
 }
 
+var log zerolog.Logger
+
 func apiHandle(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		outputMpegTS(w, r)
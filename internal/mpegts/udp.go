@@ -0,0 +1,59 @@
+package mpegts
+
+import (
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mpegts"
+	"github.com/AlexxIT/go2rtc/pkg/udp"
+)
+
+const headerInterval = 500 * time.Millisecond
+
+func initUDP() {
+	streams.HandleFunc("udp", handleUDP)
+	streams.HandleFunc("rtp", handleUDP)
+
+	streams.HandleConsumerFunc("udp", handleUDPConsumer)
+	streams.HandleConsumerFunc("rtp", handleUDPConsumer)
+}
+
+func handleUDP(rawURL string) (core.Producer, error) {
+	conn, err := udp.Listen(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	prod, err := mpegts.Open(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	prod.Protocol = "udp"
+	prod.RemoteAddr = conn.LocalAddr().String()
+	prod.URL = rawURL
+
+	return prod, nil
+}
+
+func handleUDPConsumer(rawURL string) (core.Consumer, func(), error) {
+	cons := mpegts.NewConsumer()
+	cons.Protocol = "udp"
+	cons.URL = rawURL
+
+	run := func() {
+		conn, err := udp.Dial(rawURL)
+		if err != nil {
+			log.Warn().Err(err).Caller().Send()
+			return
+		}
+
+		// receivers can join at any time
+		_, _ = cons.WriteTo(mpegts.RepeatHeader(conn, headerInterval))
+		_ = conn.Close()
+	}
+
+	return cons, run, nil
+}
@@ -0,0 +1,84 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/AlexxIT/go2rtc/internal/app"
+)
+
+// publishDiscovery - announce stream as Home Assistant device with camera and helper entities
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+func publishDiscovery(name string) {
+	id := "go2rtc_" + objectID(name)
+	topic := baseTopic + "/" + name
+
+	device := map[string]any{
+		"identifiers":  []string{id},
+		"name":         name,
+		"manufacturer": "go2rtc",
+		"model":        "Stream",
+		"sw_version":   app.Version,
+	}
+
+	entities := []struct {
+		component string
+		suffix    string
+		config    map[string]any
+	}{
+		{"camera", "camera", map[string]any{
+			"name":  nil, // use device name
+			"topic": topic + "/snapshot",
+		}},
+		{"binary_sensor", "online", map[string]any{
+			"name":         "Online",
+			"device_class": "connectivity",
+			"state_topic":  topic + "/state",
+			"payload_on":   "online",
+			"payload_off":  "offline",
+		}},
+		{"sensor", "consumers", map[string]any{
+			"name":        "Consumers",
+			"icon":        "mdi:eye",
+			"state_class": "measurement",
+			"state_topic": topic + "/consumers",
+		}},
+		{"switch", "preload", map[string]any{
+			"name":          "Preload",
+			"icon":          "mdi:play-network",
+			"state_topic":   topic + "/preload",
+			"command_topic": topic + "/preload/set",
+		}},
+		{"button", "snapshot", map[string]any{
+			"name":          "Snapshot",
+			"icon":          "mdi:camera",
+			"command_topic": topic + "/snapshot/set",
+		}},
+		{"text", "play", map[string]any{
+			"name":          "Play audio",
+			"icon":          "mdi:speaker",
+			"command_topic": topic + "/play/set",
+		}},
+	}
+
+	for _, entity := range entities {
+		config := entity.config
+		config["unique_id"] = id + "_" + entity.suffix
+		config["availability_topic"] = baseTopic + "/status"
+		config["device"] = device
+
+		b, err := json.Marshal(config)
+		if err != nil {
+			continue
+		}
+
+		publish(discoveryPrefix+"/"+entity.component+"/"+id+"/"+entity.suffix+"/config", b, true)
+	}
+}
+
+var objectIDRe = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// objectID - Home Assistant allows only [a-zA-Z0-9_-] in discovery topic
+func objectID(name string) string {
+	return objectIDRe.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,305 @@
+package mqtt
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/events"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mqtt"
+	"github.com/rs/zerolog"
+)
+
+func Init() {
+	var cfg struct {
+		Mod struct {
+			URL             string        `yaml:"url"`
+			Topic           string        `yaml:"topic"`
+			Discovery       *bool         `yaml:"discovery"`
+			DiscoveryPrefix string        `yaml:"discovery_prefix"`
+			Snapshot        time.Duration `yaml:"snapshot"`
+			Streams         []string      `yaml:"streams"`
+		} `yaml:"mqtt"`
+	}
+
+	cfg.Mod.Topic = "go2rtc"
+	cfg.Mod.DiscoveryPrefix = "homeassistant"
+
+	app.LoadConfig(&cfg)
+
+	log = app.GetLogger("mqtt")
+
+	if cfg.Mod.URL == "" {
+		return
+	}
+
+	baseTopic = cfg.Mod.Topic
+	if cfg.Mod.Discovery == nil || *cfg.Mod.Discovery {
+		discoveryPrefix = cfg.Mod.DiscoveryPrefix
+	}
+	if names = cfg.Mod.Streams; names == nil {
+		// only streams from config, not the temporary ones
+		names = streams.GetAllNames()
+		sort.Strings(names)
+	}
+
+	events.Subscribe(onEvent)
+
+	go worker(cfg.Mod.URL)
+
+	if cfg.Mod.Snapshot > 0 {
+		go snapshotWorker(cfg.Mod.Snapshot)
+	}
+}
+
+var log zerolog.Logger
+
+var baseTopic string
+var discoveryPrefix string
+var names []string // streams for publishing
+
+var client *mqtt.Client
+var clientMu sync.Mutex
+
+var states = map[string]string{} // last published states
+var statesMu sync.Mutex
+
+// retryTimeout - delay before reconnect to the broker
+const retryTimeout = 10 * time.Second
+
+// pingInterval - MQTT client uses keepalive 30 seconds
+const pingInterval = 20 * time.Second
+
+func worker(rawURL string) {
+	clientID := "go2rtc-" + core.RandString(8, 16)
+	will := &mqtt.Will{Topic: baseTopic + "/status", Payload: []byte("offline")}
+
+	for {
+		c, err := mqtt.Dial(rawURL, clientID, will)
+		if err != nil {
+			log.Warn().Err(err).Msg("[mqtt] connect")
+			time.Sleep(retryTimeout)
+			continue
+		}
+
+		log.Debug().Msg("[mqtt] connected")
+
+		if err = serve(c); err != nil {
+			log.Warn().Err(err).Msg("[mqtt] disconnect")
+		}
+
+		clientMu.Lock()
+		client = nil
+		clientMu.Unlock()
+
+		_ = c.Close()
+
+		time.Sleep(retryTimeout)
+	}
+}
+
+func serve(c *mqtt.Client) error {
+	clientMu.Lock()
+	client = c
+
+	err := c.PublishRetain(baseTopic+"/status", []byte("online"))
+	if err == nil {
+		err = c.Subscribe(baseTopic + "/+/+/set")
+	}
+	if err == nil {
+		err = c.Subscribe(baseTopic + "/+/publish/+")
+	}
+	if err == nil && discoveryPrefix != "" {
+		// Home Assistant sends online status after restart
+		err = c.Subscribe(discoveryPrefix + "/status")
+	}
+	clientMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// publish all states again after reconnect
+	statesMu.Lock()
+	clear(states)
+	statesMu.Unlock()
+
+	publishAll()
+
+	ping := time.Now()
+
+	for {
+		topic, payload, err := c.Read()
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return err
+			}
+		} else if topic != "" {
+			go handle(topic, string(payload))
+		}
+
+		if time.Since(ping) > pingInterval {
+			clientMu.Lock()
+			err = c.Ping()
+			clientMu.Unlock()
+			if err != nil {
+				return err
+			}
+			ping = time.Now()
+		}
+	}
+}
+
+func publish(topic string, payload []byte, retain bool) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client == nil {
+		return
+	}
+
+	var err error
+	if retain {
+		err = client.PublishRetain(topic, payload)
+	} else {
+		err = client.Publish(topic, payload)
+	}
+	if err != nil {
+		log.Debug().Err(err).Msgf("[mqtt] publish topic=%s", topic)
+	}
+}
+
+func hasStream(name string) bool {
+	return slices.Contains(names, name)
+}
+
+// publishAll - publish discovery and state of all streams, after connection to the broker
+func publishAll() {
+	for _, name := range names {
+		stream := streams.Get(name)
+		if stream == nil {
+			continue
+		}
+		if discoveryPrefix != "" {
+			publishDiscovery(name)
+		}
+		publishState(name, stream)
+	}
+}
+
+func publishState(name string, stream *streams.Stream) {
+	topic := baseTopic + "/" + name
+
+	preload := "OFF"
+	if streams.HasPreload(stream) {
+		preload = "ON"
+	}
+
+	publishChanged(topic+"/state", stream.Status())
+	publishChanged(topic+"/consumers", strconv.Itoa(stream.ConsumersCount()))
+	publishChanged(topic+"/preload", preload)
+}
+
+// publishChanged - publish retained value only if it was changed
+func publishChanged(topic, value string) {
+	statesMu.Lock()
+	changed := states[topic] != value
+	states[topic] = value
+	statesMu.Unlock()
+
+	if changed {
+		publish(topic, []byte(value), true)
+	}
+}
+
+func onEvent(event *events.Event) {
+	switch event.Type {
+	case streams.EventProducer, streams.EventConsumer:
+	default:
+		return
+	}
+
+	if event.Stream == "" || !hasStream(event.Stream) {
+		return
+	}
+
+	if stream := streams.Get(event.Stream); stream != nil {
+		publishState(event.Stream, stream)
+	}
+}
+
+// handle - commands from the broker:
+//   - go2rtc/{stream}/publish/start - start publishing to URL from payload (only from publish config)
+//   - go2rtc/{stream}/publish/stop - stop publishing to URL from payload
+//   - go2rtc/{stream}/preload/set - ON, OFF or preload query (ex. video&audio)
+//   - go2rtc/{stream}/play/set - play audio URL to the stream backchannel
+//   - go2rtc/{stream}/snapshot/set - publish snapshot to go2rtc/{stream}/snapshot
+func handle(topic, payload string) {
+	log.Trace().Msgf("[mqtt] topic=%s payload=%.100s", topic, payload)
+
+	if discoveryPrefix != "" && topic == discoveryPrefix+"/status" {
+		if payload == "online" {
+			publishAll()
+		}
+		return
+	}
+
+	// {base}/{stream}/{command}/{action}
+	parts := strings.Split(strings.TrimPrefix(topic, baseTopic+"/"), "/")
+	if len(parts) != 3 {
+		return
+	}
+
+	name, command, action := parts[0], parts[1], parts[2]
+
+	stream := streams.Get(name)
+	if stream == nil || !hasStream(name) {
+		log.Warn().Msgf("[mqtt] unknown stream: %s", name)
+		return
+	}
+
+	var err error
+
+	switch command + "/" + action {
+	case "publish/start":
+		// only targets from the config, so broker clients can't send the stream anywhere
+		if streams.IsPublishTarget(name, payload) {
+			err = stream.Publish(payload)
+		} else {
+			err = errors.New("mqtt: publish target not in config")
+		}
+	case "publish/stop":
+		err = stream.StopPublish(payload)
+	case "preload/set":
+		switch payload {
+		case "OFF":
+			err = streams.DelPreload(stream)
+		case "ON":
+			err = streams.AddPreload(stream, "")
+		default:
+			err = streams.AddPreload(stream, payload)
+		}
+		publishState(name, stream)
+	case "play/set":
+		if err = streams.Validate(payload); err == nil {
+			err = stream.Play(payload)
+		}
+	case "snapshot/set":
+		err = publishSnapshot(name, stream)
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Msgf("[mqtt] topic=%s", topic)
+	}
+}
@@ -0,0 +1,79 @@
+package mqtt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/ffmpeg"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/magic"
+	"github.com/AlexxIT/go2rtc/pkg/mjpeg"
+)
+
+// snapshotTimeout - max time to wait for a keyframe
+const snapshotTimeout = 10 * time.Second
+
+func snapshotWorker(interval time.Duration) {
+	for range time.NewTicker(interval).C {
+		for _, name := range names {
+			if stream := streams.Get(name); stream != nil {
+				if err := publishSnapshot(name, stream); err != nil {
+					log.Debug().Err(err).Str("stream", name).Msg("[mqtt] snapshot")
+				}
+			}
+		}
+	}
+}
+
+func publishSnapshot(name string, stream *streams.Stream) error {
+	clientMu.Lock()
+	connected := client != nil
+	clientMu.Unlock()
+
+	// don't start the stream if there is no connection to the broker
+	if !connected {
+		return nil
+	}
+
+	b, err := snapshot(stream)
+	if err != nil {
+		return err
+	}
+
+	publish(baseTopic+"/"+name+"/snapshot", b, true)
+	return nil
+}
+
+// snapshot - JPEG image from the stream, same as api/frame.jpeg
+func snapshot(stream *streams.Stream) ([]byte, error) {
+	cons := magic.NewKeyframe()
+
+	if err := stream.AddConsumer(cons); err != nil {
+		return nil, err
+	}
+
+	timer := time.AfterFunc(snapshotTimeout, func() {
+		stream.RemoveConsumer(cons)
+	})
+
+	once := &core.OnceBuffer{} // init and first frame
+	_, _ = cons.WriteTo(once)
+	b := once.Buffer()
+
+	timer.Stop()
+	stream.RemoveConsumer(cons)
+
+	if len(b) == 0 {
+		return nil, errors.New("mqtt: can't get snapshot")
+	}
+
+	switch cons.CodecName() {
+	case core.CodecH264, core.CodecH265:
+		return ffmpeg.JPEGWithQuery(b, nil)
+	case core.CodecJPEG:
+		return mjpeg.FixJPEG(b), nil
+	}
+
+	return b, nil
+}
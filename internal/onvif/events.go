@@ -0,0 +1,182 @@
+package onvif
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/events"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/onvif"
+)
+
+const EventType = "onvif"
+
+const (
+	eventsTermination = time.Minute
+	eventsTimeout     = 10 * time.Second // PullMessages timeout
+	eventsLimit       = 10
+	eventsRetry       = 30 * time.Second
+)
+
+// last messages for each stream by topic and source
+var messages = map[string]map[string]*onvif.Message{}
+var messagesMu sync.Mutex
+
+// initEvents - subscribe to the events of all onvif sources with events param
+func initEvents() {
+	subscribed := map[string]bool{}
+
+	for name, sources := range streams.GetAllSources() {
+		for _, source := range sources {
+			if !strings.HasPrefix(source, "onvif:") {
+				continue
+			}
+
+			u, err := url.Parse(source)
+			if err != nil || !u.Query().Has("events") {
+				continue
+			}
+
+			// one subscription per camera for each stream
+			if key := name + "/" + u.Host; !subscribed[key] {
+				subscribed[key] = true
+				go eventsWorker(name, source, u.Host)
+			}
+		}
+	}
+}
+
+func eventsWorker(name, rawURL, host string) {
+	stream := streams.Get(name)
+
+	// stop when the stream is deleted or its onvif source is changed
+	active := func() bool {
+		return stream != nil && streams.Get(name) == stream && slices.Contains(stream.Sources(), rawURL)
+	}
+
+	for active() {
+		client, err := onvif.NewClient(rawURL)
+		if err == nil {
+			if !client.HasEvents() {
+				log.Warn().Msgf("[onvif] events unsupported stream=%s", name)
+				return
+			}
+			err = pullEvents(name, host, client, active)
+		}
+
+		if err != nil {
+			log.Debug().Err(err).Msgf("[onvif] events stream=%s", name)
+		}
+
+		time.Sleep(eventsRetry)
+	}
+}
+
+func pullEvents(name, host string, client *onvif.Client, active func() bool) error {
+	address, err := client.CreatePullPointSubscription(eventsTermination)
+	if err != nil {
+		return err
+	}
+
+	defer client.Unsubscribe(address)
+
+	log.Debug().Msgf("[onvif] events subscribed stream=%s", name)
+
+	renew := time.Now().Add(eventsTermination / 2)
+
+	for active() {
+		msgs, err := client.PullMessages(address, eventsTimeout, eventsLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			sendEvent(name, host, msg)
+		}
+
+		if time.Now().After(renew) {
+			if err = client.Renew(address, eventsTermination); err != nil {
+				return err
+			}
+			renew = time.Now().Add(eventsTermination / 2)
+		}
+	}
+
+	return nil
+}
+
+func sendEvent(name, host string, msg *onvif.Message) {
+	log.Trace().Msgf("[onvif] event stream=%s topic=%s state=%s", name, msg.Topic, msg.State)
+
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+
+	key := msg.Topic
+	for _, k := range sortedKeys(msg.Source) {
+		key += "/" + msg.Source[k]
+	}
+
+	messagesMu.Lock()
+	if messages[name] == nil {
+		messages[name] = map[string]*onvif.Message{}
+	}
+	messages[name][key] = msg
+	messagesMu.Unlock()
+
+	events.Publish(&events.Event{
+		Type:   EventType,
+		Stream: name,
+		Source: host,
+		State:  msg.State,
+		Data:   msg,
+		Time:   msg.Time,
+	})
+}
+
+// apiEvents - last messages from the cameras, for the stream or for all streams
+func apiEvents(w http.ResponseWriter, r *http.Request) {
+	src := r.URL.Query().Get("src")
+
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	if src == "" {
+		items := map[string][]*onvif.Message{}
+		for name, msgs := range messages {
+			items[name] = sortedMessages(msgs)
+		}
+		api.ResponseJSON(w, items)
+		return
+	}
+
+	if streams.Get(src) == nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	api.ResponseJSON(w, sortedMessages(messages[src]))
+}
+
+func sortedMessages(msgs map[string]*onvif.Message) []*onvif.Message {
+	items := make([]*onvif.Message, 0, len(msgs))
+	for _, k := range sortedKeys(msgs) {
+		items = append(items, msgs[k])
+	}
+	return items
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -22,12 +22,18 @@ func Init() {
 	log = app.GetLogger("onvif")
 
 	streams.HandleFunc("onvif", streamOnvif)
+	streams.HandlePTZFunc("onvif", ptzOnvif)
 
 	// ONVIF server on all suburls
 	api.HandleFunc("/onvif/", onvifDeviceService)
 
 	// ONVIF client autodiscovery
 	api.HandleFunc("api/onvif", apiOnvif)
+
+	// ONVIF events from the cameras
+	api.HandleFunc("api/onvif/events", apiEvents)
+
+	initEvents()
 }
 
 var log zerolog.Logger
@@ -0,0 +1,73 @@
+package onvif
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/onvif"
+)
+
+// ptz - ONVIF client with selected profile, cached per source
+type ptz struct {
+	client *onvif.Client
+	token  string
+}
+
+var ptzs = map[string]*ptz{}
+var ptzsMu sync.Mutex
+
+func ptzOnvif(rawURL string) (core.PTZ, error) {
+	ptzsMu.Lock()
+	defer ptzsMu.Unlock()
+
+	if p := ptzs[rawURL]; p != nil {
+		return p, nil
+	}
+
+	client, err := onvif.NewClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.HasPTZ() {
+		return nil, errors.New("onvif: PTZ unsupported")
+	}
+
+	token, err := client.GetProfileToken()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ptz{client: client, token: token}
+	ptzs[rawURL] = p
+	return p, nil
+}
+
+func (p *ptz) PTZMove(mode string, pan, tilt, zoom float64) error {
+	switch mode {
+	case core.PTZContinuous:
+		return p.client.ContinuousMove(p.token, pan, tilt, zoom)
+	case core.PTZRelative:
+		return p.client.RelativeMove(p.token, pan, tilt, zoom)
+	case core.PTZAbsolute:
+		return p.client.AbsoluteMove(p.token, pan, tilt, zoom)
+	}
+	return errors.New("onvif: unsupported move mode: " + mode)
+}
+
+func (p *ptz) PTZStop() error {
+	return p.client.Stop(p.token)
+}
+
+func (p *ptz) PTZGotoPreset(token string) error {
+	return p.client.GotoPreset(p.token, token)
+}
+
+func (p *ptz) PTZGetPresets() ([]core.PTZPreset, error) {
+	return p.client.GetPresets(p.token)
+}
+
+func (p *ptz) PTZSetPreset(name string) (string, error) {
+	return p.client.SetPreset(p.token, name)
+}
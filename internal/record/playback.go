@@ -0,0 +1,425 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/hls"
+	"github.com/AlexxIT/go2rtc/pkg/iso"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+)
+
+// maxGap - segments with a bigger gap between them are from different recording sessions
+const maxGap = time.Second
+
+type Range struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Timeline - return continuous recorded ranges for the stream
+func Timeline(name string) ([]*Range, error) {
+	segments, err := ListSegments(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []*Range
+	for _, segment := range segments {
+		if n := len(ranges); n > 0 && segment.Start.Sub(ranges[n-1].End) <= maxGap {
+			ranges[n-1].End = segment.End
+		} else {
+			ranges = append(ranges, &Range{Start: segment.Start, End: segment.End})
+		}
+	}
+	return ranges, nil
+}
+
+// apiPlayback - timeline of recordings for all streams or for src streams
+func apiPlayback(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["src"]
+	if names == nil {
+		names = ListStreams()
+	}
+
+	timeline := make(map[string][]*Range, len(names))
+	for _, name := range names {
+		ranges, err := Timeline(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		timeline[name] = ranges
+	}
+
+	api.ResponseJSON(w, timeline)
+}
+
+func apiPlaybackM3U8(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	query := r.URL.Query()
+	src := query.Get("src")
+
+	segments, err := findSegments(src, query.Get("start"), query.Get("end"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if segments == nil {
+		http.Error(w, "no recordings", http.StatusNotFound)
+		return
+	}
+
+	prefix := "playback/"
+	suffix := "?src=" + url.QueryEscape(src) + "&segment="
+
+	playlist := &hls.Playlist{
+		Version: 7,
+		Type:    hls.PlaylistTypeVOD,
+		Map:     prefix + "init.mp4" + suffix + segments[0].Name,
+		End:     true,
+	}
+
+	for i, segment := range segments {
+		item := &hls.Segment{
+			Duration: segment.Duration().Seconds(),
+			URI:      prefix + "segment.m4s" + suffix + segment.Name,
+		}
+
+		// new recording session has new timestamps and can have new codecs
+		if i > 0 && segment.Start.Sub(segments[i-1].End) > maxGap {
+			item.Discontinuity = true
+			item.Map = prefix + "init.mp4" + suffix + segment.Name
+		}
+
+		if d := int(math.Ceil(item.Duration)); d > playlist.TargetDuration {
+			playlist.TargetDuration = d
+		}
+
+		playlist.Segments = append(playlist.Segments, item)
+	}
+
+	api.Response(w, playlist.Bytes(), "application/vnd.apple.mpegurl")
+}
+
+func apiPlaybackInit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	init, _, err := readSegment(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	api.Response(w, init, "video/mp4")
+}
+
+func apiPlaybackSegment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	_, data, err := readSegment(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	api.Response(w, data, "video/iso.segment")
+}
+
+// apiPlaybackMP4 - export time range as a single MP4 file
+func apiPlaybackMP4(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := parseRange(query.Get("start"), query.Get("end"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	segments, err := FindSegments(query.Get("src"), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if segments == nil {
+		http.Error(w, "no recordings", http.StatusNotFound)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "video/mp4")
+
+	if filename := query.Get("filename"); filename != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	exp := &exporter{wr: w, from: from, to: to}
+	for i, segment := range segments {
+		// new recording session has new timestamps
+		if i > 0 && segment.Start.Sub(segments[i-1].End) > maxGap {
+			exp.reset()
+		}
+
+		if err = exp.write(segment); err != nil {
+			if err != io.EOF {
+				log.Warn().Err(err).Caller().Send()
+			}
+			return
+		}
+	}
+}
+
+func findSegments(name, start, end string) ([]*Segment, error) {
+	from, to, err := parseRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return FindSegments(name, from, to)
+}
+
+// parseRange - support unix time in seconds and RFC 3339 time.
+// Empty start means from the beginning and empty end means until now.
+func parseRange(start, end string) (from, to time.Time, err error) {
+	if start != "" {
+		if from, err = parseTime(start); err != nil {
+			return
+		}
+	}
+	if end != "" {
+		to, err = parseTime(end)
+	} else {
+		to = time.Now()
+	}
+	return
+}
+
+func parseTime(s string) (time.Time, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(i, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// readSegment - read segment file from the request and split it to init and data
+func readSegment(r *http.Request) (init, data []byte, err error) {
+	query := r.URL.Query()
+
+	filename := query.Get("segment")
+	if filepath.Base(filename) != filename || !strings.HasSuffix(filename, fileExt) {
+		return nil, nil, errors.New("record: wrong segment name")
+	}
+
+	b, err := os.ReadFile(filepath.Join(streamPath(query.Get("src")), filename))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := initSize(b)
+	return b[:i], b[i:], nil
+}
+
+// initSize - size of ftyp+moov atoms at the beginning of the file
+func initSize(b []byte) (i int) {
+	for i+8 <= len(b) {
+		switch string(b[i+4 : i+8]) {
+		case iso.Ftyp, iso.Moov:
+			i += int(binary.BigEndian.Uint32(b[i:]))
+		default:
+			return
+		}
+	}
+	return
+}
+
+// exporter - join segments to one fMP4 file with continuous timestamps
+type exporter struct {
+	wr       io.Writer
+	from, to time.Time
+
+	init    []byte
+	started bool
+
+	timescales map[uint32]uint32
+	offsets    map[uint32]int64  // decode time offset for each track
+	next       map[uint32]uint64 // next decode time for each track
+}
+
+// reset - timestamps will be recalculated for next recording session
+func (e *exporter) reset() {
+	e.offsets = nil
+}
+
+func (e *exporter) write(segment *Segment) error {
+	b, err := os.ReadFile(segment.Path)
+	if err != nil {
+		return err
+	}
+
+	type fragment struct {
+		data     []byte
+		keyframe bool
+		time     time.Time
+	}
+
+	var fragments []*fragment
+	var first map[uint32]uint64 // first decode time in segment for each track
+
+	seg := &mp4.Segmenter{}
+	seg.OnFragment = func(data []byte, keyframe bool) error {
+		trackID, dts, _ := fragmentInfo(data)
+		if first == nil {
+			first = map[uint32]uint64{}
+		}
+		if _, ok := first[trackID]; !ok {
+			first[trackID] = dts
+		}
+		ts := time.Duration(dts-first[trackID]) * time.Second / time.Duration(e.timescale(trackID))
+		fragments = append(fragments, &fragment{data: data, keyframe: keyframe, time: segment.Start.Add(ts)})
+		return nil
+	}
+	seg.OnInit = func(init []byte) {
+		e.timescales = timescales(init)
+	}
+	_, _ = seg.Write(b)
+
+	init := seg.Init()
+	if e.init == nil {
+		if _, err = e.wr.Write(init); err != nil {
+			return err
+		}
+		e.init = init
+		e.next = map[uint32]uint64{}
+	} else if !bytes.Equal(e.init, init) {
+		return errors.New("record: segments with different codecs")
+	}
+
+	var i int
+	if !e.started {
+		// seek to last keyframe before start time
+		for j, frag := range fragments {
+			if frag.time.After(e.from) {
+				break
+			}
+			if frag.keyframe {
+				i = j
+			}
+		}
+		e.started = true
+	}
+
+	for _, frag := range fragments[i:] {
+		if frag.time.After(e.to) {
+			return io.EOF
+		}
+
+		e.rewrite(frag.data)
+
+		if _, err = e.wr.Write(frag.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *exporter) timescale(trackID uint32) uint32 {
+	if ts := e.timescales[trackID]; ts != 0 {
+		return ts
+	}
+	return 1000
+}
+
+// rewrite - change fragment decode time so that it continues previous fragments
+func (e *exporter) rewrite(fragment []byte) {
+	trackID, dts, duration := fragmentInfo(fragment)
+
+	if e.offsets == nil {
+		e.offsets = map[uint32]int64{}
+	}
+
+	offset, ok := e.offsets[trackID]
+	if !ok {
+		offset = int64(e.next[trackID]) - int64(dts)
+		e.offsets[trackID] = offset
+	}
+
+	dts = uint64(int64(dts) + offset)
+	e.next[trackID] = dts + uint64(duration)
+
+	// tfdt: version (1), flags (3), decode time (4 for version 0 or 8 for version 1)
+	if i := findAtom(fragment, iso.Moof, iso.MoofTraf, iso.MoofTrafTfdt); i > 0 {
+		if fragment[i] == 0 {
+			if i+8 <= len(fragment) {
+				binary.BigEndian.PutUint32(fragment[i+4:], uint32(dts))
+			}
+		} else if i+12 <= len(fragment) {
+			binary.BigEndian.PutUint64(fragment[i+4:], dts)
+		}
+	}
+}
+
+// findAtom - position of the atom data by path of atom names, -1 if not found
+func findAtom(b []byte, names ...string) int {
+	for i := 0; i+8 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[i:]))
+		if size < 8 || i+size > len(b) {
+			return -1
+		}
+		if string(b[i+4:i+8]) == names[0] {
+			if len(names) == 1 {
+				return i + 8
+			}
+			if j := findAtom(b[i+8:i+size], names[1:]...); j >= 0 {
+				return i + 8 + j
+			}
+			return -1
+		}
+		i += size
+	}
+	return -1
+}
+
+// fragmentInfo - return track ID, decode time and duration of the fragment
+func fragmentInfo(fragment []byte) (trackID uint32, dts uint64, duration uint32) {
+	atoms, _ := iso.DecodeAtoms(fragment)
+	for _, atom := range atoms {
+		switch atom := atom.(type) {
+		case *iso.AtomTfhd:
+			trackID = atom.TrackID
+			duration = atom.SampleDuration
+		case *iso.AtomTfdt:
+			dts = atom.DecodeTime
+		}
+	}
+	return
+}
+
+// timescales - return timescale for each track from init
+func timescales(init []byte) map[uint32]uint32 {
+	atoms, _ := iso.DecodeAtoms(init)
+
+	var trackID uint32
+	items := map[uint32]uint32{}
+	for _, atom := range atoms {
+		switch atom := atom.(type) {
+		case *iso.AtomTkhd:
+			trackID = atom.TrackID
+		case *iso.AtomMdhd:
+			items[trackID] = atom.TimeScale
+		}
+	}
+	return items
+}
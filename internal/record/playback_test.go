@@ -0,0 +1,123 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/iso"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+// testSegment - 2 seconds of 25 fps video with keyframe every second
+func testSegment(t *testing.T, muxer *mp4.Muxer, frame *int, start time.Time) *Segment {
+	init, err := muxer.GetInit()
+	require.Nil(t, err)
+
+	b := init
+	for i := 0; i < 50; i++ {
+		*frame++
+		pkt := &rtp.Packet{Header: rtp.Header{Timestamp: uint32(*frame * 3600)}}
+		if i%25 == 0 {
+			pkt.Payload = []byte{0, 0, 0, 6, 0x65, 't', 'f', 'd', 't', 0} // tfdt inside mdat
+		} else {
+			pkt.Payload = []byte{0, 0, 0, 2, 0x41, 0}
+		}
+		b = append(b, muxer.GetPayload(0, pkt)...)
+	}
+
+	name := start.Format("150405") + fileExt
+	path := filepath.Join(t.TempDir(), name)
+	require.Nil(t, os.WriteFile(path, b, 0644))
+
+	return &Segment{Name: name, Start: start, End: start.Add(2 * time.Second), Path: path}
+}
+
+func newTestMuxer() *mp4.Muxer {
+	muxer := &mp4.Muxer{}
+	muxer.AddTrack(&core.Codec{Name: core.CodecH264, ClockRate: 90000})
+	return muxer
+}
+
+// exportTimes - decode times of the exported fragments
+func exportTimes(t *testing.T, from time.Time, segments ...*Segment) (times []uint64) {
+	buf := bytes.NewBuffer(nil)
+	exp := &exporter{wr: buf, from: from, to: from.Add(time.Hour)}
+	for i, segment := range segments {
+		if i > 0 && segment.Start.Sub(segments[i-1].End) > maxGap {
+			exp.reset()
+		}
+		require.Nil(t, exp.write(segment))
+	}
+
+	seg := &mp4.Segmenter{OnFragment: func(fragment []byte, _ bool) error {
+		if len(times)%25 == 0 {
+			require.True(t, bytes.HasSuffix(fragment, []byte("tfdt\x00"))) // keyframe mdat unchanged
+		}
+		_, dts, _ := fragmentInfo(fragment)
+		times = append(times, dts)
+		return nil
+	}}
+	_, _ = seg.Write(buf.Bytes())
+	return
+}
+
+func TestExport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	var frame int
+	muxer := newTestMuxer()
+	seg1 := testSegment(t, muxer, &frame, start)
+	seg2 := testSegment(t, muxer, &frame, start.Add(2*time.Second))
+
+	// new recording session after the gap starts with new timestamps
+	frame = 0
+	seg3 := testSegment(t, newTestMuxer(), &frame, start.Add(10*time.Second))
+
+	times := exportTimes(t, start, seg1, seg2, seg3)
+	require.Len(t, times, 150)
+	for i, dts := range times {
+		require.Equal(t, uint64(i*3600), dts)
+	}
+
+	// seek to the last keyframe before start time
+	times = exportTimes(t, start.Add(1500*time.Millisecond), seg1, seg2)
+	require.Len(t, times, 75)
+	for i, dts := range times {
+		require.Equal(t, uint64(i*3600), dts)
+	}
+}
+
+func TestRewriteTfdtV0(t *testing.T) {
+	atom := func(name string, data ...[]byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, uint32(8+len(bytes.Join(data, nil))))
+		return append(append(b, name...), bytes.Join(data, nil)...)
+	}
+
+	fragment := append(
+		atom(iso.Moof,
+			atom(iso.MoofMfhd, []byte{0, 0, 0, 0, 0, 0, 0, 1}),
+			atom(iso.MoofTraf,
+				atom(iso.MoofTrafTfhd, []byte{0, 0, 0, 0x08, 0, 0, 0, 1, 0, 0, 0x0E, 0x10}), // track 1, duration 3600
+				atom(iso.MoofTrafTfdt, []byte{0, 0, 0, 0, 0, 0, 0x03, 0xE8}),                // version 0, time 1000
+			),
+		),
+		atom(iso.Mdat, []byte("tfdt0000"))...,
+	)
+
+	exp := &exporter{next: map[uint32]uint64{1: 500}}
+	exp.rewrite(fragment)
+
+	trackID, dts, duration := fragmentInfo(fragment)
+	require.Equal(t, uint32(1), trackID)
+	require.Equal(t, uint64(500), dts)
+	require.Equal(t, uint32(3600), duration)
+	require.Equal(t, uint64(4100), exp.next[1])
+	require.True(t, bytes.HasSuffix(fragment, []byte("tfdt0000")))
+}
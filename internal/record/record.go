@@ -0,0 +1,218 @@
+package record
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/rs/zerolog"
+)
+
+const (
+	ModeContinuous = "continuous"
+	ModeEvent      = "event"
+)
+
+type Config struct {
+	Mode            string        `yaml:"mode"`
+	Filter          string        `yaml:"filter"`
+	SegmentDuration time.Duration `yaml:"segment_duration"`
+	MaxAge          time.Duration `yaml:"max_age"`
+	MaxSize         int64         `yaml:"max_size"` // in megabytes
+}
+
+func Init() {
+	var cfg struct {
+		Mod struct {
+			Path    string `yaml:"path"`
+			Config  `yaml:",inline"`
+			Streams map[string]*Config `yaml:"streams"`
+		} `yaml:"record"`
+	}
+
+	// default config
+	cfg.Mod.Path = "record"
+	cfg.Mod.Filter = "mp4=flac"
+	cfg.Mod.SegmentDuration = time.Minute
+
+	app.LoadConfig(&cfg)
+
+	log = app.GetLogger("record")
+
+	rootPath = cfg.Mod.Path
+	defaults = cfg.Mod.Config
+
+	api.HandleFunc("api/record", apiRecord)
+
+	api.HandleFunc("api/playback", apiPlayback)
+	api.HandleFunc("api/playback.m3u8", apiPlaybackM3U8)
+	api.HandleFunc("api/playback.mp4", apiPlaybackMP4)
+	api.HandleFunc("api/playback/init.mp4", apiPlaybackInit)
+	api.HandleFunc("api/playback/segment.m4s", apiPlaybackSegment)
+
+	for name, conf := range cfg.Mod.Streams {
+		configs[name] = withDefaults(conf)
+	}
+
+	for name, conf := range configs {
+		if conf.Mode != ModeContinuous {
+			continue
+		}
+
+		if streams.Get(name) == nil {
+			log.Warn().Msgf("[record] missing stream: %s", name)
+			continue
+		}
+
+		_ = Start(name, 0)
+	}
+
+	go cleanupWorker()
+}
+
+var log zerolog.Logger
+
+var rootPath string
+var defaults Config
+var configs = map[string]*Config{}
+
+var recorders = map[string]*Recorder{}
+var recordersMu sync.Mutex
+
+// Start - start recording for the stream. Zero duration means recording until Stop.
+// Repeated calls for an active event recording will extend its duration.
+func Start(name string, duration time.Duration) error {
+	stream := streams.Get(name)
+	if stream == nil {
+		return errStreamNotFound
+	}
+
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	rec := recorders[name]
+	if rec == nil {
+		rec = NewRecorder(name, stream, getConfig(name))
+		recorders[name] = rec
+		go func() {
+			rec.Run()
+
+			recordersMu.Lock()
+			if recorders[name] == rec {
+				delete(recorders, name)
+			}
+			recordersMu.Unlock()
+		}()
+
+		rec.StopAfter(duration)
+	} else {
+		rec.Extend(duration)
+	}
+
+	return nil
+}
+
+// Stop - stop active recording for the stream
+func Stop(name string) bool {
+	recordersMu.Lock()
+	rec := recorders[name]
+	delete(recorders, name)
+	recordersMu.Unlock()
+
+	if rec == nil {
+		return false
+	}
+
+	rec.Stop()
+	return true
+}
+
+func IsRecording(name string) bool {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	return recorders[name] != nil
+}
+
+func getConfig(name string) *Config {
+	if conf := configs[name]; conf != nil {
+		return conf
+	}
+	conf := defaults
+	conf.Mode = ModeEvent
+	return &conf
+}
+
+func withDefaults(conf *Config) *Config {
+	if conf == nil {
+		conf = &Config{}
+	}
+	if conf.Mode == "" {
+		conf.Mode = ModeContinuous
+	}
+	if conf.Filter == "" {
+		conf.Filter = defaults.Filter
+	}
+	if conf.SegmentDuration == 0 {
+		conf.SegmentDuration = defaults.SegmentDuration
+	}
+	if conf.MaxAge == 0 {
+		conf.MaxAge = defaults.MaxAge
+	}
+	if conf.MaxSize == 0 {
+		conf.MaxSize = defaults.MaxSize
+	}
+	return conf
+}
+
+func apiRecord(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	src := query.Get("src")
+
+	switch r.Method {
+	case "GET":
+		type info struct {
+			Mode      string     `json:"mode,omitempty"`
+			Recording bool       `json:"recording"`
+			Segments  []*Segment `json:"segments"`
+		}
+
+		names := query["src"]
+		if names == nil {
+			names = ListStreams()
+		}
+
+		items := make(map[string]*info, len(names))
+		for _, name := range names {
+			segments, err := ListSegments(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			items[name] = &info{
+				Mode:      getConfig(name).Mode,
+				Recording: IsRecording(name),
+				Segments:  segments,
+			}
+		}
+
+		api.ResponseJSON(w, items)
+
+	case "POST":
+		duration := time.Duration(core.Atoi(query.Get("duration"))) * time.Second
+		if err := Start(src, duration); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+
+	case "DELETE":
+		if !Stop(src) {
+			http.Error(w, "", http.StatusNotFound)
+		}
+
+	default:
+		http.Error(w, "", http.StatusMethodNotAllowed)
+	}
+}
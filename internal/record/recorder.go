@@ -0,0 +1,197 @@
+package record
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/mp4"
+)
+
+var errStreamNotFound = errors.New(api.StreamNotFound)
+
+type Recorder struct {
+	name   string
+	stream *streams.Stream
+	conf   *Config
+
+	cons  *mp4.Consumer
+	file  *os.File
+	start time.Time // current segment start time
+	timer *time.Timer
+	done  bool
+	mu    sync.Mutex
+}
+
+func NewRecorder(name string, stream *streams.Stream, conf *Config) *Recorder {
+	return &Recorder{name: name, stream: stream, conf: conf}
+}
+
+// Run - record stream until Stop. Reconnect to the stream if it fails.
+func (r *Recorder) Run() {
+	log.Debug().Msgf("[record] start stream=%s", r.name)
+
+	for {
+		if err := r.record(); err != nil {
+			log.Warn().Err(err).Msgf("[record] stream=%s", r.name)
+		}
+
+		r.mu.Lock()
+		done := r.done
+		r.mu.Unlock()
+
+		if done {
+			break
+		}
+
+		// TODO: more smart retry
+		time.Sleep(5 * time.Second)
+	}
+
+	log.Debug().Msgf("[record] stop stream=%s", r.name)
+}
+
+// StopAfter - schedule stop after duration, zero duration cancels scheduled stop
+func (r *Recorder) StopAfter(duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+
+	if duration > 0 {
+		r.timer = time.AfterFunc(duration, func() {
+			recordersMu.Lock()
+			if recorders[r.name] == r {
+				delete(recorders, r.name)
+			}
+			recordersMu.Unlock()
+
+			r.Stop()
+		})
+	}
+}
+
+// Extend - extend scheduled stop, it won't limit indefinite recording
+func (r *Recorder) Extend(duration time.Duration) {
+	r.mu.Lock()
+	limited := r.timer != nil
+	r.mu.Unlock()
+
+	if limited || duration == 0 {
+		r.StopAfter(duration)
+	}
+}
+
+func (r *Recorder) Stop() {
+	r.mu.Lock()
+	r.done = true
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	cons := r.cons
+	r.mu.Unlock()
+
+	if cons != nil {
+		r.stream.RemoveConsumer(cons)
+	}
+}
+
+func (r *Recorder) record() error {
+	query, err := url.ParseQuery(r.conf.Filter)
+	if err != nil {
+		return err
+	}
+
+	cons := mp4.NewConsumer(mp4.ParseQuery(query))
+	cons.FormatName = "record"
+
+	if err = r.stream.AddConsumer(cons); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.done {
+		r.mu.Unlock()
+		r.stream.RemoveConsumer(cons)
+		return nil
+	}
+	r.cons = cons
+	r.mu.Unlock()
+
+	seg := &mp4.Segmenter{}
+	seg.OnFragment = func(fragment []byte, keyframe bool) error {
+		return r.writeFragment(seg.Init(), fragment, keyframe)
+	}
+
+	_, err = cons.WriteTo(seg)
+
+	r.stream.RemoveConsumer(cons)
+
+	r.mu.Lock()
+	r.cons = nil
+	r.closeFile()
+	r.mu.Unlock()
+
+	return err
+}
+
+func (r *Recorder) writeFragment(init, fragment []byte, keyframe bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if keyframe && (r.file == nil || time.Since(r.start) >= r.conf.SegmentDuration) {
+		r.closeFile()
+
+		if err := r.openFile(init); err != nil {
+			return err
+		}
+	}
+
+	if r.file == nil {
+		return nil // wait first keyframe
+	}
+
+	_, err := r.file.Write(fragment)
+	return err
+}
+
+func (r *Recorder) openFile(init []byte) error {
+	dir := streamPath(r.name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	r.start = time.Now()
+
+	path := filepath.Join(dir, r.start.UTC().Format(fileLayout)+fileExt)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(init); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	log.Trace().Msgf("[record] new segment %s", path)
+
+	r.file = file
+	return nil
+}
+
+func (r *Recorder) closeFile() {
+	if r.file != nil {
+		_ = r.file.Close()
+		r.file = nil
+	}
+}
@@ -0,0 +1,184 @@
+package record
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// file name is segment start time in UTC
+const (
+	fileLayout = "2006-01-02_15-04-05.000"
+	fileExt    = ".mp4"
+)
+
+type Segment struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Size  int64     `json:"size"`
+
+	Path string `json:"-"`
+}
+
+func (s *Segment) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// streamPath - stream folder inside the root path. Name is escaped, so it can't have separators,
+// and names from dots only (".", "..") are escaped too, so they can't leave the root path.
+func streamPath(name string) string {
+	dir := url.PathEscape(name)
+	if strings.Trim(dir, ".") == "" {
+		dir = strings.ReplaceAll(dir, ".", "%2E")
+	}
+	return filepath.Join(rootPath, dir)
+}
+
+// ListStreams - return names of all streams with recordings or recording config
+func ListStreams() []string {
+	var names []string
+
+	if entries, err := os.ReadDir(rootPath); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if name, err := url.PathUnescape(entry.Name()); err == nil {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for name := range configs {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ListSegments - return all stream segments sorted by start time.
+// Segment end time is the file modification time.
+func ListSegments(name string) ([]*Segment, error) {
+	dir := streamPath(name)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var segments []*Segment
+
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(filename, fileExt) {
+			continue
+		}
+
+		start, err := time.ParseInLocation(fileLayout, strings.TrimSuffix(filename, fileExt), time.UTC)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, &Segment{
+			Name:  filename,
+			Start: start,
+			End:   info.ModTime().UTC(),
+			Size:  info.Size(),
+			Path:  filepath.Join(dir, filename),
+		})
+	}
+
+	// file names have sortable format, but better to be sure
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Start.Before(segments[j].Start)
+	})
+
+	return segments, nil
+}
+
+// FindSegments - return stream segments that overlap with time range
+func FindSegments(name string, from, to time.Time) ([]*Segment, error) {
+	segments, err := ListSegments(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*Segment
+	for _, segment := range segments {
+		if segment.End.Before(from) || segment.Start.After(to) {
+			continue
+		}
+		found = append(found, segment)
+	}
+	return found, nil
+}
+
+const cleanupInterval = time.Minute
+
+func cleanupWorker() {
+	for {
+		for _, name := range ListStreams() {
+			cleanup(name, getConfig(name))
+		}
+
+		time.Sleep(cleanupInterval)
+	}
+}
+
+// cleanup - remove segments older than MaxAge and oldest segments above MaxSize.
+// The last segment is never removed because it can be in recording right now.
+func cleanup(name string, conf *Config) {
+	if conf.MaxAge == 0 && conf.MaxSize == 0 {
+		return
+	}
+
+	segments, err := ListSegments(name)
+	if err != nil || len(segments) < 2 {
+		return
+	}
+
+	segments = segments[:len(segments)-1]
+
+	var total int64
+	for _, segment := range segments {
+		total += segment.Size
+	}
+
+	maxSize := conf.MaxSize << 20 // megabytes to bytes
+	deadline := time.Now().Add(-conf.MaxAge)
+
+	for _, segment := range segments {
+		if conf.MaxAge > 0 && segment.End.Before(deadline) {
+			// remove by age
+		} else if maxSize > 0 && total > maxSize {
+			// remove by size
+		} else {
+			break
+		}
+
+		if err = os.Remove(segment.Path); err != nil {
+			log.Warn().Err(err).Caller().Send()
+			return
+		}
+
+		log.Trace().Msgf("[record] remove segment %s", segment.Path)
+
+		total -= segment.Size
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/streams"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/ring"
@@ -14,7 +15,24 @@ import (
 
 func Init() {
 	streams.HandleFunc("ring", func(source string) (core.Producer, error) {
-		return ring.Dial(source)
+		client, err := ring.Dial(source)
+		if err != nil {
+			return nil, err
+		}
+
+		// source has refresh token, so only camera ID is used in events
+		var cameraID string
+		if u, err := url.Parse(source); err == nil {
+			cameraID = "ring:?camera_id=" + u.Query().Get("camera_id")
+		}
+
+		client.Listen(func(msg any) {
+			if method, ok := msg.(string); ok {
+				events.Publish(&events.Event{Type: "ring", Source: cameraID, State: method})
+			}
+		})
+
+		return client, nil
 	})
 
 	api.HandleFunc("api/ring", apiRing)
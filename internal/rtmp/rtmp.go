@@ -1,6 +1,8 @@
 package rtmp
 
 import (
+	"crypto/subtle"
+	"crypto/tls"
 	"errors"
 	"io"
 	"net"
@@ -9,6 +11,7 @@ import (
 	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/app"
 	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/flv"
 	"github.com/AlexxIT/go2rtc/pkg/rtmp"
@@ -18,8 +21,18 @@ import (
 func Init() {
 	var conf struct {
 		Mod struct {
-			Listen string `yaml:"listen" json:"listen"`
+			Listen      string            `yaml:"listen" json:"listen"`
+			TLSListen   string            `yaml:"tls_listen" json:"tls_listen"`
+			TLSCert     string            `yaml:"tls_cert" json:"-"`
+			TLSKey      string            `yaml:"tls_key" json:"-"`
+			Username    string            `yaml:"username" json:"-"`
+			Password    string            `yaml:"password" json:"-"`
+			PublishKeys map[string]string `yaml:"publish_keys" json:"-"`
 		} `yaml:"rtmp"`
+		API struct {
+			TLSCert string `yaml:"tls_cert"`
+			TLSKey  string `yaml:"tls_key"`
+		} `yaml:"api"`
 	}
 
 	app.LoadConfig(&conf)
@@ -36,33 +49,105 @@ func Init() {
 	streams.HandleConsumerFunc("rtmps", streamsConsumerHandle)
 	streams.HandleConsumerFunc("rtmpx", streamsConsumerHandle)
 
-	address := conf.Mod.Listen
-	if address == "" {
-		return
+	username = conf.Mod.Username
+	password = conf.Mod.Password
+	publishKeys = conf.Mod.PublishKeys
+
+	if address := conf.Mod.Listen; address != "" {
+		ln, err := net.Listen("tcp", address)
+		if err != nil {
+			log.Error().Err(err).Caller().Send()
+		} else {
+			log.Info().Str("addr", address).Msg("[rtmp] listen")
+			go serve(ln)
+		}
 	}
 
-	ln, err := net.Listen("tcp", address)
-	if err != nil {
-		log.Error().Err(err).Caller().Send()
-		return
+	if address := conf.Mod.TLSListen; address != "" {
+		// use certificate from api section by default
+		if conf.Mod.TLSCert == "" && conf.Mod.TLSKey == "" {
+			conf.Mod.TLSCert = conf.API.TLSCert
+			conf.Mod.TLSKey = conf.API.TLSKey
+		}
+
+		cert, err := api.LoadCertificate(conf.Mod.TLSCert, conf.Mod.TLSKey)
+		if err != nil {
+			log.Error().Err(err).Caller().Send()
+			return
+		}
+
+		ln, err := tls.Listen("tcp", address, &tls.Config{Certificates: []tls.Certificate{cert}})
+		if err != nil {
+			log.Error().Err(err).Caller().Send()
+			return
+		}
+
+		log.Info().Str("addr", address).Msg("[rtmp] tls listen")
+		go serve(ln)
 	}
+}
 
-	log.Info().Str("addr", address).Msg("[rtmp] listen")
+var username, password string
+var publishKeys map[string]string
 
-	go func() {
-		for {
-			conn, err := ln.Accept()
-			if err != nil {
-				return
-			}
+func serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
 
-			go func() {
-				if err = tcpHandle(conn); err != nil {
+		go func() {
+			if err = tcpHandle(conn); err != nil {
+				if errors.Is(err, rtmp.FailedAuth) {
+					log.Warn().Str("remote_addr", conn.RemoteAddr().String()).Msg("[rtmp] failed authentication")
+				} else {
 					log.Error().Err(err).Caller().Send()
 				}
-			}()
+			}
+			_ = conn.Close()
+		}()
+	}
+}
+
+// checkAuth - publish key for the stream, username and password or token from the URL query.
+// Skip check for localhost, same as RTSP server.
+func checkAuth(conn *rtmp.Conn, addr net.Addr) bool {
+	if addr, ok := addr.(*net.TCPAddr); ok && addr.IP.IsLoopback() {
+		return true
+	}
+
+	if conn.Intent == rtmp.CommandPublish {
+		// OBS: server rtmp://192.168.1.123/camera1, stream key - secret
+		// FFmpeg: rtmp://192.168.1.123/camera1/secret or rtmp://192.168.1.123/camera1?key=secret
+		if key, ok := publishKeys[conn.App]; ok {
+			return equal(conn.Stream, key) || equal(conn.Query.Get("key"), key)
+		}
+	}
+
+	user, pass := conn.Query.Get("user"), conn.Query.Get("password")
+
+	if username != "" && equal(user, username) && equal(pass, password) {
+		return true
+	}
+
+	if api.ACLEnabled() {
+		u := api.Login(user, pass, conn.Query.Get("token"))
+		if u == nil {
+			return false
 		}
-	}()
+		if conn.Intent == rtmp.CommandPublish {
+			return u.Allow(conn.App, acl.ActionPublish)
+		}
+		return u.Allow(conn.App, acl.ActionView)
+	}
+
+	return username == ""
+}
+
+// equal - constant time compare for keys and credentials
+func equal(s1, s2 string) bool {
+	return subtle.ConstantTimeCompare([]byte(s1), []byte(s2)) == 1
 }
 
 func tcpHandle(netConn net.Conn) error {
@@ -71,6 +156,12 @@ func tcpHandle(netConn net.Conn) error {
 		return err
 	}
 
+	if username != "" || publishKeys != nil || api.ACLEnabled() {
+		rtmpConn.Auth(func() bool {
+			return checkAuth(rtmpConn, netConn.RemoteAddr())
+		})
+	}
+
 	if err = rtmpConn.ReadCommands(); err != nil {
 		return err
 	}
@@ -0,0 +1,40 @@
+package rtmp
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/AlexxIT/go2rtc/pkg/rtmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAuth(t *testing.T) {
+	username, password = "admin", "pass"
+	publishKeys = map[string]string{"camera1": "secret"}
+	defer func() {
+		username, password, publishKeys = "", "", nil
+	}()
+
+	remote := &net.TCPAddr{IP: net.IPv4(192, 168, 1, 123)}
+
+	conn := &rtmp.Conn{App: "camera1", Stream: "secret", Intent: rtmp.CommandPublish, Query: url.Values{}}
+	require.True(t, checkAuth(conn, remote))
+
+	conn = &rtmp.Conn{App: "camera1", Intent: rtmp.CommandPublish, Query: url.Values{"key": {"secret"}}}
+	require.True(t, checkAuth(conn, remote))
+
+	conn = &rtmp.Conn{App: "camera1", Stream: "wrong", Intent: rtmp.CommandPublish, Query: url.Values{}}
+	require.False(t, checkAuth(conn, remote))
+	require.True(t, checkAuth(conn, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}))
+
+	// publish key doesn't replace credentials for other streams
+	conn = &rtmp.Conn{App: "camera2", Intent: rtmp.CommandPublish, Query: url.Values{}}
+	require.False(t, checkAuth(conn, remote))
+
+	conn = &rtmp.Conn{App: "camera2", Intent: rtmp.CommandPlay, Query: url.Values{"user": {"admin"}, "password": {"pass"}}}
+	require.True(t, checkAuth(conn, remote))
+
+	conn = &rtmp.Conn{App: "camera2", Intent: rtmp.CommandPlay, Query: url.Values{"user": {"admin"}, "password": {"wrong"}}}
+	require.False(t, checkAuth(conn, remote))
+}
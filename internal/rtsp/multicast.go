@@ -0,0 +1,85 @@
+package rtsp
+
+import (
+	"net"
+	"sync"
+
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/rtsp"
+)
+
+// multicastSender - one shared consumer of the stream for all multicast viewers
+type multicastSender struct {
+	cons    *rtsp.Conn
+	viewers map[*rtsp.Conn]struct{}
+}
+
+var multicastGroups map[string]string // stream name => group:port
+var multicastTTL int
+var multicastSenders = map[string]*multicastSender{}
+var multicastMu sync.Mutex
+
+func getMulticast(name string, stream *streams.Stream, viewer *rtsp.Conn) (*rtsp.Conn, error) {
+	multicastMu.Lock()
+	defer multicastMu.Unlock()
+
+	sender := multicastSenders[name]
+	if sender == nil {
+		group, err := net.ResolveUDPAddr("udp4", multicastGroups[name])
+		if err != nil {
+			return nil, err
+		}
+
+		cons, err := rtsp.NewMulticast(group, multicastTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		// same medias as the viewer, so the tracks will be the same as in the DESCRIBE response
+		for _, media := range viewer.Medias {
+			if media.Direction == core.DirectionSendonly {
+				cons.Medias = append(cons.Medias, media.Clone())
+			}
+		}
+		cons.PacketSize = viewer.PacketSize
+		cons.SessionName = viewer.SessionName
+
+		if err = stream.AddConsumer(cons); err != nil {
+			_ = cons.Stop()
+			return nil, err
+		}
+
+		log.Debug().Str("stream", name).Str("group", group.String()).Msg("[rtsp] multicast start")
+
+		sender = &multicastSender{cons: cons, viewers: map[*rtsp.Conn]struct{}{}}
+		multicastSenders[name] = sender
+	}
+
+	sender.viewers[viewer] = struct{}{}
+
+	return sender.cons, nil
+}
+
+func releaseMulticast(name string, stream *streams.Stream, viewer *rtsp.Conn) {
+	multicastMu.Lock()
+	defer multicastMu.Unlock()
+
+	sender := multicastSenders[name]
+	if sender == nil {
+		return
+	}
+
+	if _, ok := sender.viewers[viewer]; !ok {
+		return
+	}
+
+	delete(sender.viewers, viewer)
+
+	if len(sender.viewers) == 0 {
+		log.Debug().Str("stream", name).Msg("[rtsp] multicast stop")
+
+		delete(multicastSenders, name)
+		stream.RemoveConsumer(sender.cons)
+	}
+}
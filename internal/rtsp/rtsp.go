@@ -1,14 +1,18 @@
 package rtsp
 
 import (
+	"crypto/tls"
 	"errors"
 	"io"
 	"net"
 	"net/url"
 	"strings"
 
+	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/rtsp"
 	"github.com/AlexxIT/go2rtc/pkg/tcp"
@@ -18,12 +22,21 @@ import (
 func Init() {
 	var conf struct {
 		Mod struct {
-			Listen       string `yaml:"listen" json:"listen"`
-			Username     string `yaml:"username" json:"-"`
-			Password     string `yaml:"password" json:"-"`
-			DefaultQuery string `yaml:"default_query" json:"default_query"`
-			PacketSize   uint16 `yaml:"pkt_size" json:"pkt_size,omitempty"`
+			Listen       string            `yaml:"listen" json:"listen"`
+			TLSListen    string            `yaml:"tls_listen" json:"tls_listen,omitempty"`
+			TLSCert      string            `yaml:"tls_cert" json:"-"`
+			TLSKey       string            `yaml:"tls_key" json:"-"`
+			Username     string            `yaml:"username" json:"-"`
+			Password     string            `yaml:"password" json:"-"`
+			DefaultQuery string            `yaml:"default_query" json:"default_query"`
+			PacketSize   uint16            `yaml:"pkt_size" json:"pkt_size,omitempty"`
+			Multicast    map[string]string `yaml:"multicast" json:"multicast,omitempty"`
+			MulticastTTL int               `yaml:"multicast_ttl" json:"multicast_ttl,omitempty"`
 		} `yaml:"rtsp"`
+		API struct {
+			TLSCert string `yaml:"tls_cert"`
+			TLSKey  string `yaml:"tls_key"`
+		} `yaml:"api"`
 	}
 
 	// default config
@@ -35,47 +48,114 @@ func Init() {
 
 	log = app.GetLogger("rtsp")
 
+	multicastGroups = conf.Mod.Multicast
+	multicastTTL = conf.Mod.MulticastTTL
+
 	// RTSP client support
 	streams.HandleFunc("rtsp", rtspHandler)
 	streams.HandleFunc("rtsps", rtspHandler)
 	streams.HandleFunc("rtspx", rtspHandler)
 
+	// RTSP publish support (ANNOUNCE > RECORD)
+	streams.HandleConsumerFunc("rtsp", streamsConsumerHandler)
+	streams.HandleConsumerFunc("rtsps", streamsConsumerHandler)
+
 	// RTSP server support
-	address := conf.Mod.Listen
-	if address == "" {
-		return
+	if query, err := url.ParseQuery(conf.Mod.DefaultQuery); err == nil {
+		defaultMedias = ParseQuery(query)
 	}
 
-	ln, err := net.Listen("tcp", address)
-	if err != nil {
-		log.Error().Err(err).Msg("[rtsp] listen")
-		return
+	username = conf.Mod.Username
+	password = conf.Mod.Password
+	packetSize = conf.Mod.PacketSize
+
+	if address := conf.Mod.Listen; address != "" {
+		ln, err := net.Listen("tcp", address)
+		if err != nil {
+			log.Error().Err(err).Msg("[rtsp] listen")
+		} else {
+			_, Port, _ = net.SplitHostPort(address)
+
+			log.Info().Str("addr", address).Msg("[rtsp] listen")
+			go serve(ln)
+		}
 	}
 
-	_, Port, _ = net.SplitHostPort(address)
+	if address := conf.Mod.TLSListen; address != "" {
+		// use certificate from api section by default
+		if conf.Mod.TLSCert == "" && conf.Mod.TLSKey == "" {
+			conf.Mod.TLSCert = conf.API.TLSCert
+			conf.Mod.TLSKey = conf.API.TLSKey
+		}
 
-	log.Info().Str("addr", address).Msg("[rtsp] listen")
+		cert, err := api.LoadCertificate(conf.Mod.TLSCert, conf.Mod.TLSKey)
+		if err != nil {
+			log.Error().Err(err).Caller().Send()
+			return
+		}
 
-	if query, err := url.ParseQuery(conf.Mod.DefaultQuery); err == nil {
-		defaultMedias = ParseQuery(query)
+		ln, err := tls.Listen("tcp", address, &tls.Config{Certificates: []tls.Certificate{cert}})
+		if err != nil {
+			log.Error().Err(err).Msg("[rtsp] tls listen")
+			return
+		}
+
+		log.Info().Str("addr", address).Msg("[rtsp] tls listen")
+		go serve(ln)
 	}
+}
 
-	go func() {
-		for {
-			conn, err := ln.Accept()
-			if err != nil {
-				return
-			}
+var username, password string
+var packetSize uint16
 
-			c := rtsp.NewServer(conn)
-			c.PacketSize = conf.Mod.PacketSize
-			// skip check auth for localhost
-			if conf.Mod.Username != "" && !conn.RemoteAddr().(*net.TCPAddr).IP.IsLoopback() {
-				c.Auth(conf.Mod.Username, conf.Mod.Password)
+func serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		c := rtsp.NewServer(conn)
+		c.PacketSize = packetSize
+		// skip check auth for localhost
+		if !conn.RemoteAddr().(*net.TCPAddr).IP.IsLoopback() {
+			if api.ACLEnabled() {
+				c.OnAuth = checkAuth
+			} else if username != "" {
+				c.Auth(username, password)
 			}
-			go tcpHandler(c)
 		}
-	}()
+		go tcpHandler(c)
+	}
+}
+
+// checkAuth - RTSP credentials or API credentials and token from the URL query.
+// Stream access is checked for DESCRIBE (view, backchannel) and ANNOUNCE (publish) requests.
+func checkAuth(req *tcp.Request, user, pass string) bool {
+	var u *acl.User
+	if username != "" && user == username && pass == password {
+		u = acl.Admin
+	} else if u = api.Login(user, pass, req.URL.Query().Get("token")); u == nil {
+		return false
+	}
+
+	if len(req.URL.Path) == 0 {
+		return true
+	}
+
+	name := req.URL.Path[1:]
+
+	switch req.Method {
+	case rtsp.MethodDescribe:
+		if req.URL.Query().Get("backchannel") == "1" && !u.Allow(name, acl.ActionBackchannel) {
+			return false
+		}
+		return u.Allow(name, acl.ActionView)
+	case rtsp.MethodAnnounce:
+		return u.Allow(name, acl.ActionPublish)
+	}
+
+	return true
 }
 
 type Handler func(conn *rtsp.Conn) bool
@@ -143,6 +223,46 @@ func rtspHandler(rawURL string) (core.Producer, error) {
 	return conn, nil
 }
 
+func streamsConsumerHandler(rawURL string) (core.Consumer, func(), error) {
+	rawURL, rawQuery, _ := strings.Cut(rawURL, "#")
+
+	cons := rtsp.NewPublisher(rawURL)
+	cons.SessionName = app.UserAgent
+	cons.UserAgent = app.UserAgent
+
+	query := streams.ParseQuery(rawQuery)
+	if query != nil {
+		cons.Timeout = core.Atoi(query.Get("timeout"))
+		cons.Transport = query.Get("transport")
+	}
+
+	// publish all video and audio tracks by default
+	if cons.Medias = ParseQuery(query); cons.Medias == nil {
+		cons.Medias = ParseQuery(url.Values{"video": {""}, "audio": {""}})
+	}
+
+	if log.Trace().Enabled() {
+		cons.Listen(func(msg any) {
+			switch msg := msg.(type) {
+			case *tcp.Request:
+				log.Trace().Msgf("[rtsp] publish request:\n%s", msg)
+			case *tcp.Response:
+				log.Trace().Msgf("[rtsp] publish response:\n%s", msg)
+			}
+		})
+	}
+
+	run := func() {
+		if err := cons.Publish(); err != nil {
+			log.Warn().Err(err).Caller().Send()
+			return
+		}
+		_ = cons.Handle()
+	}
+
+	return cons, run, nil
+}
+
 func tcpHandler(conn *rtsp.Conn) {
 	var name string
 	var closer func()
@@ -221,10 +341,19 @@ func tcpHandler(conn *rtsp.Conn) {
 				return
 			}
 
+			if _, ok := multicastGroups[name]; ok {
+				conn.OnMulticast = func() (*rtsp.Conn, error) {
+					return getMulticast(name, stream, conn)
+				}
+			}
+
 			closer = func() {
+				releaseMulticast(name, stream, conn)
 				stream.RemoveConsumer(conn)
 			}
 
+			sendEvent(name, "play", conn)
+
 		case rtsp.MethodAnnounce:
 			if len(conn.URL.Path) == 0 {
 				log.Warn().Msg("[rtsp] server empty URL on ANNOUNCE")
@@ -250,12 +379,15 @@ func tcpHandler(conn *rtsp.Conn) {
 			closer = func() {
 				stream.RemoveProducer(conn)
 			}
+
+			sendEvent(name, "record", conn)
 		}
 	})
 
 	if err := conn.Accept(); err != nil {
 		if errors.Is(err, rtsp.FailedAuth) {
 			log.Warn().Str("remote_addr", conn.Connection.RemoteAddr).Msg("[rtsp] failed authentication")
+			sendEvent(name, "unauthorized", conn)
 		} else if err != io.EOF {
 			log.WithLevel(level).Err(err).Caller().Send()
 		}
@@ -280,11 +412,20 @@ func tcpHandler(conn *rtsp.Conn) {
 		closer()
 
 		log.Debug().Str("stream", name).Msg("[rtsp] disconnect")
+
+		sendEvent(name, "disconnect", conn)
 	}
 
 	_ = conn.Close()
 }
 
+// sendEvent - RTSP server client events: play, record, unauthorized, disconnect
+func sendEvent(name, state string, conn *rtsp.Conn) {
+	events.Publish(&events.Event{
+		Type: "rtsp", Stream: name, State: state, Source: conn.Connection.RemoteAddr,
+	})
+}
+
 func ParseQuery(query map[string][]string) []*core.Media {
 	if v := query["mp4"]; v != nil {
 		return []*core.Media{
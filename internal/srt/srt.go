@@ -0,0 +1,177 @@
+package srt
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/mpegts"
+	"github.com/AlexxIT/go2rtc/pkg/srt"
+	"github.com/rs/zerolog"
+)
+
+func Init() {
+	var conf struct {
+		Mod struct {
+			Listen     string `yaml:"listen" json:"listen"`
+			Latency    int    `yaml:"latency" json:"latency"` // in milliseconds
+			Passphrase string `yaml:"passphrase" json:"passphrase"`
+			PBKeyLen   int    `yaml:"pbkeylen" json:"pbkeylen"`
+		} `yaml:"srt"`
+	}
+
+	app.LoadConfig(&conf)
+
+	log = app.GetLogger("srt")
+
+	streams.HandleFunc("srt", streamsHandle)
+
+	streams.HandleConsumerFunc("srt", streamsConsumerHandle)
+
+	address := conf.Mod.Listen
+	if address == "" {
+		return
+	}
+
+	ln, err := srt.Listen(address, &srt.Config{
+		Latency:    time.Duration(conf.Mod.Latency) * time.Millisecond,
+		Passphrase: conf.Mod.Passphrase,
+		KeyLength:  conf.Mod.PBKeyLen,
+		Accept: func(streamID string) int {
+			if name, _ := parseStreamID(streamID); streams.Get(name) == nil {
+				return srt.RejectNotFound
+			}
+			return 0
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Caller().Send()
+		return
+	}
+
+	log.Info().Str("addr", address).Msg("[srt] listen")
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				if err = handle(conn); err != nil {
+					log.Error().Err(err).Caller().Send()
+				}
+				_ = conn.Close()
+			}()
+		}
+	}()
+}
+
+var log zerolog.Logger
+
+// parseStreamID - support simple stream name, "publish:name" and SRT access control syntax:
+// "#!::r=name,m=publish", https://github.com/Haivision/srt/blob/master/docs/features/access-control.md
+func parseStreamID(streamID string) (name string, publish bool) {
+	if s, ok := strings.CutPrefix(streamID, "#!::"); ok {
+		for _, kv := range strings.Split(s, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "r":
+				name = v
+			case "m":
+				publish = v == "publish"
+			}
+		}
+		return
+	}
+
+	if s, ok := strings.CutPrefix(streamID, "publish:"); ok {
+		return s, true
+	}
+
+	return streamID, false
+}
+
+func handle(conn *srt.Conn) error {
+	name, publish := parseStreamID(conn.StreamID)
+
+	stream := streams.Get(name)
+	if stream == nil {
+		return errors.New("stream not found: " + name)
+	}
+
+	if publish {
+		prod, err := mpegts.Open(conn)
+		if err != nil {
+			return err
+		}
+
+		prod.Protocol = "srt"
+		prod.RemoteAddr = conn.RemoteAddr().String()
+
+		stream.AddProducer(prod)
+
+		defer stream.RemoveProducer(prod)
+
+		_ = prod.Start()
+
+		return nil
+	}
+
+	cons := mpegts.NewConsumer()
+	cons.Protocol = "srt"
+	cons.RemoteAddr = conn.RemoteAddr().String()
+
+	if err := stream.AddConsumer(cons); err != nil {
+		return err
+	}
+
+	defer stream.RemoveConsumer(cons)
+
+	_, _ = cons.WriteTo(conn)
+
+	return nil
+}
+
+func streamsHandle(rawURL string) (core.Producer, error) {
+	conn, err := srt.Dial(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	prod, err := mpegts.Open(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	prod.Protocol = "srt"
+	prod.RemoteAddr = conn.RemoteAddr().String()
+	prod.URL = rawURL
+
+	return prod, nil
+}
+
+func streamsConsumerHandle(rawURL string) (core.Consumer, func(), error) {
+	cons := mpegts.NewConsumer()
+	run := func() {
+		conn, err := srt.Dial(rawURL)
+		if err != nil {
+			log.Warn().Err(err).Caller().Send()
+			return
+		}
+
+		cons.Protocol = "srt"
+		cons.RemoteAddr = conn.RemoteAddr().String()
+		cons.URL = rawURL
+
+		_, _ = cons.WriteTo(conn)
+		_ = conn.Close()
+	}
+
+	return cons, run, nil
+}
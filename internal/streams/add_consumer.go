@@ -3,11 +3,16 @@ package streams
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
 )
 
-func (s *Stream) AddConsumer(cons core.Consumer) (err error) {
+func (s *Stream) AddConsumer(cons core.Consumer) error {
+	return s.addConsumer(cons, time.Time{})
+}
+
+func (s *Stream) addConsumer(cons core.Consumer, since time.Time) (err error) {
 	// support for multiple simultaneous pending from different consumers
 	consN := s.pending.Add(1) - 1
 
@@ -63,10 +68,15 @@ func (s *Stream) AddConsumer(cons core.Consumer) (err error) {
 						continue
 					}
 					// Step 5. Add track to consumer
-					if err = cons.AddTrack(consMedia, consCodec, track); err != nil {
+					layer := consumerLayer(cons, consMedia, prod)
+					if err = s.addTrack(cons, consMedia, consCodec, track, layer, since); err != nil {
 						log.Info().Err(err).Msg("[streams] can't add track")
 						continue
 					}
+					// Step 6. Add other quality layers of the same media
+					if layer != "" {
+						prodStarts = append(prodStarts, s.addLayers(cons, consMedia, consCodec, s.producers[prodN+1:], since)...)
+					}
 
 				case core.DirectionSendonly:
 					log.Trace().Msgf("[streams] match cons=%d => prod=%d", consN, prodN)
@@ -106,6 +116,10 @@ func (s *Stream) AddConsumer(cons core.Consumer) (err error) {
 	s.consumers = append(s.consumers, cons)
 	s.mu.Unlock()
 
+	consumerEvent(s, cons, "connected")
+
+	s.startFailover()
+
 	// there may be duplicates, but that's not a problem
 	for _, prod := range prodStarts {
 		prod.start()
@@ -5,6 +5,7 @@ import (
 
 	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/creds"
 	"github.com/AlexxIT/go2rtc/pkg/probe"
@@ -18,7 +19,11 @@ func apiStreams(w http.ResponseWriter, r *http.Request) {
 
 	// without source - return all streams list
 	if src == "" && r.Method != "POST" {
-		api.ResponseJSON(w, streams)
+		if user := api.User(r); user != nil && !user.IsAdmin() {
+			api.ResponseJSON(w, allowedStreams(user))
+		} else {
+			api.ResponseJSON(w, streams)
+		}
 		return
 	}
 
@@ -106,6 +111,21 @@ func apiStreams(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// allowedStreams - names of the streams that the user can view, without stream info,
+// because it has source URLs with credentials
+func allowedStreams(user *acl.User) map[string]struct{} {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+
+	list := map[string]struct{}{}
+	for name := range streams {
+		if user.Allow(name, acl.ActionView) {
+			list[name] = struct{}{}
+		}
+	}
+	return list
+}
+
 func apiStreamsDOT(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
@@ -64,3 +64,15 @@ func TestApiSchemesNoDuplicates(t *testing.T) {
 	// Should only appear once
 	require.Equal(t, 1, count, "scheme 'duplicate' should appear exactly once")
 }
+
+func TestApiMetricsSamples(t *testing.T) {
+	// sample for the closed connection from the previous scrape
+	conn := &core.Connection{}
+	samples[conn] = &sample{}
+
+	w := httptest.NewRecorder()
+	apiMetrics(w, httptest.NewRequest("GET", "/api/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.NotContains(t, samples, conn)
+}
@@ -0,0 +1,143 @@
+package streams
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/h264"
+	"github.com/AlexxIT/go2rtc/pkg/h265"
+)
+
+type BufferConfig struct {
+	Duration time.Duration `yaml:"duration"`
+	Size     int           `yaml:"size"` // in megabytes
+
+	// FastStart - send the last GOP to every new consumer, so it starts without waiting for a keyframe
+	FastStart bool `yaml:"fast_start"`
+}
+
+// audioMargin - audio buffer doesn't have keyframes, so it should be longer
+// than video buffer to cover the first GOP
+const audioMargin = 10 * time.Second
+
+// SetBuffer - keep stream packets history for consumers with preroll.
+// The stream producers can be stopped without consumers, so usually it is used with preload.
+func (s *Stream) SetBuffer(conf *BufferConfig) {
+	s.mu.Lock()
+	s.buffer = conf
+	s.mu.Unlock()
+}
+
+// AddConsumerPreroll - same as AddConsumer, but consumer also gets buffered packets
+// starting from the last keyframe before now - preroll
+func (s *Stream) AddConsumerPreroll(cons core.Consumer, preroll time.Duration) error {
+	since := s.keyframeTime(time.Now().Add(-preroll))
+	return s.addConsumer(cons, since)
+}
+
+// AddConsumerQuery - use AddConsumerPreroll if query has preroll param (in seconds)
+func (s *Stream) AddConsumerQuery(cons core.Consumer, query url.Values) error {
+	if query.Has("preroll") {
+		preroll := time.Duration(core.Atoi(query.Get("preroll"))) * time.Second
+		return s.AddConsumerPreroll(cons, preroll)
+	}
+	return s.AddConsumer(cons)
+}
+
+func (s *Stream) addTrack(cons core.Consumer, media *core.Media, codec *core.Codec, track *core.Receiver, layer string, since time.Time) error {
+	attach := func() error {
+		if layer != "" {
+			return cons.(LayerConsumer).AddLayer(layer, media, codec, track)
+		}
+		return cons.AddTrack(media, codec, track)
+	}
+
+	s.mu.Lock()
+	conf := s.buffer
+	s.mu.Unlock()
+
+	if conf == nil {
+		return attach()
+	}
+
+	if track.Buffer() == nil {
+		track.SetBuffer(newBuffer(track.Codec, conf))
+	}
+
+	if !since.IsZero() {
+		return track.Replay(since, attach)
+	}
+
+	if conf.FastStart {
+		return track.ReplayGOP(attach)
+	}
+
+	return attach()
+}
+
+// keyframeTime - return time of the last buffered keyframe before t
+func (s *Stream) keyframeTime(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, prod := range s.producers {
+		for _, track := range prod.receivers {
+			if buf := track.Buffer(); buf != nil {
+				if keytime, ok := buf.KeyframeTime(t); ok {
+					return keytime
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+func newBuffer(codec *core.Codec, conf *BufferConfig) *core.Buffer {
+	buf := &core.Buffer{
+		Duration:   conf.Duration,
+		Size:       conf.Size << 20, // megabytes to bytes
+		IsKeyframe: keyframeFunc(codec),
+	}
+	if buf.IsKeyframe == nil {
+		buf.Duration += audioMargin
+	}
+	return buf
+}
+
+// keyframeFunc - return keyframe detector for video codecs (RTP or AVCC format)
+func keyframeFunc(codec *core.Codec) func(packet *core.Packet) bool {
+	switch codec.Name {
+	case core.CodecH264:
+		if codec.IsRTP() {
+			return func(packet *core.Packet) bool {
+				return h264.IsKeyframeRTP(packet.Payload)
+			}
+		}
+		return func(packet *core.Packet) bool {
+			return len(packet.Payload) > 4 && h264.IsKeyframe(packet.Payload)
+		}
+	case core.CodecH265:
+		if codec.IsRTP() {
+			return func(packet *core.Packet) bool {
+				return h265.IsKeyframeRTP(packet.Payload)
+			}
+		}
+		return func(packet *core.Packet) bool {
+			return len(packet.Payload) > 4 && h265.IsKeyframe(packet.Payload)
+		}
+	case core.CodecJPEG:
+		if codec.IsRTP() {
+			// first packet of the frame has zero fragment offset
+			return func(packet *core.Packet) bool {
+				b := packet.Payload
+				return len(b) > 4 && b[1] == 0 && b[2] == 0 && b[3] == 0
+			}
+		}
+		return func(packet *core.Packet) bool {
+			return true
+		}
+	}
+	return nil
+}
@@ -56,13 +56,13 @@ func humanBytes(i int) string {
 }
 
 type node struct {
-	ID     uint32         `json:"id"`
-	Codec  map[string]any `json:"codec"`
-	Parent uint32         `json:"parent"`
-	Childs []uint32       `json:"childs"`
-	Bytes  int            `json:"bytes"`
-	//Packets uint32         `json:"packets"`
-	//Drops   uint32         `json:"drops"`
+	ID      uint32         `json:"id"`
+	Codec   map[string]any `json:"codec"`
+	Parent  uint32         `json:"parent"`
+	Childs  []uint32       `json:"childs"`
+	Bytes   int            `json:"bytes"`
+	Packets int            `json:"packets"`
+	Drops   int            `json:"drops"`
 }
 
 var codecKeys = []string{"codec_name", "sample_rate", "channels", "profile", "level"}
@@ -0,0 +1,111 @@
+package streams
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/AlexxIT/go2rtc/internal/events"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+const (
+	EventProducer = "producer"
+	EventConsumer = "consumer"
+)
+
+// event - streams event with link to the stream, because the stream doesn't know its name
+type event struct {
+	*events.Event
+
+	stream   *Stream
+	producer *Producer
+}
+
+var streamEvents chan *event
+var streamEventsOnce sync.Once
+
+// sendEvent - stream name is resolved in separate goroutine, because
+// events are sent under producer and stream locks
+func sendEvent(e *event) {
+	streamEventsOnce.Do(func() {
+		streamEvents = make(chan *event, 100)
+		go eventsWorker()
+	})
+
+	select {
+	case streamEvents <- e:
+	default:
+		log.Warn().Msgf("[streams] drop event type=%s", e.Type)
+	}
+}
+
+func eventsWorker() {
+	for e := range streamEvents {
+		if e.stream != nil {
+			e.Stream = GetName(e.stream)
+		} else if e.producer != nil {
+			e.Stream = streamName(func(s *Stream) bool {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				for _, producer := range s.producers {
+					if producer == e.producer {
+						return true
+					}
+				}
+				return false
+			})
+		}
+
+		events.Publish(e.Event)
+	}
+}
+
+// streamName - return stream name, first name for linked streams
+func streamName(match func(s *Stream) bool) string {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+
+	var names []string
+	for name, stream := range streams {
+		if match(stream) {
+			names = append(names, name)
+		}
+	}
+
+	if names == nil {
+		return ""
+	}
+
+	sort.Strings(names)
+	return names[0]
+}
+
+// GetName - return stream name, first name for linked streams
+func GetName(stream *Stream) string {
+	return streamName(func(s *Stream) bool {
+		return s == stream
+	})
+}
+
+type consumerInfo struct {
+	ID         uint32 `json:"id"`
+	FormatName string `json:"format_name,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+}
+
+func consumerEvent(s *Stream, cons core.Consumer, state string) {
+	e := &events.Event{Type: EventConsumer, State: state}
+
+	if conn, err := marshalConn(cons); err == nil {
+		e.Source = conn.RemoteAddr
+		e.Data = &consumerInfo{
+			ID:         conn.ID,
+			FormatName: conn.FormatName,
+			Protocol:   conn.Protocol,
+			UserAgent:  conn.UserAgent,
+		}
+	}
+
+	sendEvent(&event{Event: e, stream: s})
+}
@@ -0,0 +1,268 @@
+package streams
+
+import (
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+// FailoverConfig - use stream sources by priority (from the first to the last). Consumers are moved
+// to the next source when the active source has no packets, and back when the source recovers.
+type FailoverConfig struct {
+	Timeout  time.Duration `yaml:"timeout"`  // switch to the next source if the active source has no packets
+	Recovery time.Duration `yaml:"recovery"` // check sources with higher priority with this interval
+}
+
+const (
+	defaultFailoverTimeout  = 5 * time.Second
+	defaultFailoverRecovery = 30 * time.Second
+)
+
+func (s *Stream) SetFailover(conf *FailoverConfig) {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultFailoverTimeout
+	}
+	if conf.Recovery <= 0 {
+		conf.Recovery = defaultFailoverRecovery
+	}
+
+	s.mu.Lock()
+	s.failover = conf
+	s.mu.Unlock()
+}
+
+// startFailover - run failover worker while the stream has consumers
+func (s *Stream) startFailover() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failover == nil || s.failoverRun {
+		return
+	}
+
+	s.failoverRun = true
+
+	go s.failoverWorker(s.failover)
+}
+
+func (s *Stream) failoverWorker(conf *FailoverConfig) {
+	// check interval shouldn't be longer than timeout
+	ticker := time.NewTicker(min(time.Second, conf.Timeout))
+	defer ticker.Stop()
+
+	packets := map[*Producer]int{}
+	lastPacket := map[*Producer]time.Time{}
+	failed := map[*Producer]time.Time{} // skip failed sources until recovery
+	lastRecovery := time.Now()
+
+	for range ticker.C {
+		s.mu.Lock()
+		if len(s.consumers) == 0 {
+			s.failoverRun = false
+			s.mu.Unlock()
+			return
+		}
+
+		// quality layers are used at the same time, so they don't participate in failover
+		var producers []*Producer
+		for _, prod := range s.producers {
+			if prod.layer() == "" {
+				producers = append(producers, prod)
+			}
+		}
+		s.mu.Unlock()
+
+		now := time.Now()
+
+		for _, prod := range producers {
+			if !prod.hasReaders() {
+				delete(packets, prod)
+				delete(lastPacket, prod)
+				continue
+			}
+
+			// same as watchdog, but source may be "online" without packets (ex. RTSP over UDP)
+			n := prod.packets()
+			if prev, ok := packets[prod]; !ok || n > prev {
+				lastPacket[prod] = now
+			}
+			packets[prod] = n
+
+			if now.Sub(lastPacket[prod]) < conf.Timeout {
+				continue
+			}
+
+			log.Warn().Msgf("[streams] failover no packets for %s from url=%s", conf.Timeout, prod.url)
+
+			delete(packets, prod)
+			delete(lastPacket, prod)
+			failed[prod] = now
+
+			// try all other sources by priority, the source with higher priority may already be online
+			for _, next := range producers {
+				if since, ok := failed[next]; ok && now.Sub(since) < conf.Recovery {
+					continue
+				}
+				if s.moveReaders(next, []*Producer{prod}, conf.Timeout) {
+					log.Info().Msgf("[streams] failover from url=%s to url=%s", prod.url, next.url)
+					break
+				}
+				failed[next] = now
+			}
+		}
+
+		if now.Sub(lastRecovery) >= conf.Recovery {
+			lastRecovery = now
+			clear(failed)
+			s.recoverReaders(producers, conf.Timeout)
+		}
+	}
+}
+
+// recoverReaders - move consumers from the sources with lower priority
+// to the first source with higher priority that is online
+func (s *Stream) recoverReaders(producers []*Producer, timeout time.Duration) {
+	last := -1
+	for i, prod := range producers {
+		if prod.hasReaders() {
+			last = i
+		}
+	}
+
+	for i := 0; i < last; i++ {
+		if s.moveReaders(producers[i], producers[i+1:last+1], timeout) {
+			log.Info().Msgf("[streams] failover recovery to url=%s", producers[i].url)
+			return
+		}
+	}
+}
+
+// moveReaders - move consumers of the src producers tracks to the dst producer tracks with the same codecs.
+// The dst producer should receive packets during the wait time before moving.
+func (s *Stream) moveReaders(dst *Producer, srcs []*Producer, wait time.Duration) (moved bool) {
+	// protect producers without readers from stopping while moving
+	s.pending.Add(1)
+	defer func() {
+		// stop producers without readers (old sources or failed new source)
+		if s.pending.Add(-1) == 0 {
+			s.stopProducers()
+		}
+	}()
+
+	var codecs []*core.Codec
+	for _, src := range srcs {
+		codecs = append(codecs, src.readerCodecs()...)
+	}
+
+	if len(codecs) == 0 {
+		return false
+	}
+
+	if err := dst.Dial(); err != nil {
+		log.Debug().Err(err).Msgf("[streams] failover url=%s", dst.url)
+		return false
+	}
+
+	// get tracks before moving, because GetTrack may be slow (ex. RTSP SETUP)
+	var tracks []*core.Receiver
+	for _, codec := range codecs {
+		if track := dst.matchTrack(codec); track != nil {
+			tracks = append(tracks, track)
+		}
+	}
+
+	if len(tracks) == 0 {
+		return false
+	}
+
+	dst.start()
+
+	for packets, deadline := dst.packets(), time.Now().Add(wait); dst.packets() <= packets; {
+		if time.Now().After(deadline) {
+			log.Debug().Msgf("[streams] failover no packets from url=%s", dst.url)
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, src := range srcs {
+		if src.moveTo(tracks) {
+			moved = true
+		}
+	}
+
+	return
+}
+
+// hasReaders - producer tracks have consumers
+func (p *Producer) hasReaders() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, track := range p.receivers {
+		if len(track.Senders()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// packets - number of packets received by the producer tracks
+func (p *Producer) packets() (n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, receiver := range p.receivers {
+		n += receiver.Packets()
+	}
+	return
+}
+
+// readerCodecs - codecs of the producer tracks with consumers
+func (p *Producer) readerCodecs() (codecs []*core.Codec) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, track := range p.receivers {
+		if len(track.Senders()) > 0 {
+			codecs = append(codecs, track.Codec)
+		}
+	}
+	return
+}
+
+// matchTrack - producer track with the codec matching the track codec of the other producer
+func (p *Producer) matchTrack(codec *core.Codec) *core.Receiver {
+	for _, media := range p.GetMedias() {
+		if media.Direction != core.DirectionRecvonly {
+			continue
+		}
+		if prodCodec := media.MatchCodec(codec); prodCodec != nil {
+			if track, err := p.GetTrack(media, prodCodec); err == nil {
+				return track
+			}
+		}
+	}
+	return nil
+}
+
+// moveTo - move consumers from the producer tracks to the tracks of other producer with the same codecs
+func (p *Producer) moveTo(tracks []*core.Receiver) (moved bool) {
+	// lock producer, so it can't replace tracks on reconnect while moving
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, receiver := range p.receivers {
+		if len(receiver.Senders()) == 0 {
+			continue
+		}
+		for _, track := range tracks {
+			if track.Codec.Match(receiver.Codec) {
+				receiver.Replace(track)
+				moved = true
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package streams
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+// LayerConsumer - consumer that can switch between quality layers of the same media
+// without renegotiation (ex. WebRTC). Layers are added from the first source to the last.
+type LayerConsumer interface {
+	AddLayer(name string, media *core.Media, codec *core.Codec, track *core.Receiver) error
+}
+
+// layer - quality layer name from the source params:
+// rtsp://192.168.1.123/sub#layer=low
+func (p *Producer) layer() string {
+	i := strings.Index(p.url, "#layer=")
+	if i < 0 {
+		return ""
+	}
+	s := p.url[i+len("#layer="):]
+	if i = strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// consumerLayer - layer name if the consumer supports layers for this media
+func consumerLayer(cons core.Consumer, media *core.Media, prod *Producer) string {
+	if _, ok := cons.(LayerConsumer); !ok || media.Kind != core.KindVideo {
+		return ""
+	}
+	return prod.layer()
+}
+
+// addLayers - add tracks from the next producers with layers to the same consumer media
+func (s *Stream) addLayers(cons core.Consumer, consMedia *core.Media, consCodec *core.Codec, producers []*Producer, since time.Time) (prodStarts []*Producer) {
+	for _, prod := range producers {
+		layer := prod.layer()
+		if layer == "" {
+			continue
+		}
+
+		if err := prod.Dial(); err != nil {
+			log.Trace().Err(err).Msgf("[streams] dial layer=%s", layer)
+			continue
+		}
+
+		for _, prodMedia := range prod.GetMedias() {
+			if prodMedia.Kind != consMedia.Kind || prodMedia.Direction != core.DirectionRecvonly {
+				continue
+			}
+
+			// layers should have the same codec as the main track
+			prodCodec := prodMedia.MatchCodec(consCodec)
+			if prodCodec == nil {
+				continue
+			}
+
+			track, err := prod.GetTrack(prodMedia, prodCodec)
+			if err != nil {
+				log.Info().Err(err).Msg("[streams] can't get track")
+				break
+			}
+
+			if err = s.addTrack(cons, consMedia, consCodec, track, layer, since); err != nil {
+				log.Info().Err(err).Msg("[streams] can't add layer")
+				break
+			}
+
+			prodStarts = append(prodStarts, prod)
+			break
+		}
+	}
+
+	return
+}
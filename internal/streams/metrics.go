@@ -0,0 +1,229 @@
+package streams
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/creds"
+)
+
+// apiMetrics - streams, producers and consumers stats in Prometheus text format
+func apiMetrics(w http.ResponseWriter, r *http.Request) {
+	streamsMu.Lock()
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	streamsMu.Unlock()
+
+	sort.Strings(names)
+
+	m := &metrics{seen: map[any]struct{}{}}
+	for _, name := range names {
+		if stream := Get(name); stream != nil {
+			m.appendStream(name, stream)
+		}
+	}
+
+	removeSamples(m.seen)
+
+	b := []byte(creds.SecretString(string(m.bytes())))
+	api.Response(w, b, "text/plain; version=0.0.4; charset=utf-8")
+}
+
+type metric struct {
+	name    string
+	typ     string
+	help    string
+	samples []byte
+}
+
+type metrics struct {
+	items []*metric
+	seen  map[any]struct{} // connections from the current scrape
+}
+
+func (m *metrics) add(name, typ, help, labels string, value float64) {
+	var item *metric
+	for _, i := range m.items {
+		if i.name == name {
+			item = i
+			break
+		}
+	}
+	if item == nil {
+		item = &metric{name: name, typ: typ, help: help}
+		m.items = append(m.items, item)
+	}
+	item.samples = fmt.Appendf(item.samples, "%s{%s} %g\n", name, labels, value)
+}
+
+func (m *metrics) bytes() []byte {
+	var b []byte
+	for _, item := range m.items {
+		b = fmt.Appendf(b, "# HELP %s %s\n# TYPE %s %s\n", item.name, item.help, item.name, item.typ)
+		b = append(b, item.samples...)
+	}
+	return b
+}
+
+func (m *metrics) appendStream(name string, stream *Stream) {
+	stream.mu.Lock()
+	producers := append([]*Producer(nil), stream.producers...)
+	consumers := append([]core.Consumer(nil), stream.consumers...)
+	stream.mu.Unlock()
+
+	labels := formatLabels("stream", name)
+
+	m.add("go2rtc_stream_producers", "gauge", "Number of stream producers.", labels, float64(len(producers)))
+	m.add("go2rtc_stream_consumers", "gauge", "Number of stream consumers.", labels, float64(len(consumers)))
+
+	for _, prod := range producers {
+		prod.mu.Lock()
+		conn := prod.conn
+		status := prod.status
+		started := prod.started
+		reconnects := prod.reconnects
+		source := prod.url
+		prod.mu.Unlock()
+
+		labels = formatLabels("stream", name, "producer", redactURL(source))
+
+		var up, uptime float64
+		if status == StatusOnline {
+			up = 1
+			uptime = time.Since(started).Seconds()
+		}
+
+		// idle producers are stopped without consumers, they are neither up nor down
+		if status != StatusIdle && status != "" {
+			m.add("go2rtc_producer_up", "gauge", "Producer connection is online.", labels, up)
+			m.add("go2rtc_producer_uptime_seconds", "gauge", "Time since producer became online.", labels, uptime)
+		}
+		m.add("go2rtc_producer_reconnects_total", "counter", "Number of producer reconnects.", labels, float64(reconnects))
+
+		var bytes, packets int
+		if conn != nil {
+			if c, err := marshalConn(conn); err == nil {
+				for _, recv := range c.Receivers {
+					bytes += recv.Bytes
+					packets += recv.Packets
+				}
+			}
+		}
+
+		m.add("go2rtc_producer_received_bytes_total", "counter", "Bytes received by producer tracks.", labels, float64(bytes))
+		m.add("go2rtc_producer_received_packets_total", "counter", "Packets received by producer tracks.", labels, float64(packets))
+		m.add("go2rtc_producer_bitrate", "gauge", "Producer bitrate in bits per second.", labels, m.bitrate(conn, bytes))
+	}
+
+	for _, cons := range consumers {
+		c, err := marshalConn(cons)
+		if err != nil {
+			continue
+		}
+
+		var bytes, packets, drops int
+		for _, send := range c.Senders {
+			bytes += send.Bytes
+			packets += send.Packets
+			drops += send.Drops
+		}
+
+		labels = formatLabels(
+			"stream", name, "consumer", fmt.Sprint(c.ID), "format_name", c.FormatName,
+			"protocol", c.Protocol, "remote_addr", c.RemoteAddr,
+		)
+
+		m.add("go2rtc_consumer_sent_bytes_total", "counter", "Bytes sent by consumer tracks.", labels, float64(bytes))
+		m.add("go2rtc_consumer_sent_packets_total", "counter", "Packets sent by consumer tracks.", labels, float64(packets))
+		m.add("go2rtc_consumer_dropped_packets_total", "counter", "Packets dropped by slow consumer.", labels, float64(drops))
+		m.add("go2rtc_consumer_bitrate", "gauge", "Consumer bitrate in bits per second.", labels, m.bitrate(cons, bytes))
+	}
+}
+
+// formatLabels - format label pairs with escaping
+func formatLabels(pairs ...string) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(pairs[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelReplacer.Replace(pairs[i+1]))
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// redactURL - hide password from source URL
+func redactURL(s string) string {
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return s
+}
+
+type sample struct {
+	bytes   int
+	time    time.Time
+	bitrate float64
+}
+
+// samples - last bytes value for each connection, used for bitrate calculation
+var samples = map[any]*sample{}
+var samplesMu sync.Mutex
+
+// minInterval - bitrate isn't recalculated if scrapes are too frequent
+const minInterval = time.Second
+
+func (m *metrics) bitrate(conn any, bytes int) float64 {
+	if conn == nil {
+		return 0
+	}
+
+	m.seen[conn] = struct{}{}
+
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+
+	now := time.Now()
+
+	s := samples[conn]
+	if s == nil {
+		samples[conn] = &sample{bytes: bytes, time: now}
+		return 0
+	}
+
+	if d := now.Sub(s.time); d >= minInterval {
+		s.bitrate = float64(bytes-s.bytes) * 8 / d.Seconds()
+		s.bytes = bytes
+		s.time = now
+	}
+
+	return s.bitrate
+}
+
+// removeSamples - remove closed connections, that are not in the current scrape
+func removeSamples(seen map[any]struct{}) {
+	samplesMu.Lock()
+	for conn := range samples {
+		if _, ok := seen[conn]; !ok {
+			delete(samples, conn)
+		}
+	}
+	samplesMu.Unlock()
+}
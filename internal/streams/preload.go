@@ -56,3 +56,9 @@ func DelPreload(stream *Stream) error {
 
 	return errors.New("streams: preload not found")
 }
+
+func HasPreload(stream *Stream) bool {
+	preloadsMu.Lock()
+	defer preloadsMu.Unlock()
+	return preloads[stream] != nil
+}
@@ -3,10 +3,12 @@ package streams
 import (
 	"encoding/json"
 	"errors"
+	"math/rand/v2"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 )
 
@@ -21,6 +23,15 @@ const (
 	stateInternal
 )
 
+// producer status for API and events
+const (
+	StatusConnecting = "connecting"
+	StatusOnline     = "online"
+	StatusStalled    = "stalled"
+	StatusOffline    = "offline"
+	StatusIdle       = "idle" // stopped because there are no consumers
+)
+
 type Producer struct {
 	core.Listener
 
@@ -34,6 +45,11 @@ type Producer struct {
 	state    state
 	mu       sync.Mutex
 	workerID int
+
+	status     string
+	started    time.Time // online status start time
+	reconnects int
+	retry      int // reconnect retries without success
 }
 
 const SourceTemplate = "{input}"
@@ -59,8 +75,12 @@ func (p *Producer) Dial() error {
 	defer p.mu.Unlock()
 
 	if p.state == stateNone {
-		conn, err := GetProducer(p.url)
+		p.setStatus(StatusConnecting)
+
+		source, _ := parseSource(p.url)
+		conn, err := GetProducer(source)
 		if err != nil {
+			p.setStatus(StatusOffline)
 			return err
 		}
 
@@ -132,10 +152,31 @@ func (p *Producer) AddTrack(media *core.Media, codec *core.Codec, track *core.Re
 }
 
 func (p *Producer) MarshalJSON() ([]byte, error) {
-	if conn := p.conn; conn != nil {
-		return json.Marshal(conn)
+	p.mu.Lock()
+	conn := p.conn
+	source := p.url
+	status := p.status
+	p.mu.Unlock()
+
+	if conn != nil {
+		b, err := json.Marshal(conn)
+		if err != nil || status == "" {
+			return b, err
+		}
+
+		// add producer status to the connection info
+		var info map[string]json.RawMessage
+		if err = json.Unmarshal(b, &info); err != nil || info == nil {
+			return b, nil
+		}
+		info["state"], _ = json.Marshal(status)
+		return json.Marshal(info)
+	}
+
+	info := map[string]string{"url": source}
+	if status != "" {
+		info["state"] = status
 	}
-	info := map[string]string{"url": p.url}
 	return json.Marshal(info)
 }
 
@@ -155,6 +196,7 @@ func (p *Producer) start() {
 	p.workerID++
 
 	go p.worker(p.conn, p.workerID)
+	go p.watchdog(p.workerID)
 }
 
 func (p *Producer) worker(conn core.Producer, workerID int) {
@@ -170,10 +212,31 @@ func (p *Producer) worker(conn core.Producer, workerID int) {
 		log.Warn().Err(err).Str("url", p.url).Caller().Send()
 	}
 
-	p.reconnect(workerID, 0)
+	p.mu.Lock()
+	p.scheduleReconnect(workerID)
+	p.mu.Unlock()
 }
 
-func (p *Producer) reconnect(workerID, retry int) {
+// scheduleReconnect - reconnect with exponential backoff, first retry is immediate
+func (p *Producer) scheduleReconnect(workerID int) {
+	if p.workerID != workerID {
+		return
+	}
+
+	if p.status != StatusStalled {
+		p.setStatus(StatusOffline)
+	}
+
+	_, params := parseSource(p.url)
+	delay := backoff(p.retry, params.backoff)
+	p.retry++
+
+	time.AfterFunc(delay, func() {
+		p.reconnect(workerID)
+	})
+}
+
+func (p *Producer) reconnect(workerID int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -182,24 +245,15 @@ func (p *Producer) reconnect(workerID, retry int) {
 		return
 	}
 
-	log.Debug().Msgf("[streams] retry=%d to url=%s", retry, p.url)
+	log.Debug().Msgf("[streams] retry=%d to url=%s", p.retry, p.url)
 
-	conn, err := GetProducer(p.url)
+	p.setStatus(StatusConnecting)
+
+	source, _ := parseSource(p.url)
+	conn, err := GetProducer(source)
 	if err != nil {
 		log.Debug().Msgf("[streams] producer=%s", err)
-
-		timeout := time.Minute
-		if retry < 5 {
-			timeout = time.Second
-		} else if retry < 10 {
-			timeout = time.Second * 5
-		} else if retry < 20 {
-			timeout = time.Second * 10
-		}
-
-		time.AfterFunc(timeout, func() {
-			p.reconnect(workerID, retry+1)
-		})
+		p.scheduleReconnect(workerID)
 		return
 	}
 
@@ -238,6 +292,7 @@ func (p *Producer) reconnect(workerID, retry int) {
 	_ = p.conn.Stop()
 	// swap connections
 	p.conn = conn
+	p.reconnects++
 
 	go p.worker(conn, workerID)
 }
@@ -267,4 +322,133 @@ func (p *Producer) stop() {
 	p.state = stateNone
 	p.receivers = nil
 	p.senders = nil
+	p.retry = 0
+	p.setStatus(StatusIdle)
+}
+
+// watchdog - check that producer receives packets. Restart the connection
+// if there are no packets longer than the stall timeout from the source params.
+func (p *Producer) watchdog(workerID int) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var packets int
+	var lastPacket = time.Now()
+
+	for range ticker.C {
+		p.mu.Lock()
+
+		if p.workerID != workerID {
+			p.mu.Unlock()
+			return
+		}
+
+		var n int
+		for _, receiver := range p.receivers {
+			n += receiver.Packets()
+		}
+
+		switch {
+		case n > packets || len(p.receivers) == 0:
+			lastPacket = time.Now()
+			if p.status == StatusConnecting {
+				p.setStatus(StatusOnline)
+				p.retry = 0
+			}
+		case n < packets:
+			lastPacket = time.Now() // new connection with new receivers
+		default:
+			_, params := parseSource(p.url)
+			if stall := params.stall; stall > 0 && time.Since(lastPacket) > stall && p.status != StatusStalled {
+				log.Warn().Msgf("[streams] no packets for %s from url=%s", stall, p.url)
+				p.setStatus(StatusStalled)
+				// worker will reconnect after connection stop
+				conn := p.conn
+				go func() { _ = conn.Stop() }()
+			}
+		}
+
+		packets = n
+
+		p.mu.Unlock()
+	}
+}
+
+// setStatus - change producer status and send event, should be called under lock
+func (p *Producer) setStatus(status string) {
+	if p.status == status {
+		return
+	}
+
+	p.status = status
+
+	if status == StatusOnline {
+		p.started = time.Now()
+	} else {
+		p.started = time.Time{}
+	}
+
+	log.Debug().Msgf("[streams] producer status=%s url=%s", status, p.url)
+
+	sendEvent(&event{
+		Event:    &events.Event{Type: EventProducer, State: status, Source: redactURL(p.url)},
+		producer: p,
+	})
+}
+
+const defaultBackoff = time.Minute
+
+// sourceParams - producer and publish params from the source
+type sourceParams struct {
+	backoff time.Duration // max reconnect delay
+	stall   time.Duration // reconnect if there are no packets for this time
+
+	preroll     time.Duration // publish with packets from the buffer
+	withPreroll bool
+}
+
+// parseSource - cut producer params from the source, other params stay for the source handler:
+// rtsp://192.168.1.123/stream#backoff=30#stall=10#layer=high
+// rtmp://example.com/live#preroll=5#backoff=30
+func parseSource(source string) (url string, params sourceParams) {
+	params.backoff = defaultBackoff
+
+	i := strings.IndexByte(source, '#')
+	if i < 0 {
+		return source, params
+	}
+
+	url = source[:i]
+	for _, param := range strings.Split(source[i+1:], "#") {
+		key, value, _ := strings.Cut(param, "=")
+		switch key {
+		case "backoff":
+			params.backoff = time.Duration(core.Atoi(value)) * time.Second
+		case "stall":
+			params.stall = time.Duration(core.Atoi(value)) * time.Second
+		case "preroll":
+			params.preroll = time.Duration(core.Atoi(value)) * time.Second
+			params.withPreroll = true
+		case "layer":
+			// used only by streams, see Producer.layer
+		default:
+			url += "#" + param
+		}
+	}
+	return
+}
+
+// backoff - exponential delay with jitter: 0, 1s, 2s, 4s... up to max
+func backoff(retry int, max time.Duration) time.Duration {
+	if retry == 0 {
+		return 0
+	}
+
+	delay := time.Second << min(retry-1, 16)
+	if delay > max {
+		delay = max
+	}
+
+	// random delay from 50% to 100%, so many producers don't reconnect at the same time
+	return delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
 }
@@ -0,0 +1,118 @@
+package streams
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AlexxIT/go2rtc/internal/api"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+// PTZHandler - PTZ control for sources, whose producers don't support it
+// (ex. onvif source returns RTSP producer)
+type PTZHandler func(source string) (core.PTZ, error)
+
+var ptzHandlers = map[string]PTZHandler{}
+
+func HandlePTZFunc(scheme string, handler PTZHandler) {
+	ptzHandlers[scheme] = handler
+}
+
+// PTZ - return PTZ control from the first stream producer that supports it
+func (s *Stream) PTZ() (core.PTZ, error) {
+	var sources []string
+
+	s.mu.Lock()
+	for _, producer := range s.producers {
+		producer.mu.Lock()
+		conn, source := producer.conn, producer.url
+		producer.mu.Unlock()
+
+		if ptz, ok := conn.(core.PTZ); ok {
+			s.mu.Unlock()
+			return ptz, nil
+		}
+		sources = append(sources, source)
+	}
+	s.mu.Unlock()
+
+	for _, source := range sources {
+		source, _ = parseSource(source)
+		if i := strings.IndexByte(source, ':'); i > 0 {
+			if handler, ok := ptzHandlers[source[:i]]; ok {
+				return handler(source)
+			}
+		}
+	}
+
+	return nil, errors.New("streams: PTZ unsupported")
+}
+
+func apiPTZ(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	stream := Get(query.Get("src"))
+	if stream == nil {
+		http.Error(w, api.StreamNotFound, http.StatusNotFound)
+		return
+	}
+
+	ptz, err := stream.PTZ()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == "GET" {
+		presets, err := ptz.PTZGetPresets()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		api.ResponseJSON(w, presets)
+		return
+	}
+
+	if r.Method != "POST" {
+		http.Error(w, "", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action := query.Get("action"); action {
+	case "", "move", core.PTZRelative, core.PTZAbsolute:
+		if action == "" || action == "move" {
+			action = core.PTZContinuous
+		}
+
+		var xyz [3]float64
+		for i, key := range []string{"pan", "tilt", "zoom"} {
+			if s := query.Get(key); s != "" {
+				if xyz[i], err = strconv.ParseFloat(s, 64); err != nil {
+					http.Error(w, "wrong "+key+" value", http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		err = ptz.PTZMove(action, xyz[0], xyz[1], xyz[2])
+	case "stop":
+		err = ptz.PTZStop()
+	case "goto_preset":
+		err = ptz.PTZGotoPreset(query.Get("preset"))
+	case "set_preset":
+		var token string
+		if token, err = ptz.PTZSetPreset(query.Get("name")); err == nil {
+			api.ResponseJSON(w, &core.PTZPreset{Token: token, Name: query.Get("name")})
+			return
+		}
+	default:
+		http.Error(w, "unsupported action: "+action, http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,29 +1,177 @@
 package streams
 
-import "time"
+import (
+	"errors"
+	"sync"
+	"time"
 
-func (s *Stream) Publish(url string) error {
-	cons, run, err := GetConsumer(url)
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+type publisher struct {
+	cons    core.Consumer
+	stopped bool
+}
+
+type publishKey struct {
+	stream *Stream
+	url    string
+}
+
+var publishers = map[publishKey]*publisher{}
+var publishersMu sync.Mutex
+
+func (s *Stream) Publish(rawURL string) error {
+	key := publishKey{stream: s, url: rawURL}
+
+	publishersMu.Lock()
+	if publishers[key] != nil {
+		publishersMu.Unlock()
+		return errors.New("streams: already publishing")
+	}
+
+	// reserve the key, so slow connection to the target doesn't block other publishers
+	pub := &publisher{}
+	publishers[key] = pub
+	publishersMu.Unlock()
+
+	// support pre-event buffer: rtmp://example.com/live#preroll=5
+	url, params := parseSource(rawURL)
+
+	cons, run, err := s.publish(url, params.preroll, params.withPreroll)
+
+	publishersMu.Lock()
 	if err != nil {
+		if publishers[key] == pub {
+			delete(publishers, key)
+		}
+		publishersMu.Unlock()
 		return err
 	}
+	pub.cons = cons
+	stopped := pub.stopped
+	publishersMu.Unlock()
 
-	if err = s.AddConsumer(cons); err != nil {
-		return err
+	// stopped while connecting
+	if stopped {
+		s.RemoveConsumer(cons)
+		return nil
 	}
 
 	go func() {
-		run()
-		s.RemoveConsumer(cons)
+		var retry int
+
+		for {
+			ts := time.Now()
+			run()
+			s.RemoveConsumer(cons)
+
+			// reset backoff if publishing worked long enough
+			if time.Since(ts) > params.backoff {
+				retry = 0
+			}
+
+			for {
+				time.Sleep(backoff(retry, params.backoff))
+				retry++
+
+				publishersMu.Lock()
+				stopped := pub.stopped
+				publishersMu.Unlock()
 
-		// TODO: more smart retry
-		time.Sleep(5 * time.Second)
-		_ = s.Publish(url)
+				if stopped {
+					return
+				}
+
+				log.Debug().Msgf("[streams] retry=%d publish to url=%s", retry, url)
+
+				// retries without preroll
+				if cons, run, err = s.publish(url, 0, false); err == nil {
+					break
+				}
+
+				log.Debug().Msgf("[streams] publish=%s", err)
+			}
+
+			publishersMu.Lock()
+			pub.cons = cons
+			stopped := pub.stopped
+			publishersMu.Unlock()
+
+			if stopped {
+				s.RemoveConsumer(cons)
+				return
+			}
+		}
 	}()
 
 	return nil
 }
 
+// StopPublish - stop publishing started with the same URL
+func (s *Stream) StopPublish(rawURL string) error {
+	key := publishKey{stream: s, url: rawURL}
+
+	publishersMu.Lock()
+	pub := publishers[key]
+	if pub == nil {
+		publishersMu.Unlock()
+		return errors.New("streams: publish not found")
+	}
+	pub.stopped = true
+	cons := pub.cons
+	delete(publishers, key)
+	publishersMu.Unlock()
+
+	// consumer is nil while connecting, it will be removed by Publish
+	if cons != nil {
+		s.RemoveConsumer(cons)
+	}
+	return nil
+}
+
+func (s *Stream) publish(url string, preroll time.Duration, withPreroll bool) (core.Consumer, func(), error) {
+	cons, run, err := GetConsumer(url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if withPreroll {
+		err = s.AddConsumerPreroll(cons, preroll)
+	} else {
+		err = s.AddConsumer(cons)
+	}
+	if err != nil {
+		// consumer may have a connection opened by the handler
+		_ = cons.Stop()
+		return nil, nil, err
+	}
+
+	return cons, run, nil
+}
+
+// publishConfig - publish targets from the config by stream name
+var publishConfig map[string]any
+
+// IsPublishTarget - check that destination is in the publish config of the stream
+func IsPublishTarget(name, dst string) bool {
+	return hasTarget(publishConfig[name], dst)
+}
+
+func hasTarget(destination any, dst string) bool {
+	switch v := destination.(type) {
+	case string:
+		return v == dst
+	case []any:
+		for _, v := range v {
+			if hasTarget(v, dst) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func Publish(stream *Stream, destination any) {
 	switch v := destination.(type) {
 	case string:
@@ -13,6 +13,10 @@ type Stream struct {
 	consumers []core.Consumer
 	mu        sync.Mutex
 	pending   atomic.Int32
+	buffer    *BufferConfig
+
+	failover    *FailoverConfig
+	failoverRun bool
 }
 
 func NewStream(source any) *Stream {
@@ -48,9 +52,14 @@ func NewStream(source any) *Stream {
 }
 
 func (s *Stream) Sources() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	sources := make([]string, 0, len(s.producers))
 	for _, prod := range s.producers {
+		prod.mu.Lock()
 		sources = append(sources, prod.url)
+		prod.mu.Unlock()
 	}
 	return sources
 }
@@ -61,18 +70,62 @@ func (s *Stream) SetSource(source string) {
 	}
 }
 
+// ConsumersCount - return number of stream consumers
+func (s *Stream) ConsumersCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.consumers)
+}
+
+// Status - return the best status of stream producers: online, stalled, connecting, offline or idle
+func (s *Stream) Status() string {
+	s.mu.Lock()
+	producers := s.producers
+	s.mu.Unlock()
+
+	status := StatusIdle
+	for _, prod := range producers {
+		prod.mu.Lock()
+		switch prod.status {
+		case StatusOnline:
+			status = StatusOnline
+		case StatusStalled:
+			if status != StatusOnline {
+				status = StatusStalled
+			}
+		case StatusConnecting:
+			if status == StatusOffline || status == StatusIdle {
+				status = StatusConnecting
+			}
+		case StatusOffline:
+			if status == StatusIdle {
+				status = StatusOffline
+			}
+		}
+		prod.mu.Unlock()
+	}
+	return status
+}
+
 func (s *Stream) RemoveConsumer(cons core.Consumer) {
 	_ = cons.Stop()
 
+	var found bool
+
 	s.mu.Lock()
 	for i, consumer := range s.consumers {
 		if consumer == cons {
 			s.consumers = append(s.consumers[:i], s.consumers[i+1:]...)
+			found = true
 			break
 		}
 	}
 	s.mu.Unlock()
 
+	if found {
+		consumerEvent(s, cons, "disconnected")
+	}
+
 	s.stopProducers()
 }
 
@@ -81,6 +134,10 @@ func (s *Stream) AddProducer(prod core.Producer) {
 	s.mu.Lock()
 	s.producers = append(s.producers, producer)
 	s.mu.Unlock()
+
+	producer.mu.Lock()
+	producer.setStatus(StatusOnline)
+	producer.mu.Unlock()
 }
 
 func (s *Stream) RemoveProducer(prod core.Producer) {
@@ -88,6 +145,10 @@ func (s *Stream) RemoveProducer(prod core.Producer) {
 	for i, producer := range s.producers {
 		if producer.conn == prod {
 			s.producers = append(s.producers[:i], s.producers[i+1:]...)
+
+			producer.mu.Lock()
+			producer.setStatus(StatusOffline)
+			producer.mu.Unlock()
 			break
 		}
 	}
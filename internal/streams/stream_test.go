@@ -2,21 +2,27 @@ package streams
 
 import (
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRecursion(t *testing.T) {
+	HandleFunc("rtsp", func(url string) (core.Producer, error) { return nil, nil }) // bypass HasProducer
+
 	// create stream with some source
-	stream1 := New("from_yaml", "does_not_matter")
+	stream1, err := New("from_yaml", "rtsp://does_not_matter")
+	require.Nil(t, err)
 	require.Len(t, streams, 1)
 
 	// ask another unnamed stream that links go2rtc
 	query, err := url.ParseQuery("src=rtsp://localhost:8554/from_yaml?video")
 	require.Nil(t, err)
-	stream2 := GetOrPatch(query)
+	stream2, err := GetOrPatch(query)
+	require.Nil(t, err)
 
 	// check stream is same
 	require.Equal(t, stream1, stream2)
@@ -27,12 +33,133 @@ func TestRecursion(t *testing.T) {
 
 func TestTempate(t *testing.T) {
 	HandleFunc("rtsp", func(url string) (core.Producer, error) { return nil, nil }) // bypass HasProducer
+	HandleFunc("ffmpeg", func(url string) (core.Producer, error) { return nil, nil })
 
 	// config from yaml
-	stream1 := New("camera.from_hass", "ffmpeg:{input}#video=copy")
+	stream1, err := New("camera.from_hass", "ffmpeg:{input}#video=copy")
+	require.Nil(t, err)
 	// request from hass
-	stream2 := Patch("camera.from_hass", "rtsp://example.com")
+	stream2, err := Patch("camera.from_hass", "rtsp://example.com")
+	require.Nil(t, err)
 
 	require.Equal(t, stream1, stream2)
 	require.Equal(t, "ffmpeg:rtsp://example.com#video=copy", stream1.producers[0].url)
 }
+
+func TestFailover(t *testing.T) {
+	var primaryAlive atomic.Bool
+	primaryAlive.Store(true)
+
+	HandleFunc("failover", func(url string) (core.Producer, error) {
+		prod := &testProducer{alive: &primaryAlive, done: make(chan struct{})}
+		prod.URL = url
+		prod.Medias = []*core.Media{{
+			Kind: core.KindAudio, Direction: core.DirectionRecvonly,
+			Codecs: []*core.Codec{{Name: core.CodecPCMA, ClockRate: 8000}},
+		}}
+		return prod, nil
+	})
+
+	stream := NewStream([]string{"failover:primary", "failover:secondary"})
+	stream.SetFailover(&FailoverConfig{Timeout: 300 * time.Millisecond, Recovery: time.Second})
+
+	cons := &testConsumer{}
+	cons.Medias = []*core.Media{{
+		Kind: core.KindAudio, Direction: core.DirectionSendonly,
+		Codecs: []*core.Codec{{Name: core.CodecPCMA, ClockRate: 8000}},
+	}}
+	require.Nil(t, stream.AddConsumer(cons))
+	defer stream.RemoveConsumer(cons)
+
+	source := func() string {
+		s, _ := cons.source.Load().(string)
+		return s
+	}
+
+	require.Eventually(t, func() bool { return source() == "failover:primary" }, time.Second, 10*time.Millisecond)
+
+	// primary stops sending packets, but stays connected
+	primaryAlive.Store(false)
+	require.Eventually(t, func() bool { return source() == "failover:secondary" }, 3*time.Second, 10*time.Millisecond)
+
+	// primary sends packets again
+	primaryAlive.Store(true)
+	require.Eventually(t, func() bool { return source() == "failover:primary" }, 3*time.Second, 10*time.Millisecond)
+}
+
+// testProducer - sends packets with source URL as payload, primary source only while alive
+type testProducer struct {
+	core.Connection
+	alive *atomic.Bool
+	done  chan struct{}
+}
+
+func (p *testProducer) Start() error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return nil
+		case <-ticker.C:
+			if p.URL == "failover:primary" && !p.alive.Load() {
+				continue
+			}
+			for _, receiver := range p.Receivers {
+				receiver.Input(&core.Packet{Payload: []byte(p.URL)})
+			}
+		}
+	}
+}
+
+func (p *testProducer) Stop() error {
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	return nil
+}
+
+// testConsumer - saves the source of the last packet
+type testConsumer struct {
+	core.Connection
+	source atomic.Value
+}
+
+func (c *testConsumer) AddTrack(media *core.Media, _ *core.Codec, track *core.Receiver) error {
+	sender := core.NewSender(media, track.Codec)
+	sender.Handler = func(packet *core.Packet) {
+		c.source.Store(string(packet.Payload))
+	}
+	sender.HandleRTP(track)
+	c.Senders = append(c.Senders, sender)
+	return nil
+}
+
+func TestParseSource(t *testing.T) {
+	source, params := parseSource("rtmp://example.com/live#preroll=5#backoff=30#video=copy")
+	require.Equal(t, "rtmp://example.com/live#video=copy", source)
+	require.Equal(t, 30*time.Second, params.backoff)
+	require.Equal(t, 5*time.Second, params.preroll)
+	require.True(t, params.withPreroll)
+
+	_, params = parseSource("rtmp://example.com/live")
+	require.Equal(t, defaultBackoff, params.backoff)
+	require.False(t, params.withPreroll)
+}
+
+func TestProducerJSON(t *testing.T) {
+	prod := &Producer{url: "rtsp://example.com", status: StatusIdle}
+	b, err := prod.MarshalJSON()
+	require.Nil(t, err)
+	require.JSONEq(t, `{"url":"rtsp://example.com","state":"idle"}`, string(b))
+
+	conn := &testProducer{}
+	conn.FormatName = "rtsp"
+	prod = &Producer{conn: conn, status: StatusOnline}
+	b, err = prod.MarshalJSON()
+	require.Nil(t, err)
+	require.JSONEq(t, `{"format_name":"rtsp","state":"online"}`, string(b))
+}
@@ -2,6 +2,7 @@ package streams
 
 import (
 	"errors"
+	"net/http"
 	"net/url"
 	"sync"
 	"time"
@@ -13,9 +14,12 @@ import (
 
 func Init() {
 	var cfg struct {
-		Streams map[string]any    `yaml:"streams"`
-		Publish map[string]any    `yaml:"publish"`
-		Preload map[string]string `yaml:"preload"`
+		Streams map[string]any           `yaml:"streams"`
+		Publish map[string]any           `yaml:"publish"`
+		Preload map[string]string        `yaml:"preload"`
+		Buffer  map[string]*BufferConfig `yaml:"buffer"`
+
+		Failover map[string]*FailoverConfig `yaml:"failover"`
 	}
 
 	app.LoadConfig(&cfg)
@@ -26,10 +30,37 @@ func Init() {
 		streams[name] = NewStream(item)
 	}
 
+	for name, conf := range cfg.Buffer {
+		if stream := Get(name); stream != nil && conf != nil {
+			stream.SetBuffer(conf)
+
+			// buffer is useful only with running producer
+			if _, ok := cfg.Preload[name]; !ok {
+				if cfg.Preload == nil {
+					cfg.Preload = map[string]string{}
+				}
+				cfg.Preload[name] = ""
+			}
+		}
+	}
+
+	for name, conf := range cfg.Failover {
+		if stream := Get(name); stream != nil {
+			if conf == nil {
+				conf = &FailoverConfig{} // default settings
+			}
+			stream.SetFailover(conf)
+		}
+	}
+
 	api.HandleFunc("api/streams", apiStreams)
 	api.HandleFunc("api/streams.dot", apiStreamsDOT)
+	api.HandleFunc("api/metrics", apiMetrics)
 	api.HandleFunc("api/preload", apiPreload)
 	api.HandleFunc("api/schemes", apiSchemes)
+	api.HandleFunc("api/ptz", apiPTZ)
+
+	publishConfig = cfg.Publish
 
 	if cfg.Publish == nil && cfg.Preload == nil {
 		return
@@ -115,6 +146,19 @@ func Patch(name string, source string) (*Stream, error) {
 	return stream, nil
 }
 
+// GetOrPatchRequest - same as GetOrPatch, but users without admin access
+// can only get existing streams and can't create or change them
+func GetOrPatchRequest(r *http.Request) (*Stream, error) {
+	query := r.URL.Query()
+	if user := api.User(r); user != nil && !user.IsAdmin() {
+		if stream := Get(query.Get("src")); stream != nil {
+			return stream, nil
+		}
+		return nil, errors.New(api.StreamNotFound)
+	}
+	return GetOrPatch(query)
+}
+
 func GetOrPatch(query url.Values) (*Stream, error) {
 	// check if src param exists
 	source := query.Get("src")
@@ -15,11 +15,12 @@ import (
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/webrtc"
 	"github.com/gorilla/websocket"
+	"github.com/pion/sdp/v3"
 	pion "github.com/pion/webrtc/v4"
 )
 
 // streamsHandler supports:
-//  1. WHEP:    webrtc:http://192.168.1.123:1984/api/webrtc?src=camera1
+//  1. WHEP:    webrtc:http://192.168.1.123:1984/api/webrtc?src=camera1#token=secret
 //  2. go2rtc:  webrtc:ws://192.168.1.123:1984/api/ws?src=camera1
 //  3. Wyze:    webrtc:http://192.168.1.123:5000/signaling/camera1?kvs#format=wyze
 //  4. Kinesis: webrtc:wss://...amazonaws.com/?...#format=kinesis#client_id=...#ice_servers=[{...},{...}]
@@ -59,7 +60,7 @@ func streamsHandler(rawURL string) (core.Producer, error) {
 			} else if format == "creality" {
 				return crealityClient(rawURL)
 			} else {
-				return whepClient(rawURL)
+				return whepClient(rawURL, query)
 			}
 		}
 	}
@@ -181,8 +182,8 @@ func go2rtcClient(url string) (core.Producer, error) {
 }
 
 // whepClient - support WebRTC-HTTP Egress Protocol (WHEP)
-// ex: http://localhost:1984/api/webrtc?src=camera1
-func whepClient(url string) (core.Producer, error) {
+// ex: http://localhost:1984/api/webrtc?src=camera1#token=secret
+func whepClient(url string, query url.Values) (core.Producer, error) {
 	// 2. Create PeerConnection
 	pc, err := PeerConnection(true)
 	if err != nil {
@@ -203,35 +204,186 @@ func whepClient(url string) (core.Producer, error) {
 	// 3. Create offer
 	offer, err := prod.CreateCompleteOffer(medias)
 	if err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
 
 	req, err := http.NewRequest("POST", url, strings.NewReader(offer))
 	if err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
 	req.Header.Set("Content-Type", MimeSDP)
 
+	bearer := query.Get("token")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
 	client := http.Client{Timeout: time.Second * 5000}
 	defer client.CloseIdleConnections()
 
 	res, err := client.Do(req)
 	if err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		_ = pc.Close()
+		return nil, errors.New("webrtc: wrong WHEP response: " + res.Status)
+	}
 
 	answer, err := io.ReadAll(res.Body)
 	if err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
 
+	// 4. Delete session resource on server when connection closed
+	if location, err := res.Location(); err == nil {
+		prod.Listen(func(msg any) {
+			if msg == pion.PeerConnectionStateClosed {
+				go whepDelete(location.String(), bearer)
+			}
+		})
+	}
+
 	if err = prod.SetAnswer(string(answer)); err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
 
 	return prod, nil
 }
 
+func whepDelete(location, bearer string) {
+	req, err := http.NewRequest("DELETE", location, nil)
+	if err != nil {
+		return
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	client := http.Client{Timeout: time.Second * 5}
+	defer client.CloseIdleConnections()
+
+	if res, err := client.Do(req); err == nil {
+		_ = res.Body.Close()
+	}
+}
+
+// streamsConsumerHandler - support WebRTC-HTTP Ingestion Protocol (WHIP) for publish
+// ex: whip:https://example.com/whip/endpoint#token=secret
+func streamsConsumerHandler(rawURL string) (core.Consumer, func(), error) {
+	var query url.Values
+	if i := strings.IndexByte(rawURL, '#'); i > 0 {
+		query = streams.ParseQuery(rawURL[i+1:])
+		rawURL = rawURL[:i]
+	}
+
+	// remove whip: or webrtc:
+	_, rawURL, _ = strings.Cut(rawURL, ":")
+
+	cons, answer, err := whipClient(rawURL, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	run := func() {
+		// start ICE only after all stream tracks added to the connection
+		if err := cons.SetAnswer(answer); err != nil {
+			log.Warn().Err(err).Caller().Send()
+			return
+		}
+		// connection will be closed on ICE failure and the publisher will reconnect
+		_ = cons.Start()
+	}
+
+	return cons, run, nil
+}
+
+// whipClient - sendonly connection to the WHIP server
+// ex: http://localhost:1984/api/webrtc?dst=camera1#token=secret
+func whipClient(url string, query url.Values) (*webrtc.Conn, string, error) {
+	pc, err := PeerConnection(true)
+	if err != nil {
+		log.Error().Err(err).Caller().Send()
+		return nil, "", err
+	}
+
+	cons := webrtc.NewConn(pc)
+	cons.Mode = core.ModeActiveConsumer
+	cons.Protocol = "http"
+	cons.URL = url
+
+	medias := []*core.Media{
+		{Kind: core.KindVideo, Direction: core.DirectionSendonly},
+		{Kind: core.KindAudio, Direction: core.DirectionSendonly},
+	}
+
+	offer, err := cons.CreateCompleteOffer(medias)
+	if err != nil {
+		_ = pc.Close()
+		return nil, "", err
+	}
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(offer))
+	if err != nil {
+		_ = pc.Close()
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", MimeSDP)
+
+	bearer := query.Get("token")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	client := http.Client{Timeout: time.Second * 5}
+	defer client.CloseIdleConnections()
+
+	res, err := client.Do(req)
+	if err != nil {
+		_ = pc.Close()
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		_ = pc.Close()
+		return nil, "", errors.New("webrtc: wrong WHIP response: " + res.Status)
+	}
+
+	answer, err := io.ReadAll(res.Body)
+	if err != nil {
+		_ = pc.Close()
+		return nil, "", err
+	}
+
+	// delete session resource on server when connection closed
+	if location, err := res.Location(); err == nil {
+		cons.Listen(func(msg any) {
+			if msg == pion.PeerConnectionStateClosed {
+				go whepDelete(location.String(), bearer)
+			}
+		})
+	}
+
+	// answer medias will be used for matching stream tracks
+	sd := &sdp.SessionDescription{}
+	if err = sd.Unmarshal(answer); err != nil {
+		_ = pc.Close()
+		return nil, "", err
+	}
+
+	cons.Medias = webrtc.UnmarshalMedias(sd.MediaDescriptions)
+
+	return cons, string(answer), nil
+}
+
 // Dial - websocket.Dial with Basic auth support
 func Dial(rawURL string) (*websocket.Conn, *http.Response, error) {
 	u, err := url.Parse(rawURL)
@@ -1,16 +1,18 @@
 package webrtc
 
 import (
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
-	"strconv"
+	"slices"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/webrtc"
 	pion "github.com/pion/webrtc/v4"
@@ -18,9 +20,34 @@ import (
 
 const MimeSDP = "application/sdp"
 
-var sessions = map[string]*webrtc.Conn{}
+// session - WHIP/WHEP resource with the stream name and action for access control
+type session struct {
+	conn   *webrtc.Conn
+	stream string
+	action string
+}
+
+// WHIP/WHEP sessions (RFC 9725), resource URL - api/webrtc?id=...
+var sessions = map[string]*session{}
+var sessionsMu sync.Mutex
+
+// token - optional Bearer token for WHIP/WHEP requests
+var token string
 
 func syncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "OPTIONS" {
+		setLinks(w)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// token is required for any request, not only for WHIP/WHEP SDP
+	if token != "" && !validToken(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	switch r.Method {
 	case "POST":
 		query := r.URL.Query()
@@ -35,29 +62,145 @@ func syncHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case "PATCH":
-		// TODO: WHEP/WHIP
-		http.Error(w, "", http.StatusMethodNotAllowed)
+		if conn := getSession(w, r); conn != nil {
+			patchSession(w, r, conn)
+		}
 
 	case "DELETE":
-		if id := r.URL.Query().Get("id"); id != "" {
-			if conn, ok := sessions[id]; ok {
-				delete(sessions, id)
-				_ = conn.Close()
-			} else {
-				http.Error(w, "", http.StatusNotFound)
-			}
-		} else {
-			http.Error(w, "", http.StatusBadRequest)
+		if conn := getSession(w, r); conn != nil {
+			_ = conn.Close() // session will be removed on closed state
 		}
 
-	case "OPTIONS":
-		w.WriteHeader(http.StatusNoContent)
-
 	default:
 		http.Error(w, "", http.StatusMethodNotAllowed)
 	}
 }
 
+// validToken - check Bearer token in constant time
+func validToken(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	return subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) == 1
+}
+
+func getSession(w http.ResponseWriter, r *http.Request) *webrtc.Conn {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil
+	}
+
+	sessionsMu.Lock()
+	s := sessions[id]
+	sessionsMu.Unlock()
+
+	if s == nil {
+		http.Error(w, "", http.StatusNotFound)
+		return nil
+	}
+
+	if !api.Allow(r, s.stream, s.action) {
+		http.Error(w, "", http.StatusForbidden)
+		return nil
+	}
+
+	return s.conn
+}
+
+// addSession - register WHIP/WHEP resource and write 201 response headers
+func addSession(w http.ResponseWriter, conn *webrtc.Conn, stream, action string) {
+	id := core.RandString(16, 62)
+
+	sessionsMu.Lock()
+	sessions[id] = &session{conn: conn, stream: stream, action: action}
+	sessionsMu.Unlock()
+
+	conn.Listen(func(msg any) {
+		if msg == pion.PeerConnectionStateClosed {
+			sessionsMu.Lock()
+			delete(sessions, id)
+			sessionsMu.Unlock()
+		}
+	})
+
+	setLinks(w)
+
+	header := w.Header()
+	header.Set("Content-Type", MimeSDP)
+	header.Set("Location", "webrtc?id="+id)
+	header.Set("ETag", etag(conn))
+	header.Set("Accept-Patch", webrtc.MimeSDPFrag)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// setLinks - ICE servers for WHIP/WHEP clients
+func setLinks(w http.ResponseWriter) {
+	for _, link := range webrtc.LinkICEServers(append(slices.Clip(iceServers), GetTURNServers()...)) {
+		w.Header().Add("Link", link)
+	}
+}
+
+// etag - identify ICE session by local ICE ufrag
+func etag(conn *webrtc.Conn) string {
+	return `"` + conn.LocalICE().Ufrag + `"`
+}
+
+// patchSession - trickle ICE candidates or ICE restart with new credentials
+func patchSession(w http.ResponseWriter, r *http.Request, conn *webrtc.Conn) {
+	if mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); mediaType != webrtc.MimeSDPFrag {
+		http.Error(w, "", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Trace().Msgf("[webrtc] PATCH\n%s", body)
+
+	frag := webrtc.ParseSDPFrag(string(body))
+	ifMatch := r.Header.Get("If-Match")
+
+	if ifMatch == "*" {
+		// ICE restart, https://www.rfc-editor.org/rfc/rfc9725.html#section-4.4
+		if frag.Ufrag == "" || frag.Pwd == "" {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		local, err := conn.RestartICE(frag.Ufrag, frag.Pwd, GetCandidates(), FilterCandidate)
+		if err != nil {
+			log.Warn().Err(err).Caller().Send()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, candidate := range frag.Candidates {
+			_ = conn.AddCandidate(candidate)
+		}
+
+		w.Header().Set("Content-Type", webrtc.MimeSDPFrag)
+		w.Header().Set("ETag", etag(conn))
+		_, _ = w.Write([]byte(local.Marshal()))
+		return
+	}
+
+	if ifMatch != "" && ifMatch != etag(conn) {
+		http.Error(w, "", http.StatusPreconditionFailed)
+		return
+	}
+
+	for _, candidate := range frag.Candidates {
+		log.Trace().Str("candidate", candidate).Msg("[webrtc] remote")
+		if err = conn.AddCandidate(candidate); err != nil {
+			log.Warn().Err(err).Caller().Send()
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // outputWebRTC support API depending on Content-Type:
 // 1. application/json - receive {"type":"offer","sdp":"v=0\r\n..."} and response {"type":"answer","sdp":"v=0\r\n..."}
 // 2. application/sdp - receive/response SDP via WebRTC-HTTP Egress Protocol (WHEP)
@@ -124,7 +267,11 @@ func outputWebRTC(w http.ResponseWriter, r *http.Request) {
 		desc = "webrtc/post"
 	}
 
-	answer, err := ExchangeSDP(stream, offer, desc, r.UserAgent())
+	allow := func(action string) bool {
+		return api.Allow(r, u, action)
+	}
+
+	conn, answer, err := exchangeSDP(stream, offer, desc, r.UserAgent(), allow)
 	if err != nil {
 		log.Error().Err(err).Caller().Send()
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -146,8 +293,7 @@ func outputWebRTC(w http.ResponseWriter, r *http.Request) {
 		_, err = w.Write([]byte(answerB64))
 
 	case MimeSDP:
-		w.Header().Set("Content-Type", mediaType)
-		w.WriteHeader(http.StatusCreated)
+		addSession(w, conn, u, acl.ActionView)
 
 		_, err = w.Write([]byte(answer))
 
@@ -209,24 +355,18 @@ func inputWebRTC(w http.ResponseWriter, r *http.Request) {
 
 	log.Trace().Msgf("[webrtc] WHIP answer\n%s", answer)
 
-	id := strconv.FormatInt(time.Now().UnixNano(), 36)
-	sessions[id] = prod
-
 	prod.Listen(func(msg any) {
 		switch msg := msg.(type) {
 		case pion.PeerConnectionState:
 			if msg == pion.PeerConnectionStateClosed {
 				stream.RemoveProducer(prod)
-				delete(sessions, id)
 			}
 		}
 	})
 
 	stream.AddProducer(prod)
 
-	w.Header().Set("Content-Type", MimeSDP)
-	w.Header().Set("Location", "webrtc?id="+id)
-	w.WriteHeader(http.StatusCreated)
+	addSession(w, prod, dst, acl.ActionPublish)
 
 	if _, err = w.Write([]byte(answer)); err != nil {
 		log.Warn().Err(err).Caller().Send()
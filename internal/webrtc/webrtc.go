@@ -2,12 +2,16 @@ package webrtc
 
 import (
 	"errors"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/internal/api"
 	"github.com/AlexxIT/go2rtc/internal/api/ws"
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/streams"
+	"github.com/AlexxIT/go2rtc/pkg/acl"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/webrtc"
 	pion "github.com/pion/webrtc/v4"
@@ -17,10 +21,12 @@ import (
 func Init() {
 	var cfg struct {
 		Mod struct {
-			Listen     string           `yaml:"listen"`
-			Candidates []string         `yaml:"candidates"`
-			IceServers []pion.ICEServer `yaml:"ice_servers"`
-			Filters    webrtc.Filters   `yaml:"filters"`
+			Listen     string            `yaml:"listen"`
+			Candidates []string          `yaml:"candidates"`
+			IceServers []pion.ICEServer  `yaml:"ice_servers"`
+			Filters    webrtc.Filters    `yaml:"filters"`
+			Token      string            `yaml:"token"`
+			TURN       webrtc.TURNConfig `yaml:"turn"`
 		} `yaml:"webrtc"`
 	}
 
@@ -28,12 +34,19 @@ func Init() {
 	cfg.Mod.IceServers = []pion.ICEServer{
 		{URLs: []string{"stun:stun.l.google.com:19302"}},
 	}
+	cfg.Mod.TURN.TTL = 24 * time.Hour
 
 	app.LoadConfig(&cfg)
 
 	log = app.GetLogger("webrtc")
 
 	filters = cfg.Mod.Filters
+	iceServers = cfg.Mod.IceServers
+	token = cfg.Mod.Token
+	if token != "" {
+		// WHIP/WHEP token is separate from the API access control tokens
+		api.AddToken("api/webrtc", token)
+	}
 
 	address, network, _ := strings.Cut(cfg.Mod.Listen, "/")
 	for _, candidate := range cfg.Mod.Candidates {
@@ -71,19 +84,43 @@ func Init() {
 		}
 	}
 
+	if cfg.Mod.TURN.Listen != "" {
+		if turnServer, err = webrtc.NewTURNServer(&cfg.Mod.TURN); err != nil {
+			log.Error().Err(err).Caller().Send()
+		} else {
+			log.Info().Str("addr", cfg.Mod.TURN.Listen).Strs("urls", turnServer.URLs).Msg("[webrtc] turn listen")
+		}
+	}
+
 	// async WebRTC server (two API versions)
 	ws.HandleFunc("webrtc", asyncHandler)
 	ws.HandleFunc("webrtc/offer", asyncHandler)
 	ws.HandleFunc("webrtc/candidate", candidateHandler)
+	ws.HandleFunc("webrtc/layer", layerHandler)
+	ws.HandleFunc("webrtc/ice_servers", iceServersHandler)
 
 	// sync WebRTC server (two API versions)
 	api.HandleFunc("api/webrtc", syncHandler)
 
 	// WebRTC client
 	streams.HandleFunc("webrtc", streamsHandler)
+
+	// WebRTC client for publish (WHIP)
+	streams.HandleConsumerFunc("webrtc", streamsConsumerHandler)
+	streams.HandleConsumerFunc("whip", streamsConsumerHandler)
 }
 
 var serverAPI, clientAPI *pion.API
+var iceServers []pion.ICEServer
+var turnServer *webrtc.TURNServer
+
+// GetTURNServers - built-in TURN server with new time-limited credentials for viewers
+func GetTURNServers() []pion.ICEServer {
+	if turnServer == nil {
+		return nil
+	}
+	return []pion.ICEServer{turnServer.ICEServer()}
+}
 
 var log zerolog.Logger
 
@@ -92,10 +129,11 @@ var PeerConnection func(active bool) (*pion.PeerConnection, error)
 func asyncHandler(tr *ws.Transport, msg *ws.Message) (err error) {
 	var stream *streams.Stream
 	var mode core.Mode
+	var name string
 
 	query := tr.Request.URL.Query()
-	if name := query.Get("src"); name != "" {
-		stream, _ = streams.GetOrPatch(query)
+	if name = query.Get("src"); name != "" {
+		stream, _ = streams.GetOrPatchRequest(tr.Request)
 		mode = core.ModePassiveConsumer
 		log.Debug().Str("src", name).Msg("[webrtc] new consumer")
 	} else if name = query.Get("dst"); name != "" {
@@ -149,6 +187,7 @@ func asyncHandler(tr *ws.Transport, msg *ws.Message) (err error) {
 	conn.Listen(func(msg any) {
 		switch msg := msg.(type) {
 		case pion.PeerConnectionState:
+			sendEvent(stream, conn, msg)
 			if msg != pion.PeerConnectionStateClosed {
 				return
 			}
@@ -181,6 +220,10 @@ func asyncHandler(tr *ws.Transport, msg *ws.Message) (err error) {
 
 	switch mode {
 	case core.ModePassiveConsumer:
+		if !api.Allow(tr.Request, name, acl.ActionBackchannel) {
+			removeBackchannel(conn)
+		}
+
 		// 2. AddConsumer, so we get new tracks
 		if err = stream.AddConsumer(conn); err != nil {
 			log.Debug().Err(err).Msg("[webrtc] add consumer")
@@ -214,7 +257,44 @@ func asyncHandler(tr *ws.Transport, msg *ws.Message) (err error) {
 	return nil
 }
 
+// iceServersHandler - built-in TURN server for the viewer, should be asked before the offer,
+// so the first WebRTC connection can use it
+func iceServersHandler(tr *ws.Transport, _ *ws.Message) error {
+	tr.Write(&ws.Message{Type: "webrtc/ice_servers", Value: GetTURNServers()})
+	return nil
+}
+
+// layerHandler - switch quality layer of the stream for this viewer: name or "auto"
+func layerHandler(tr *ws.Transport, msg *ws.Message) (err error) {
+	var conn *webrtc.Conn
+
+	tr.WithContext(func(ctx map[any]any) {
+		conn, _ = ctx["webrtc"].(*webrtc.Conn)
+	})
+
+	if conn == nil {
+		return errors.New("webrtc: connection not ready")
+	}
+
+	if err = conn.SetLayer(msg.String()); err != nil {
+		return err
+	}
+
+	log.Debug().Str("layer", msg.String()).Msg("[webrtc] switch layer")
+
+	tr.Write(&ws.Message{Type: "webrtc/layer", Value: msg.String()})
+	return nil
+}
+
 func ExchangeSDP(stream *streams.Stream, offer, desc, userAgent string) (answer string, err error) {
+	_, answer, err = exchangeSDP(stream, offer, desc, userAgent, nil)
+	return
+}
+
+// exchangeSDP - allow is optional check for the stream actions (backchannel and publish)
+func exchangeSDP(
+	stream *streams.Stream, offer, desc, userAgent string, allow func(action string) bool,
+) (conn *webrtc.Conn, answer string, err error) {
 	pc, err := PeerConnection(false)
 	if err != nil {
 		log.Error().Err(err).Caller().Send()
@@ -222,13 +302,14 @@ func ExchangeSDP(stream *streams.Stream, offer, desc, userAgent string) (answer
 	}
 
 	// create new webrtc instance
-	conn := webrtc.NewConn(pc)
+	conn = webrtc.NewConn(pc)
 	conn.FormatName = desc
 	conn.UserAgent = userAgent
 	conn.Protocol = "http"
 	conn.Listen(func(msg any) {
 		switch msg := msg.(type) {
 		case pion.PeerConnectionState:
+			sendEvent(stream, conn, msg)
 			if msg != pion.PeerConnectionStateClosed {
 				return
 			}
@@ -251,6 +332,10 @@ func ExchangeSDP(stream *streams.Stream, offer, desc, userAgent string) (answer
 	if IsConsumer(conn) {
 		conn.Mode = core.ModePassiveConsumer
 
+		if allow != nil && !allow(acl.ActionBackchannel) {
+			removeBackchannel(conn)
+		}
+
 		// 2. AddConsumer, so we get new tracks
 		if err = stream.AddConsumer(conn); err != nil {
 			log.Warn().Err(err).Caller().Send()
@@ -260,6 +345,12 @@ func ExchangeSDP(stream *streams.Stream, offer, desc, userAgent string) (answer
 	} else {
 		conn.Mode = core.ModePassiveProducer
 
+		if allow != nil && !allow(acl.ActionPublish) {
+			_ = conn.Close()
+			err = errors.New("webrtc: publish not allowed")
+			return
+		}
+
 		stream.AddProducer(conn)
 	}
 
@@ -273,6 +364,13 @@ func ExchangeSDP(stream *streams.Stream, offer, desc, userAgent string) (answer
 	return
 }
 
+// removeBackchannel - remove medias for sending audio from the viewer to the camera
+func removeBackchannel(conn *webrtc.Conn) {
+	conn.Medias = slices.DeleteFunc(conn.Medias, func(media *core.Media) bool {
+		return media.Direction == core.DirectionRecvonly
+	})
+}
+
 func IsConsumer(conn *webrtc.Conn) bool {
 	// if wants get video - consumer
 	for _, media := range conn.GetMedias() {
@@ -294,3 +392,21 @@ func IsConsumer(conn *webrtc.Conn) bool {
 	}
 	return false
 }
+
+// sendEvent - WebRTC server peer connection events: connected, disconnected, failed, closed
+func sendEvent(stream *streams.Stream, conn *webrtc.Conn, state pion.PeerConnectionState) {
+	switch state {
+	case pion.PeerConnectionStateConnected, pion.PeerConnectionStateDisconnected,
+		pion.PeerConnectionStateFailed, pion.PeerConnectionStateClosed:
+	default:
+		return
+	}
+
+	events.Publish(&events.Event{
+		Type:   "webrtc",
+		Stream: streams.GetName(stream),
+		Source: conn.RemoteAddr,
+		State:  state.String(),
+		Data:   map[string]any{"mode": conn.Mode.String(), "user_agent": conn.UserAgent},
+	})
+}
@@ -2,6 +2,8 @@ package webrtc
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -71,3 +73,24 @@ a=candidate:3 1 TCP 1010827519 172.22.233.10 60677 typ host tcptype passive
 	require.Nil(t, err)
 	require.False(t, strings.Contains(sdp, "x-google-max-bitrate"))
 }
+
+func TestSyncHandlerToken(t *testing.T) {
+	token = "secret"
+	defer func() { token = "" }()
+
+	for _, contentType := range []string{MimeSDP, "application/json", ""} {
+		r := httptest.NewRequest("POST", "/api/webrtc?src=camera1", strings.NewReader("v=0"))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		syncHandler(w, r)
+		require.Equal(t, http.StatusUnauthorized, w.Code, contentType)
+	}
+
+	r := httptest.NewRequest("DELETE", "/api/webrtc?id=unknown", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	syncHandler(w, r)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,9 +1,11 @@
 package wyoming
 
 import (
+	"encoding/json"
 	"net"
 
 	"github.com/AlexxIT/go2rtc/internal/app"
+	"github.com/AlexxIT/go2rtc/internal/events"
 	"github.com/AlexxIT/go2rtc/internal/streams"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/wyoming"
@@ -59,6 +61,9 @@ func Init() {
 			SndHandler: func(prod core.Producer) error {
 				return stream.Play(prod)
 			},
+			EventHandler: func(evt *wyoming.Event) {
+				sendEvent(name, evt)
+			},
 			Trace: func(format string, v ...any) {
 				log.Trace().Msgf("[wyoming] "+format, v...)
 			},
@@ -72,6 +77,18 @@ func Init() {
 
 var log zerolog.Logger
 
+// sendEvent - satellite events: detection, transcript, synthesize, error...
+func sendEvent(name string, evt *wyoming.Event) {
+	var data any
+	if json.Valid([]byte(evt.Data)) {
+		data = json.RawMessage(evt.Data)
+	} else if evt.Data != "" {
+		data = evt.Data
+	}
+
+	events.Publish(&events.Event{Type: "wyoming", Stream: name, State: evt.Type, Data: data})
+}
+
 func serve(srv *wyoming.Server, mode, address string) {
 	ln, err := net.Listen("tcp", address)
 	if err != nil {
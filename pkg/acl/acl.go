@@ -0,0 +1,137 @@
+package acl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ActionView        = "view"        // watch the stream
+	ActionPublish     = "publish"     // send the stream to the server (RTSP, RTMP, WHIP, etc.)
+	ActionBackchannel = "backchannel" // send audio to the camera (two-way audio)
+	ActionAdmin       = "admin"       // any action for any stream, config and other API
+)
+
+type User struct {
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Token    string   `yaml:"token"`
+	Streams  []string `yaml:"streams"` // stream names or patterns, ex. camera1, garage_*, *
+	Actions  []string `yaml:"actions"`
+
+	stream string // stream name from signed token, compared exactly (not a pattern)
+}
+
+// Admin - user with full access (global credentials or localhost)
+var Admin = &User{Actions: []string{ActionAdmin}}
+
+func (u *User) IsAdmin() bool {
+	return slices.Contains(u.Actions, ActionAdmin)
+}
+
+// Allow - check action for the stream
+func (u *User) Allow(stream, action string) bool {
+	if u.IsAdmin() {
+		return true
+	}
+	if !slices.Contains(u.Actions, action) {
+		return false
+	}
+	if u.stream != "" {
+		return stream == u.stream
+	}
+	for _, pattern := range u.Streams {
+		if ok, _ := path.Match(pattern, stream); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type ACL struct {
+	Users []*User
+	Key   []byte // secret key for signed tokens
+}
+
+// Login - user with the same username and password
+func (a *ACL) Login(username, password string) *User {
+	if username == "" {
+		return nil
+	}
+	for _, user := range a.Users {
+		if equal(user.Username, username) && equal(user.Password, password) {
+			return user
+		}
+	}
+	return nil
+}
+
+// Token - user with static token or view only user from signed token
+func (a *ACL) Token(token string) *User {
+	if token == "" {
+		return nil
+	}
+	for _, user := range a.Users {
+		if user.Token != "" && equal(user.Token, token) {
+			return user
+		}
+	}
+	if stream, ok := a.Verify(token, time.Now()); ok {
+		return &User{Actions: []string{ActionView}, stream: stream}
+	}
+	return nil
+}
+
+// Sign - token with view access to one stream until expiration time.
+// Format: {expires unix time}.{base64 stream name}.{base64 HMAC-SHA256 signature}
+func (a *ACL) Sign(stream string, expires time.Time) string {
+	payload := strconv.FormatInt(expires.Unix(), 10) + "." + base64.RawURLEncoding.EncodeToString([]byte(stream))
+	return payload + "." + a.signature(payload)
+}
+
+// Verify - stream name from the valid signed token
+func (a *ACL) Verify(token string, now time.Time) (string, bool) {
+	if a.Key == nil {
+		return "", false
+	}
+
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(a.signature(token[:i]))) {
+		return "", false
+	}
+
+	ts, name, ok := strings.Cut(token[:i], ".")
+	if !ok {
+		return "", false
+	}
+
+	expires, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", false
+	}
+
+	stream, err := base64.RawURLEncoding.DecodeString(name)
+	if err != nil {
+		return "", false
+	}
+
+	return string(stream), true
+}
+
+// equal - constant time compare for credentials and tokens
+func equal(s1, s2 string) bool {
+	return subtle.ConstantTimeCompare([]byte(s1), []byte(s2)) == 1
+}
+
+func (a *ACL) signature(payload string) string {
+	h := hmac.New(sha256.New, a.Key)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,71 @@
+package acl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllow(t *testing.T) {
+	user := &User{Streams: []string{"camera1", "garage_*"}, Actions: []string{ActionView, ActionBackchannel}}
+
+	require.True(t, user.Allow("camera1", ActionView))
+	require.True(t, user.Allow("garage_door", ActionBackchannel))
+	require.False(t, user.Allow("camera2", ActionView))
+	require.False(t, user.Allow("camera1", ActionPublish))
+	require.False(t, user.IsAdmin())
+
+	require.True(t, Admin.Allow("camera2", ActionPublish))
+}
+
+func TestLogin(t *testing.T) {
+	a := &ACL{Users: []*User{
+		{Username: "viewer", Password: "pass", Actions: []string{ActionView}},
+		{Token: "secret", Actions: []string{ActionPublish}},
+	}}
+
+	require.NotNil(t, a.Login("viewer", "pass"))
+	require.Nil(t, a.Login("viewer", "wrong"))
+	require.Nil(t, a.Login("", ""))
+
+	require.NotNil(t, a.Token("secret"))
+	require.Nil(t, a.Token("wrong"))
+	require.Nil(t, a.Token(""))
+}
+
+func TestSign(t *testing.T) {
+	a := &ACL{Key: []byte("key")}
+
+	now := time.Now()
+	token := a.Sign("camera 1", now.Add(time.Hour))
+
+	stream, ok := a.Verify(token, now)
+	require.True(t, ok)
+	require.Equal(t, "camera 1", stream)
+
+	user := a.Token(token)
+	require.True(t, user.Allow("camera 1", ActionView))
+	require.False(t, user.Allow("camera 1", ActionPublish))
+	require.False(t, user.Allow("camera2", ActionView))
+
+	// stream name isn't a pattern
+	user = a.Token(a.Sign("camera*", now.Add(time.Hour)))
+	require.True(t, user.Allow("camera*", ActionView))
+	require.False(t, user.Allow("camera1", ActionView))
+
+	// expired token
+	_, ok = a.Verify(token, now.Add(2*time.Hour))
+	require.False(t, ok)
+
+	// token for another stream with the same signature
+	_, ok = a.Verify(token[:11]+"Y2FtZXJhMg"+token[19:], now)
+	require.False(t, ok)
+
+	// another key
+	_, ok = (&ACL{Key: []byte("other")}).Verify(token, now)
+	require.False(t, ok)
+
+	// signed tokens disabled
+	require.Nil(t, (&ACL{}).Token(token))
+}
@@ -0,0 +1,142 @@
+// Package av1 - AV1 low overhead bitstream format related functions
+package av1
+
+import (
+	"github.com/AlexxIT/go2rtc/pkg/bits"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	OBUTypeSequenceHeader    = 1
+	OBUTypeTemporalDelimiter = 2
+	OBUTypeFrameHeader       = 3
+	OBUTypeFrame             = 6
+)
+
+// OBUs - split temporal unit in low overhead bitstream format (with obu_size fields)
+func OBUs(b []byte) (obus [][]byte) {
+	for len(b) > 0 {
+		header := 1
+		if b[0]&0b100 != 0 {
+			header++ // obu_extension_flag
+		}
+
+		if b[0]&0b10 == 0 || len(b) < header {
+			// obu_has_size_field is not set, OBU lasts until the end
+			return append(obus, b)
+		}
+
+		size, n := leb128(b[header:])
+		if n == 0 || header+n+int(size) > len(b) {
+			return
+		}
+
+		size += uint64(header + n)
+		obus = append(obus, b[:size])
+		b = b[size:]
+	}
+
+	return
+}
+
+func OBUType(obu []byte) byte {
+	return (obu[0] >> 3) & 0b1111
+}
+
+// SequenceHeader - return sequence header OBU from temporal unit
+func SequenceHeader(b []byte) []byte {
+	for _, obu := range OBUs(b) {
+		if OBUType(obu) == OBUTypeSequenceHeader {
+			return obu
+		}
+	}
+	return nil
+}
+
+// IsKeyframe - encoders send sequence header before each keyframe
+func IsKeyframe(b []byte) bool {
+	return SequenceHeader(b) != nil
+}
+
+// EncodeConfig - AV1CodecConfigurationRecord (av1C) from sequence header OBU,
+// https://aomediacodec.github.io/av1-isobmff/#av1codecconfigurationbox-syntax
+func EncodeConfig(obu []byte) []byte {
+	var profile, level, tier byte
+	level = 31 // maximum parameters
+
+	// skip OBU header and size
+	data := obu[1:]
+	if obu[0]&0b100 != 0 {
+		data = data[1:]
+	}
+	if obu[0]&0b10 != 0 {
+		_, n := leb128(data)
+		data = data[n:]
+	}
+
+	if len(data) > 0 {
+		rd := bits.NewReader(data)
+		profile = rd.ReadBits8(3)
+		_ = rd.ReadBit() // still_picture
+		if reduced := rd.ReadBit(); reduced != 0 {
+			level = rd.ReadBits8(5)
+		} else if timing := rd.ReadBit(); timing == 0 {
+			_ = rd.ReadBit()      // initial_display_delay_present_flag
+			_ = rd.ReadBits8(5)   // operating_points_cnt_minus_1
+			_ = rd.ReadBits16(12) // operating_point_idc[0]
+			if level = rd.ReadBits8(5); level > 7 {
+				tier = rd.ReadBit()
+			}
+		}
+	}
+
+	b := []byte{
+		0x81, // marker + version
+		profile<<5 | level,
+		tier << 7, // other flags are zero: 8 bit, 4:2:0
+		0,         // no initial_presentation_delay
+	}
+
+	if profile == 0 {
+		b[2] |= 0b1100 // chroma_subsampling_x and chroma_subsampling_y
+	}
+
+	return append(b, obu...)
+}
+
+// RTPDepay - join RTP packets into temporal units in low overhead bitstream format
+func RTPDepay(handler core.HandlerFunc) core.HandlerFunc {
+	depack := &codecs.AV1Depacketizer{}
+	var buf []byte
+
+	return func(packet *rtp.Packet) {
+		b, err := depack.Unmarshal(packet.Payload)
+		if err != nil {
+			buf = nil
+			return
+		}
+
+		buf = append(buf, b...)
+
+		if !packet.Marker {
+			return
+		}
+
+		clone := *packet
+		clone.Payload = buf
+		buf = nil
+		handler(&clone)
+	}
+}
+
+func leb128(b []byte) (v uint64, n int) {
+	for i := 0; i < 8 && i < len(b); i++ {
+		v |= uint64(b[i]&0x7F) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Buffer - history of Receiver packets, that can be sent to the new consumers
+// (pre-event recording, fast start of playback).
+// For codecs with keyframes, buffer always starts from a keyframe and trims only by whole GOPs.
+type Buffer struct {
+	Duration time.Duration // keep packets for at least this time
+	Size     int           // max size of packets payload in bytes, zero - unlimited
+
+	// IsKeyframe - check if packet starts a keyframe, nil for codecs without keyframes (audio)
+	IsKeyframe func(packet *Packet) bool
+
+	items []*bufferItem
+	size  int
+
+	keyTS  uint32 // timestamp of last keyframe start
+	hasKey bool
+
+	mu sync.Mutex
+}
+
+type bufferItem struct {
+	packet   *Packet
+	size     int // consumers can change packet payload, so size is saved
+	time     time.Time
+	keyframe bool
+}
+
+// NewBuffer - return empty buffer with same settings
+func (b *Buffer) NewBuffer() *Buffer {
+	return &Buffer{Duration: b.Duration, Size: b.Size, IsKeyframe: b.IsKeyframe}
+}
+
+// KeyframeTime - return receive time of last keyframe before t or time of first keyframe
+// if all keyframes are newer. Returns false for buffers without keyframes.
+func (b *Buffer) KeyframeTime(t time.Time) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var keytime time.Time
+	for _, item := range b.items {
+		if !item.keyframe {
+			continue
+		}
+		if keytime.IsZero() || !item.time.After(t) {
+			keytime = item.time
+		} else {
+			break
+		}
+	}
+	return keytime, !keytime.IsZero()
+}
+
+// Len - return number of packets in buffer
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+func (b *Buffer) push(packet *Packet, now time.Time) {
+	item := &bufferItem{packet: packet, size: len(packet.Payload), time: now}
+
+	if b.IsKeyframe != nil {
+		// all packets of one keyframe (SPS, PPS, IDR) have same timestamp
+		if b.IsKeyframe(packet) && !(b.hasKey && b.keyTS == packet.Timestamp) {
+			item.keyframe = true
+			b.keyTS = packet.Timestamp
+			b.hasKey = true
+		} else if len(b.items) == 0 {
+			return // wait first keyframe
+		}
+	}
+
+	b.items = append(b.items, item)
+	b.size += item.size
+
+	if b.IsKeyframe == nil || item.keyframe {
+		b.trim(now.Add(-b.Duration))
+	}
+}
+
+// trim - remove packets older than deadline and oldest packets above Size.
+// For codecs with keyframes it never removes the last GOP.
+func (b *Buffer) trim(deadline time.Time) {
+	var i int // first item to keep
+
+	for j, item := range b.items {
+		if b.IsKeyframe == nil {
+			if !item.time.Before(deadline) {
+				break
+			}
+			i = j + 1
+		} else if item.keyframe {
+			if item.time.After(deadline) {
+				break
+			}
+			i = j // last keyframe before deadline
+		}
+	}
+
+	size := b.size
+	for _, item := range b.items[:i] {
+		size -= item.size
+	}
+
+	if b.Size > 0 {
+		rest := size // size of items[j:]
+		for j := i; j < len(b.items) && size > b.Size; j++ {
+			item := b.items[j]
+			if j > i && (b.IsKeyframe == nil || item.keyframe) {
+				i = j
+				size = rest
+			}
+			rest -= item.size
+		}
+	}
+
+	if i == 0 {
+		return
+	}
+
+	clear(b.items[:i]) // release packets for GC
+	b.items = b.items[i:]
+	b.size = size
+}
+
+// packets - return packets received after since time, starting from a keyframe
+func (b *Buffer) packets(since time.Time) []*Packet {
+	var packets []*Packet
+	for _, item := range b.items {
+		if packets == nil {
+			if item.time.Before(since) || b.IsKeyframe != nil && !item.keyframe {
+				continue
+			}
+			packets = make([]*Packet, 0, len(b.items))
+		}
+		packets = append(packets, item.packet)
+	}
+	return packets
+}
+
+// lastGOP - return copy of packets from the last keyframe, where each previous
+// frame timestamp is less than next one by step. The last frame timestamp is not changed.
+func (b *Buffer) lastGOP(step uint32) []*Packet {
+	i := -1
+	for j, item := range b.items {
+		if item.keyframe {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil
+	}
+
+	items := b.items[i:]
+	packets := make([]*Packet, len(items))
+
+	n := len(items) - 1
+	ts := items[n].packet.Timestamp
+
+	for j := n; j >= 0; j-- {
+		packet := *items[j].packet
+		// all packets of one frame have same timestamp
+		if j < n && packet.Timestamp != items[j+1].packet.Timestamp {
+			ts -= step
+		}
+		packet.Timestamp = ts
+		packets[j] = &packet
+	}
+
+	return packets
+}
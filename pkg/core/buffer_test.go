@@ -0,0 +1,164 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	buf := &Buffer{
+		Duration: 3 * time.Second,
+		IsKeyframe: func(packet *Packet) bool {
+			return packet.Payload[0] == 'I'
+		},
+	}
+
+	now := time.Now()
+
+	// GOP with 2 seconds length, one frame per second
+	for i, frame := range "PIPIPIPIP" {
+		packet := &Packet{Payload: []byte{byte(frame)}}
+		packet.Timestamp = uint32(i)
+		buf.push(packet, now.Add(time.Duration(i)*time.Second))
+	}
+
+	// first P-frame skipped, first GOP trimmed, last keyframe before deadline kept
+	require.Equal(t, 6, buf.Len())
+
+	keytime, ok := buf.KeyframeTime(now.Add(6 * time.Second))
+	require.True(t, ok)
+	require.Equal(t, now.Add(5*time.Second), keytime)
+
+	// all keyframes newer, so return first one
+	keytime, _ = buf.KeyframeTime(now)
+	require.Equal(t, now.Add(3*time.Second), keytime)
+
+	packets := buf.packets(now.Add(4 * time.Second))
+	require.Len(t, packets, 4)
+	require.Equal(t, uint32(5), packets[0].Timestamp)
+
+	// size limit trims whole GOPs, but keeps the last one
+	buf.Size = 1
+	buf.push(&Packet{Payload: []byte{'P'}}, now.Add(9*time.Second))
+	buf.trim(now)
+	require.Equal(t, 3, buf.Len())
+}
+
+func TestReplay(t *testing.T) {
+	recv := NewReceiver(nil, &Codec{})
+	recv.SetBuffer(&Buffer{Duration: time.Minute})
+
+	recv.Input(&Packet{Payload: []byte{1}})
+	recv.Input(&Packet{Payload: []byte{2}})
+
+	var payloads []byte
+
+	sender := NewSender(nil, &Codec{})
+	sender.Output = func(packet *Packet) {
+		payloads = append(payloads, packet.Payload...)
+	}
+
+	err := recv.Replay(time.Time{}, func() error {
+		sender.WithParent(recv)
+		return nil
+	})
+	require.Nil(t, err)
+
+	recv.Input(&Packet{Payload: []byte{3}})
+
+	sender.Start()
+	sender.Close()
+	sender.Wait()
+
+	require.Equal(t, []byte{1, 2, 3}, payloads)
+}
+
+func TestReplaySlowSender(t *testing.T) {
+	recv := NewReceiver(nil, &Codec{})
+	recv.SetBuffer(&Buffer{Duration: time.Minute})
+
+	// more packets than sender queue size
+	for i := 0; i < 200; i++ {
+		recv.Input(&Packet{Payload: []byte{byte(i)}})
+	}
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	var payloads []byte
+
+	sender := NewSender(nil, &Codec{})
+	sender.Output = func(packet *Packet) {
+		if len(payloads) == 0 {
+			close(started)
+			<-unblock
+		}
+		payloads = append(payloads, packet.Payload...)
+	}
+
+	replayed := make(chan error)
+	go func() {
+		replayed <- recv.Replay(time.Time{}, func() error {
+			sender.HandleRTP(recv)
+			return nil
+		})
+	}()
+
+	<-started
+
+	// live packets for other childs aren't blocked by the slow sender
+	sent := make(chan struct{})
+	go func() {
+		recv.Input(&Packet{Payload: []byte{200}})
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		require.FailNow(t, "receiver input is blocked")
+	}
+
+	close(unblock)
+	require.Nil(t, <-replayed)
+
+	sender.Close()
+	sender.Wait()
+
+	// live packet is sent after buffered
+	require.Len(t, payloads, 201)
+	for i, b := range payloads {
+		require.Equal(t, byte(i), b)
+	}
+}
+
+func TestBufferLastGOP(t *testing.T) {
+	buf := &Buffer{
+		IsKeyframe: func(packet *Packet) bool {
+			return packet.Payload[0] == 'I'
+		},
+	}
+
+	now := time.Now()
+
+	// I-frame in two packets with same timestamp
+	for i, frame := range "IIPPIIPP" {
+		packet := &Packet{Payload: []byte{byte(frame)}}
+		packet.Timestamp = []uint32{0, 0, 3000, 6000, 9000, 9000, 12000, 15000}[i]
+		buf.push(packet, now)
+	}
+
+	packets := buf.lastGOP(90)
+	require.Len(t, packets, 4)
+
+	var timestamps []uint32
+	for _, packet := range packets {
+		timestamps = append(timestamps, packet.Timestamp)
+	}
+	require.Equal(t, []uint32{14820, 14820, 14910, 15000}, timestamps)
+
+	// buffered packets are not changed
+	require.Equal(t, uint32(9000), buf.items[0].packet.Timestamp)
+}
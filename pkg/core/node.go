@@ -1,6 +1,7 @@
 package core
 
 import (
+	"slices"
 	"sync"
 
 	"github.com/pion/rtp"
@@ -30,6 +31,7 @@ type Node struct {
 	id     uint32
 	childs []*Node
 	parent *Node
+	sender *Sender // owner of the Node, if Node is a part of Sender
 
 	mu sync.Mutex
 }
@@ -39,9 +41,11 @@ func (n *Node) WithParent(parent *Node) *Node {
 	return n
 }
 
+// AppendChild and RemoveChild always create new slice (copy on write),
+// so Input can iterate over childs snapshot without lock
 func (n *Node) AppendChild(child *Node) {
 	n.mu.Lock()
-	n.childs = append(n.childs, child)
+	n.childs = append(slices.Clip(n.childs), child)
 	n.mu.Unlock()
 
 	child.parent = n
@@ -49,20 +53,24 @@ func (n *Node) AppendChild(child *Node) {
 
 func (n *Node) RemoveChild(child *Node) {
 	n.mu.Lock()
-	for i, ch := range n.childs {
-		if ch == child {
-			n.childs = append(n.childs[:i], n.childs[i+1:]...)
-			break
-		}
+	if i := slices.Index(n.childs, child); i >= 0 {
+		n.childs = slices.Delete(slices.Clone(n.childs), i, i+1)
 	}
 	n.mu.Unlock()
 }
 
+// getChilds - current childs, slice should not be changed
+func (n *Node) getChilds() []*Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.childs
+}
+
 func (n *Node) Close() {
 	if parent := n.parent; parent != nil {
 		parent.RemoveChild(n)
 
-		if len(parent.childs) == 0 {
+		if len(parent.getChilds()) == 0 {
 			parent.Close()
 		}
 	} else {
@@ -0,0 +1,21 @@
+package core
+
+const (
+	PTZContinuous = "continuous" // velocity -1..1, until stop
+	PTZRelative   = "relative"   // translation from current position
+	PTZAbsolute   = "absolute"   // position in camera coordinates
+)
+
+// PTZ - producer or source with pan, tilt and zoom control
+type PTZ interface {
+	PTZMove(mode string, pan, tilt, zoom float64) error
+	PTZStop() error
+	PTZGotoPreset(token string) error
+	PTZGetPresets() ([]PTZPreset, error)
+	PTZSetPreset(name string) (string, error)
+}
+
+type PTZPreset struct {
+	Token string `json:"token"`
+	Name  string `json:"name,omitempty"`
+}
@@ -3,6 +3,9 @@ package core
 import (
 	"encoding/json"
 	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/pion/rtp"
 )
@@ -17,8 +20,10 @@ type Receiver struct {
 	// Deprecated: should be removed
 	ID byte `json:"-"` // Channel for RTSP, PayloadType for MPEG-TS
 
-	Bytes   int `json:"bytes,omitempty"`
-	Packets int `json:"packets,omitempty"`
+	Bytes int `json:"bytes,omitempty"`
+
+	packets atomic.Int64 // read from other goroutines (watchdog)
+	buffer  atomic.Pointer[Buffer]
 }
 
 func NewReceiver(media *Media, codec *Codec) *Receiver {
@@ -28,14 +33,132 @@ func NewReceiver(media *Media, codec *Codec) *Receiver {
 	}
 	r.Input = func(packet *Packet) {
 		r.Bytes += len(packet.Payload)
-		r.Packets++
-		for _, child := range r.childs {
+		r.packets.Add(1)
+
+		if buf := r.buffer.Load(); buf != nil {
+			// lock buffer so new childs can't get live packets before buffered
+			buf.mu.Lock()
+			buf.push(packet, time.Now())
+			for _, child := range r.getChilds() {
+				child.Input(packet)
+			}
+			buf.mu.Unlock()
+			return
+		}
+
+		for _, child := range r.getChilds() {
 			child.Input(packet)
 		}
 	}
 	return r
 }
 
+// replayTimeout - max time for sending buffered packets to new childs.
+// New childs get live packets only after buffered.
+const replayTimeout = 2 * time.Second
+
+// replayInterval - check interval for free space in the sender queue while replaying
+const replayInterval = 10 * time.Millisecond
+
+// Packets - number of received packets
+func (r *Receiver) Packets() int {
+	return int(r.packets.Load())
+}
+
+func (r *Receiver) Buffer() *Buffer {
+	return r.buffer.Load()
+}
+
+func (r *Receiver) SetBuffer(buf *Buffer) {
+	r.buffer.Store(buf)
+}
+
+// Replay - call attach function, that adds new childs to the Receiver,
+// and send buffered packets received after since time to these childs.
+// Live packets are sent only after buffered, so the order is preserved.
+func (r *Receiver) Replay(since time.Time, attach func() error) error {
+	return r.replay(attach, func(buf *Buffer) []*Packet {
+		return buf.packets(since)
+	})
+}
+
+// ReplayGOP - same as Replay, but sends only the last GOP with timestamps moved
+// close to the last frame. So new consumer starts from the keyframe without delay.
+// Buffers without keyframes (audio) are not replayed.
+func (r *Receiver) ReplayGOP(attach func() error) error {
+	step := r.Codec.ClockRate / 1000 // one millisecond
+	if step == 0 {
+		step = 1
+	}
+
+	return r.replay(attach, func(buf *Buffer) []*Packet {
+		if buf.IsKeyframe == nil {
+			return nil
+		}
+		return buf.lastGOP(step)
+	})
+}
+
+func (r *Receiver) replay(attach func() error, packets func(buf *Buffer) []*Packet) error {
+	buf := r.buffer.Load()
+	if buf == nil {
+		return attach()
+	}
+
+	// lock buffer only while adding new childs, so live packets for other childs aren't delayed
+	buf.mu.Lock()
+
+	r.mu.Lock()
+	childs := slices.Clone(r.childs)
+	r.mu.Unlock()
+
+	if err := attach(); err != nil {
+		buf.mu.Unlock()
+		return err
+	}
+
+	var news []*Node
+
+	r.mu.Lock()
+	for _, child := range r.childs {
+		if !slices.Contains(childs, child) {
+			news = append(news, child)
+		}
+	}
+	r.mu.Unlock()
+
+	var senders []*Sender
+
+	if news != nil {
+		replay := packets(buf)
+		for _, child := range news {
+			if child.sender != nil {
+				// sender holds live packets until buffered packets are sent
+				child.sender.hold(replay)
+				senders = append(senders, child.sender)
+			} else {
+				for _, packet := range replay {
+					child.Input(packet)
+				}
+			}
+		}
+	}
+
+	buf.mu.Unlock()
+
+	if senders == nil {
+		return nil
+	}
+
+	timeout := time.After(replayTimeout)
+
+	for _, sender := range senders {
+		sender.flush(timeout)
+	}
+
+	return nil
+}
+
 // Deprecated: should be removed
 func (r *Receiver) WriteRTP(packet *rtp.Packet) {
 	r.Input(packet)
@@ -43,7 +166,7 @@ func (r *Receiver) WriteRTP(packet *rtp.Packet) {
 
 // Deprecated: should be removed
 func (r *Receiver) Senders() []*Sender {
-	if len(r.childs) > 0 {
+	if len(r.getChilds()) > 0 {
 		return []*Sender{{}}
 	} else {
 		return nil
@@ -53,6 +176,11 @@ func (r *Receiver) Senders() []*Sender {
 // Deprecated: should be removed
 func (r *Receiver) Replace(target *Receiver) {
 	MoveNode(&target.Node, &r.Node)
+
+	// new receiver has new timestamps, so only buffer settings are moved
+	if buf := r.buffer.Load(); buf != nil {
+		target.SetBuffer(buf.NewBuffer())
+	}
 }
 
 func (r *Receiver) Close() {
@@ -73,6 +201,9 @@ type Sender struct {
 
 	buf  chan *Packet
 	done chan struct{}
+
+	held    []*Packet // buffered and live packets waiting for free space in the queue
+	holding bool
 }
 
 func NewSender(media *Media, codec *Codec) *Sender {
@@ -96,8 +227,14 @@ func NewSender(media *Media, codec *Codec) *Sender {
 		Media: media,
 		buf:   buf,
 	}
+	s.Node.sender = s
 	s.Input = func(packet *Packet) {
 		s.mu.Lock()
+		if s.holding {
+			s.held = append(s.held, packet)
+			s.mu.Unlock()
+			return
+		}
 		// unblock write to nil chan - OK, write to closed chan - panic
 		select {
 		case s.buf <- packet:
@@ -114,6 +251,57 @@ func NewSender(media *Media, codec *Codec) *Sender {
 	return s
 }
 
+// hold - queue packets and following live packets until flush
+func (s *Sender) hold(packets []*Packet) {
+	s.mu.Lock()
+	s.held = append(make([]*Packet, 0, len(packets)), packets...)
+	s.holding = true
+	s.mu.Unlock()
+}
+
+// flush - send held packets to the queue, waits for free space in the queue if Sender is started.
+// Held packets are dropped after timeout. Live packets are sent directly after flush.
+func (s *Sender) flush(timeout <-chan time.Time) {
+	for {
+		s.mu.Lock()
+
+		for len(s.held) > 0 && s.buf != nil {
+			select {
+			case s.buf <- s.held[0]:
+				s.Bytes += len(s.held[0].Payload)
+				s.Packets++
+				s.held = s.held[1:]
+				continue
+			default:
+			}
+			break
+		}
+
+		// all packets are sent or sender is closed or not started (nobody reads the queue)
+		if len(s.held) == 0 || s.buf == nil || s.done == nil {
+			s.Drops += len(s.held)
+			s.held = nil
+			s.holding = false
+			s.mu.Unlock()
+			return
+		}
+
+		s.mu.Unlock()
+
+		// don't lock sender while waiting, so Receiver can add live packets to the held
+		select {
+		case <-timeout:
+			s.mu.Lock()
+			s.Drops += len(s.held)
+			s.held = nil
+			s.holding = false
+			s.mu.Unlock()
+			return
+		case <-time.After(replayInterval):
+		}
+	}
+}
+
 // Deprecated: should be removed
 func (s *Sender) HandleRTP(parent *Receiver) {
 	s.WithParent(parent)
@@ -187,9 +375,9 @@ func (r *Receiver) MarshalJSON() ([]byte, error) {
 		ID:      r.Node.id,
 		Codec:   r.Node.Codec,
 		Bytes:   r.Bytes,
-		Packets: r.Packets,
+		Packets: r.Packets(),
 	}
-	for _, child := range r.childs {
+	for _, child := range r.getChilds() {
 		v.Childs = append(v.Childs, child.id)
 	}
 	return json.Marshal(v)
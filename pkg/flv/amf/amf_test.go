@@ -216,6 +216,17 @@ func TestNewReader(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "obs-enhanced-connect",
+			actual: "020007636f6e6e656374003ff00000000000000300036170700200046c697665000a666f757243634c6973740a00000003020004617630310200047670303902000468766331000009",
+			expect: []any{
+				"connect", float64(1),
+				map[string]any{
+					"app":        "live",
+					"fourCcList": []any{"av01", "vp09", "hvc1"},
+				},
+			},
+		},
 		{
 			name:   "obs-key",
 			actual: "02000d72656c6561736553747265616d004000000000000000050200046b657931",
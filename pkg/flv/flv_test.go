@@ -1,8 +1,14 @@
 package flv
 
 import (
+	"bytes"
+	"encoding/base64"
 	"testing"
 
+	"github.com/AlexxIT/go2rtc/pkg/av1"
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/h265"
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,3 +25,50 @@ func TestTimeToRTP(t *testing.T) {
 		frameN *= 2
 	}
 }
+
+func TestEnhancedRTMP(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0c, 0x01}
+	sps, _ := base64.StdEncoding.DecodeString("QgEBAWAAAAMAAAMAAAMAAAMAmaAAoAgBaH+KrTuiS7/8AAQABbAgApMuADN/mAE=")
+	pps := []byte{0x44, 0x01, 0xc0, 0x73}
+
+	h265Codec := h265.ConfigToCodec(h265.EncodeConfig(vps, sps, pps))
+	opusCodec := &core.Codec{Name: core.CodecOpus, ClockRate: 48000, Channels: 1}
+
+	muxer := &Muxer{}
+	videoPay := muxer.GetPayloader(h265Codec)
+	audioPay := muxer.GetPayloader(opusCodec)
+
+	b := muxer.GetInit()
+	b = append(b, videoPay(&rtp.Packet{Payload: []byte{0, 0, 0, 2, 0x26, 0x01}})...) // IDR
+	b = append(b, audioPay(&rtp.Packet{Payload: []byte{0xfc, 1, 2}})...)
+
+	prod, err := Open(bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Len(t, prod.Medias, 2)
+	require.Equal(t, h265Codec.FmtpLine, prod.Medias[0].Codecs[0].FmtpLine)
+	require.Equal(t, core.CodecOpus, prod.Medias[1].Codecs[0].Name)
+	require.Equal(t, uint8(1), prod.Medias[1].Codecs[0].Channels)
+}
+
+func TestEnhancedAV1(t *testing.T) {
+	// sequence header OBU with size field: profile 0, level 8 (4.0), tier 0
+	seqHeader := []byte{0x0a, 0x04, 0, 0, 0, 0b0100_0000}
+	frame := append([]byte{0x12, 0x00}, seqHeader...) // temporal delimiter + sequence header
+	frame = append(frame, 0x32, 0x01, 0xff)           // frame OBU
+
+	muxer := &Muxer{}
+	pay := muxer.GetPayloader(&core.Codec{Name: core.CodecAV1, ClockRate: 90000})
+
+	require.Nil(t, pay(&rtp.Packet{Payload: []byte{0x12, 0x00, 0x32, 0x01, 0xff}})) // wait keyframe
+
+	b := muxer.GetInit()
+	b = append(b, pay(&rtp.Packet{Payload: frame})...)
+
+	prod, err := Open(bytes.NewReader(b))
+	require.Nil(t, err)
+	require.Len(t, prod.Medias, 1)
+	require.Equal(t, core.CodecAV1, prod.Medias[0].Codecs[0].Name)
+
+	config := av1.EncodeConfig(seqHeader)
+	require.Equal(t, []byte{0x81, 0x08, 0x0c, 0x00}, config[:4])
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 
+	"github.com/AlexxIT/go2rtc/pkg/av1"
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/flv/amf"
 	"github.com/AlexxIT/go2rtc/pkg/h264"
+	"github.com/AlexxIT/go2rtc/pkg/h265"
+	"github.com/AlexxIT/go2rtc/pkg/opus"
+	"github.com/AlexxIT/go2rtc/pkg/vp9"
 	"github.com/pion/rtp"
 )
 
@@ -36,12 +40,22 @@ func (m *Muxer) GetInit() []byte {
 			b[4] |= FlagsVideo
 			obj["videocodecid"] = CodecH264
 
+		case core.CodecH265, core.CodecAV1, core.CodecVP9:
+			b[4] |= FlagsVideo
+			obj["videocodecid"] = fourCCNumber(fourCC(codec))
+
 		case core.CodecAAC:
 			b[4] |= FlagsAudio
 			obj["audiocodecid"] = CodecAAC
 			obj["audiosamplerate"] = codec.ClockRate
 			obj["audiosamplesize"] = 16
 			obj["stereo"] = codec.Channels == 2
+
+		case core.CodecOpus:
+			b[4] |= FlagsAudio
+			obj["audiocodecid"] = fourCCNumber(FourCCOpus)
+			obj["audiosamplerate"] = codec.ClockRate
+			obj["stereo"] = codec.Channels == 2
 		}
 	}
 
@@ -65,11 +79,26 @@ func (m *Muxer) GetInit() []byte {
 			video := append(encodeAVData(codec, 0), config...)
 			b = append(b, EncodeTag(TagVideo, 0, video)...)
 
+		case core.CodecH265:
+			vps, sps, pps := h265.GetParameterSet(codec.FmtpLine)
+			if len(vps) == 0 || len(sps) == 0 || len(pps) == 0 {
+				continue // will be sent before first keyframe
+			}
+
+			config := h265.EncodeConfig(vps, sps, pps)
+			video := append(encodeExVideo(PacketTypeSequenceStart, true, FourCCHEVC), config...)
+			b = append(b, EncodeTag(TagVideo, 0, video)...)
+
 		case core.CodecAAC:
 			s := core.Between(codec.FmtpLine, "config=", ";")
 			config, _ := hex.DecodeString(s)
 			audio := append(encodeAVData(codec, 0), config...)
 			b = append(b, EncodeTag(TagAudio, 0, audio)...)
+
+		case core.CodecOpus:
+			config := opus.EncodeHead(max(codec.Channels, 1), codec.ClockRate)
+			audio := append(encodeExAudio(PacketTypeSequenceStart, FourCCOpus), config...)
+			b = append(b, EncodeTag(TagAudio, 0, audio)...)
 		}
 	}
 
@@ -103,6 +132,49 @@ func (m *Muxer) GetPayloader(codec *core.Codec) func(packet *rtp.Packet) []byte
 			return EncodeTag(TagVideo, timeMS, buf)
 		}
 
+	case core.CodecH265, core.CodecAV1, core.CodecVP9:
+		// config inside FmtpLine (H265) or inside keyframe (AV1, VP9)
+		var config []byte
+		if codec.Name == core.CodecH265 {
+			if vps, sps, pps := h265.GetParameterSet(codec.FmtpLine); len(vps) > 0 && len(sps) > 0 && len(pps) > 0 {
+				config = h265.EncodeConfig(vps, sps, pps)
+			}
+		}
+
+		var buf []byte
+
+		return func(packet *rtp.Packet) []byte {
+			if ts0 == 0 {
+				ts0 = packet.Timestamp
+			}
+
+			timeMS := (packet.Timestamp - ts0) / k
+
+			keyframe := isKeyframe(codec.Name, packet.Payload)
+
+			var b []byte
+			if config == nil {
+				if !keyframe {
+					return nil // wait keyframe with config
+				}
+				if config = encodeConfig(codec.Name, packet.Payload); config == nil {
+					return nil
+				}
+				video := append(encodeExVideo(PacketTypeSequenceStart, true, fourCC(codec)), config...)
+				b = EncodeTag(TagVideo, timeMS, video)
+			}
+
+			// CodedFramesX for H265 is CodedFrames with zero composition time,
+			// AV1 and VP9 don't have composition time at all
+			packetType := byte(PacketTypeCodedFrames)
+			if codec.Name == core.CodecH265 {
+				packetType = PacketTypeCodedFramesX
+			}
+
+			buf = append(encodeExVideo(packetType, keyframe, fourCC(codec)), packet.Payload...)
+			return append(b, EncodeTag(TagVideo, timeMS, buf)...)
+		}
+
 	case core.CodecAAC:
 		buf := encodeAVData(codec, 1)
 
@@ -113,6 +185,20 @@ func (m *Muxer) GetPayloader(codec *core.Codec) func(packet *rtp.Packet) []byte
 				ts0 = packet.Timestamp
 			}
 
+			timeMS := (packet.Timestamp - ts0) / k
+			return EncodeTag(TagAudio, timeMS, buf)
+		}
+
+	case core.CodecOpus:
+		buf := encodeExAudio(PacketTypeCodedFrames, FourCCOpus)
+
+		return func(packet *rtp.Packet) []byte {
+			buf = append(buf[:5], packet.Payload...)
+
+			if ts0 == 0 {
+				ts0 = packet.Timestamp
+			}
+
 			timeMS := (packet.Timestamp - ts0) / k
 			return EncodeTag(TagAudio, timeMS, buf)
 		}
@@ -172,3 +258,68 @@ func encodeAVData(codec *core.Codec, isFrame byte) []byte {
 
 	return nil
 }
+
+// encodeExVideo - Enhanced RTMP video tag header
+func encodeExVideo(packetType byte, keyframe bool, fourCC string) []byte {
+	b := []byte{0b1000_0000 | 2<<4 | packetType, 0, 0, 0, 0} // inter frame
+	if keyframe {
+		b[0] = 0b1000_0000 | 1<<4 | packetType
+	}
+	copy(b[1:], fourCC)
+	return b
+}
+
+// encodeExAudio - Enhanced RTMP audio tag header
+func encodeExAudio(packetType byte, fourCC string) []byte {
+	b := []byte{CodecExHeader<<4 | packetType, 0, 0, 0, 0}
+	copy(b[1:], fourCC)
+	return b
+}
+
+func fourCC(codec *core.Codec) string {
+	switch codec.Name {
+	case core.CodecH265:
+		return FourCCHEVC
+	case core.CodecAV1:
+		return FourCCAV1
+	case core.CodecVP9:
+		return FourCCVP9
+	}
+	return ""
+}
+
+// fourCCNumber - Enhanced RTMP uses FourCC as codec ID in metadata
+func fourCCNumber(fourCC string) uint32 {
+	return binary.BigEndian.Uint32([]byte(fourCC))
+}
+
+func isKeyframe(name string, frame []byte) bool {
+	switch name {
+	case core.CodecH265:
+		return h265.IsKeyframe(frame)
+	case core.CodecAV1:
+		return av1.IsKeyframe(frame)
+	case core.CodecVP9:
+		return vp9.IsKeyframe(frame)
+	}
+	return false
+}
+
+// encodeConfig - decoder config from keyframe
+func encodeConfig(name string, keyframe []byte) []byte {
+	switch name {
+	case core.CodecH265:
+		if codec := h265.AVCCToCodec(keyframe); codec != nil {
+			if vps, sps, pps := h265.GetParameterSet(codec.FmtpLine); len(vps) > 0 && len(sps) > 0 && len(pps) > 0 {
+				return h265.EncodeConfig(vps, sps, pps)
+			}
+		}
+	case core.CodecAV1:
+		if obu := av1.SequenceHeader(keyframe); obu != nil {
+			return av1.EncodeConfig(obu)
+		}
+	case core.CodecVP9:
+		return vp9.EncodeConfig(keyframe)
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/h264"
 	"github.com/AlexxIT/go2rtc/pkg/h265"
+	"github.com/AlexxIT/go2rtc/pkg/opus"
 	"github.com/pion/rtp"
 )
 
@@ -43,12 +44,22 @@ const (
 	TagVideo = 9
 	TagData  = 18
 
-	CodecAAC = 10
+	CodecAAC      = 10
+	CodecExHeader = 9 // Enhanced RTMP audio
 
 	CodecH264 = 7
 	CodecHEVC = 12
 )
 
+// Enhanced RTMP FourCC, https://github.com/veovera/enhanced-rtmp
+const (
+	FourCCAVC  = "avc1"
+	FourCCHEVC = "hvc1"
+	FourCCAV1  = "av01"
+	FourCCVP9  = "vp09"
+	FourCCOpus = "Opus"
+)
+
 const (
 	PacketTypeAVCHeader = iota
 	PacketTypeAVCNALU
@@ -85,12 +96,24 @@ func (c *Producer) Start() error {
 
 		switch pkt.PayloadType {
 		case TagAudio:
-			if c.audio == nil || pkt.Payload[1] == 0 {
+			if c.audio == nil {
 				continue
 			}
 
+			if pkt.Payload[0]>>4 == CodecExHeader {
+				// sound format 4b, packet type 4b, fourCC 32b
+				if pkt.Payload[0]&0b1111 != PacketTypeCodedFrames {
+					continue
+				}
+				pkt.Payload = pkt.Payload[5:]
+			} else {
+				if pkt.Payload[1] == 0 {
+					continue
+				}
+				pkt.Payload = pkt.Payload[2:]
+			}
+
 			pkt.Timestamp = TimeToRTP(pkt.Timestamp, c.audio.Codec.ClockRate)
-			pkt.Payload = pkt.Payload[2:]
 			c.audio.WriteRTP(pkt)
 
 		case TagVideo:
@@ -101,8 +124,14 @@ func (c *Producer) Start() error {
 			if isExHeader(pkt.Payload) {
 				switch packetType := pkt.Payload[0] & 0b1111; packetType {
 				case PacketTypeCodedFrames:
-					// frame type 4b, packet type 4b, fourCC 32b, composition time 24b
-					pkt.Payload = pkt.Payload[8:]
+					switch string(pkt.Payload[1:5]) {
+					case FourCCAVC, FourCCHEVC:
+						// frame type 4b, packet type 4b, fourCC 32b, composition time 24b
+						pkt.Payload = pkt.Payload[8:]
+					default:
+						// AV1 and VP9 don't have composition time
+						pkt.Payload = pkt.Payload[5:]
+					}
 				case PacketTypeCodedFramesX:
 					// frame type 4b, packet type 4b, fourCC 32b
 					pkt.Payload = pkt.Payload[5:]
@@ -172,15 +201,39 @@ func (c *Producer) probe() error {
 			_ = pkt.Payload[0] & 0b0010    // SoundSize
 			_ = pkt.Payload[0] & 0b0001    // SoundType
 
-			if codecID != CodecAAC {
-				continue
-			}
+			var codec *core.Codec
+
+			switch codecID {
+			case CodecAAC:
+				if pkt.Payload[1] != 0 { // check if header
+					continue
+				}
+
+				codec = aac.ConfigToCodec(pkt.Payload[2:])
+
+			case CodecExHeader:
+				if len(pkt.Payload) < 5 || string(pkt.Payload[1:5]) != FourCCOpus {
+					continue
+				}
+
+				// sequence start with OpusHead is optional
+				codec = &core.Codec{
+					Name:        core.CodecOpus,
+					ClockRate:   48000,
+					Channels:    2,
+					PayloadType: core.PayloadTypeRAW,
+				}
 
-			if pkt.Payload[1] != 0 { // check if header
+				if pkt.Payload[0]&0b1111 == PacketTypeSequenceStart {
+					if channels := opus.HeadChannels(pkt.Payload[5:]); channels != 0 {
+						codec.Channels = channels
+					}
+				}
+
+			default:
 				continue
 			}
 
-			codec := aac.ConfigToCodec(pkt.Payload[2:])
 			media := &core.Media{
 				Kind:      core.KindAudio,
 				Direction: core.DirectionRecvonly,
@@ -197,15 +250,22 @@ func (c *Producer) probe() error {
 			var codec *core.Codec
 
 			if isExHeader(pkt.Payload) {
-				if string(pkt.Payload[1:5]) != "hvc1" {
+				if packetType := pkt.Payload[0] & 0b1111; packetType != PacketTypeSequenceStart {
 					continue
 				}
 
-				if packetType := pkt.Payload[0] & 0b1111; packetType != PacketTypeSequenceStart {
+				switch string(pkt.Payload[1:5]) {
+				case FourCCAVC:
+					codec = h264.ConfigToCodec(pkt.Payload[5:])
+				case FourCCHEVC:
+					codec = h265.ConfigToCodec(pkt.Payload[5:])
+				case FourCCAV1:
+					codec = &core.Codec{Name: core.CodecAV1, ClockRate: 90000, PayloadType: core.PayloadTypeRAW}
+				case FourCCVP9:
+					codec = &core.Codec{Name: core.CodecVP9, ClockRate: 90000, PayloadType: core.PayloadTypeRAW}
+				default:
 					continue
 				}
-
-				codec = h265.ConfigToCodec(pkt.Payload[5:])
 			} else {
 				_ = pkt.Payload[0] >> 4 // FrameType
 
@@ -108,3 +108,11 @@ func TestAVCCToCodec(t *testing.T) {
 	codec := AVCCToCodec(b)
 	require.Equal(t, "packetization-mode=1;profile-level-id=64001f;sprop-parameter-sets=Z2QAH6wkhAFAFuwEQAAAAwBAAAAMI8YMkg==,aO4yyLA=", codec.FmtpLine)
 }
+
+func TestIsKeyframeRTP(t *testing.T) {
+	require.True(t, IsKeyframeRTP([]byte{0x67, 0x64}))             // SPS
+	require.True(t, IsKeyframeRTP([]byte{0x78, 0x00, 0x10, 0x67})) // STAP-A with SPS
+	require.True(t, IsKeyframeRTP([]byte{0x7C, 0x85}))             // FU-A start of IDR
+	require.False(t, IsKeyframeRTP([]byte{0x7C, 0x05}))            // FU-A middle of IDR
+	require.False(t, IsKeyframeRTP([]byte{0x41, 0x9A}))            // P-frame
+}
@@ -13,6 +13,27 @@ const RTPPacketVersionAVC = 0
 
 const PSMaxSize = 128 // the biggest SPS I've seen is 48 (EZVIZ CS-CV210)
 
+// IsKeyframeRTP - check if RTP packet starts a keyframe (SPS or IDR)
+func IsKeyframeRTP(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+
+	switch payload[0] & 0x1F {
+	case NALUTypeSPS, NALUTypeIFrame:
+		return true
+	case 24: // STAP-A
+		if len(payload) > 3 {
+			nalu := payload[3] & 0x1F
+			return nalu == NALUTypeSPS || nalu == NALUTypeIFrame
+		}
+	case 28: // FU-A
+		return payload[1]&0x80 != 0 && payload[1]&0x1F == NALUTypeIFrame
+	}
+
+	return false
+}
+
 func RTPDepay(codec *core.Codec, handler core.HandlerFunc) core.HandlerFunc {
 	depack := &codecs.H264Packet{IsAVC: true}
 
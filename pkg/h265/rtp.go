@@ -8,6 +8,28 @@ import (
 	"github.com/pion/rtp"
 )
 
+// IsKeyframeRTP - check if RTP packet starts a keyframe (VPS or IRAP)
+func IsKeyframeRTP(payload []byte) bool {
+	if len(payload) < 3 {
+		return false
+	}
+
+	switch nuType := (payload[0] >> 1) & 0x3F; nuType {
+	case NALUTypeVPS, NALUTypeIFrame, NALUTypeIFrame2, NALUTypeIFrame3:
+		return true
+	case 48: // AP
+		if len(payload) > 4 {
+			nuType = (payload[4] >> 1) & 0x3F
+			return nuType == NALUTypeVPS || nuType >= NALUTypeIFrame && nuType <= NALUTypeIFrame3
+		}
+	case NALUTypeFU:
+		nuType = payload[2] & 0x3F
+		return payload[2]&0x80 != 0 && nuType >= NALUTypeIFrame && nuType <= NALUTypeIFrame3
+	}
+
+	return false
+}
+
 func RTPDepay(codec *core.Codec, handler core.HandlerFunc) core.HandlerFunc {
 	vps, sps, pps := GetParameterSet(codec.FmtpLine)
 	ps := h264.JoinNALU(vps, sps, pps)
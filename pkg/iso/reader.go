@@ -141,6 +141,9 @@ func DecodeAtom(b []byte) (any, error) {
 		return atom, nil
 
 	case MoofTrafTfdt:
+		if data[0] == 0 {
+			return &AtomTfdt{DecodeTime: uint64(binary.BigEndian.Uint32(data[4:]))}, nil
+		}
 		return &AtomTfdt{DecodeTime: binary.BigEndian.Uint64(data[4:])}, nil
 
 	case MoofTrafTrun:
@@ -0,0 +1,169 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/iso"
+)
+
+// Segmenter - split fMP4 stream from Consumer.WriteTo into init and fragments.
+// Consumer can merge several atoms into one Write, so Segmenter buffers data
+// until the atom is completely received.
+type Segmenter struct {
+	// OnInit - called with ftyp+moov atoms
+	OnInit func(init []byte)
+	// OnFragment - called with moof+mdat atoms, keyframe is true for video
+	// keyframe or for any fragment if the stream has no video.
+	// Returned error will be returned from Write.
+	OnFragment func(fragment []byte, keyframe bool) error
+
+	buf     []byte
+	init    []byte
+	moof    []byte
+	videoID uint32
+
+	// main track - video or first track if the stream has no video
+	mainID    uint32
+	timeScale uint32
+}
+
+func (s *Segmenter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+
+	for len(s.buf) >= 8 {
+		size := int(binary.BigEndian.Uint32(s.buf))
+		if size < 8 {
+			// broken stream, drop buffer
+			s.buf = nil
+			break
+		}
+		if len(s.buf) < size {
+			break
+		}
+
+		atom := s.buf[:size:size]
+		s.buf = s.buf[size:]
+
+		switch string(atom[4:8]) {
+		case iso.Ftyp:
+			s.init = atom
+		case iso.Moov:
+			s.init = append(s.init, atom...)
+			s.videoID, s.mainID, s.timeScale = parseTracks(atom)
+			if s.OnInit != nil {
+				s.OnInit(s.init)
+			}
+		case iso.Moof:
+			s.moof = atom
+		case iso.Mdat:
+			if s.moof == nil {
+				continue
+			}
+			fragment := append(s.moof, atom...)
+			keyframe := s.isKeyframe(s.moof)
+			s.moof = nil
+			if s.OnFragment != nil {
+				if err := s.OnFragment(fragment, keyframe); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Init - return last received init (ftyp+moov)
+func (s *Segmenter) Init() []byte {
+	return s.init
+}
+
+func (s *Segmenter) isKeyframe(moof []byte) bool {
+	if s.videoID == 0 {
+		return true
+	}
+
+	atoms, err := iso.DecodeAtoms(moof)
+	if err != nil {
+		return false
+	}
+
+	for _, atom := range atoms {
+		if tfhd, ok := atom.(*iso.AtomTfhd); ok {
+			return tfhd.TrackID == s.videoID && tfhd.SampleFlags == iso.SampleVideoIFrame
+		}
+	}
+
+	return false
+}
+
+// Time - return decode time and duration of the fragment (moof+mdat) for the main track.
+// ok is false for fragments of other tracks.
+func (s *Segmenter) Time(fragment []byte) (dts, duration time.Duration, ok bool) {
+	if s.timeScale == 0 {
+		return
+	}
+
+	size := binary.BigEndian.Uint32(fragment)
+	atoms, err := iso.DecodeAtoms(fragment[:size])
+	if err != nil {
+		return
+	}
+
+	var decodeTime, sampleDuration uint64
+
+	for _, atom := range atoms {
+		switch atom := atom.(type) {
+		case *iso.AtomTfhd:
+			if atom.TrackID != s.mainID {
+				return
+			}
+			sampleDuration = uint64(atom.SampleDuration)
+		case *iso.AtomTfdt:
+			decodeTime = atom.DecodeTime
+			ok = true
+		case *iso.AtomTrun:
+			if len(atom.SamplesDuration) > 0 {
+				sampleDuration = 0
+				for _, d := range atom.SamplesDuration {
+					sampleDuration += uint64(d)
+				}
+			}
+		}
+	}
+
+	timeScale := uint64(s.timeScale)
+	dts = time.Duration(decodeTime/timeScale*uint64(time.Second) + decodeTime%timeScale*uint64(time.Second)/timeScale)
+	duration = time.Duration(sampleDuration * uint64(time.Second) / timeScale)
+	return
+}
+
+// parseTracks - search first track with "vide" handler inside moov atom
+// and return its ID with timescale, or first track if there is no video
+func parseTracks(moov []byte) (videoID, mainID, timeScale uint32) {
+	atoms, err := iso.DecodeAtoms(moov)
+	if err != nil {
+		return
+	}
+
+	var trackID, trackScale uint32
+	for _, atom := range atoms {
+		switch atom := atom.(type) {
+		case *iso.AtomTkhd:
+			trackID = atom.TrackID
+		case *iso.AtomMdhd:
+			trackScale = atom.TimeScale
+			if mainID == 0 {
+				mainID, timeScale = trackID, trackScale
+			}
+		case *iso.Atom:
+			// hdlr: version (1), flags (3), pre_defined (4), handler_type (4)
+			if atom.Name == iso.MoovTrakMdiaHdlr && len(atom.Data) >= 12 && string(atom.Data[8:12]) == "vide" {
+				return trackID, trackID, trackScale
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,58 @@
+package mp4
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmenter(t *testing.T) {
+	muxer := &Muxer{}
+	muxer.AddTrack(&core.Codec{Name: core.CodecH264, ClockRate: 90000})
+	muxer.AddTrack(&core.Codec{Name: core.CodecAAC, ClockRate: 16000, Channels: 1, FmtpLine: "config=1408"})
+
+	init, err := muxer.GetInit()
+	require.Nil(t, err)
+
+	iframe := &rtp.Packet{Header: rtp.Header{Timestamp: 9000}, Payload: []byte{0, 0, 0, 2, 0x65, 0}}
+	pframe := &rtp.Packet{Header: rtp.Header{Timestamp: 18000}, Payload: []byte{0, 0, 0, 2, 0x41, 0}}
+	audio := &rtp.Packet{Payload: []byte{1, 2, 3}}
+
+	var stream []byte
+	stream = append(stream, init...)
+	stream = append(stream, muxer.GetPayload(0, iframe)...)
+	stream = append(stream, muxer.GetPayload(1, audio)...)
+	stream = append(stream, muxer.GetPayload(0, pframe)...)
+
+	var inits [][]byte
+	var keyframes []bool
+	var times []time.Duration
+
+	s := &Segmenter{}
+	s.OnInit = func(b []byte) {
+		inits = append(inits, b)
+	}
+	s.OnFragment = func(b []byte, keyframe bool) error {
+		keyframes = append(keyframes, keyframe)
+		if dts, duration, ok := s.Time(b); ok {
+			times = append(times, dts, duration)
+		}
+		return nil
+	}
+
+	// write by small chunks, because real consumer can split or merge atoms
+	for b := stream; len(b) > 0; {
+		n := min(7, len(b))
+		_, _ = s.Write(b[:n])
+		b = b[n:]
+	}
+
+	require.Len(t, inits, 1)
+	require.Equal(t, init, inits[0])
+	require.Equal(t, []bool{true, false, false}, keyframes)
+	// only video fragments, 100ms each
+	require.Equal(t, []time.Duration{0, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}, times)
+}
@@ -2,6 +2,7 @@ package mpegts
 
 import (
 	"io"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/aac"
 	"github.com/AlexxIT/go2rtc/pkg/core"
@@ -116,6 +117,32 @@ func (c *Consumer) WriteTo(wr io.Writer) (int64, error) {
 	return c.wr.WriteTo(wr)
 }
 
+// RepeatHeader - resend PAT/PMT from the first write with interval,
+// so receivers can join in the middle of the stream (UDP multicast)
+func RepeatHeader(wr io.Writer, interval time.Duration) io.Writer {
+	return &headerWriter{wr: wr, interval: interval}
+}
+
+type headerWriter struct {
+	wr       io.Writer
+	header   []byte
+	interval time.Duration
+	sent     time.Time
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if w.header == nil {
+		w.header = append([]byte(nil), b...)
+		w.sent = time.Now()
+	} else if time.Since(w.sent) >= w.interval {
+		if _, err := w.wr.Write(w.header); err != nil {
+			return 0, err
+		}
+		w.sent = time.Now()
+	}
+	return w.wr.Write(b)
+}
+
 //func TimestampFromRTP(rtp *rtp.Packet, codec *core.Codec) {
 //	if codec.ClockRate == ClockRate {
 //		return
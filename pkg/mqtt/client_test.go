@@ -0,0 +1,43 @@
+package mqtt
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRead(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+	client := NewClient(conn1)
+
+	puback := make(chan []byte)
+
+	go func() {
+		// broker sends message with QoS 1
+		_, _ = conn2.Write(NewPublishQOS1(5, "go2rtc/camera1/set", []byte("ON")).Bytes())
+
+		b := make([]byte, 4)
+		_, _ = io.ReadFull(conn2, b)
+		puback <- b
+	}()
+
+	topic, payload, err := client.Read()
+	require.Nil(t, err)
+	require.Equal(t, "go2rtc/camera1/set", topic)
+	require.Equal(t, []byte("ON"), payload)
+
+	// client should response with PUBACK and same packet ID
+	require.Equal(t, []byte{PUBACK, 2, 0, 5}, <-puback)
+}
+
+func TestConnectWill(t *testing.T) {
+	will := &Will{Topic: "go2rtc/status", Payload: []byte("offline")}
+	b := NewConnectWill("id", "user", "pass", will).Bytes()
+
+	// fixed header + remaining length
+	require.Equal(t, byte(CONNECT), b[0])
+	require.Equal(t, len(b)-2, int(b[1]))
+	require.Equal(t, byte(flagCleanStart|flagWill|flagWillQOS1|flagWillRetain|flagUsername|flagPassword), b[9])
+}
@@ -16,7 +16,10 @@ const (
 	PUBACK    = 0x40
 	SUBSCRIBE = 0x82
 	SUBACK    = 0x90
+	PINGREQ   = 0xC0
+	PINGRESP  = 0xD0
 	QOS1      = 0x02
+	RETAIN    = 0x01
 )
 
 func (m *Message) WriteByte(b byte) {
@@ -52,21 +55,41 @@ func (m *Message) Bytes() []byte {
 
 const (
 	flagCleanStart = 0x02
+	flagWill       = 0x04
+	flagWillQOS1   = 0x08
+	flagWillRetain = 0x20
 	flagUsername   = 0x80
 	flagPassword   = 0x40
 )
 
 func NewConnect(clientID, username, password string) *Message {
+	return NewConnectWill(clientID, username, password, nil)
+}
+
+// NewConnectWill - connect with retained last will message, will can be nil
+func NewConnectWill(clientID, username, password string, will *Will) *Message {
+	size := 16 + len(clientID) + len(username) + len(password)
+	flags := byte(flagCleanStart | flagUsername | flagPassword)
+	if will != nil {
+		size += 4 + len(will.Topic) + len(will.Payload)
+		flags |= flagWill | flagWillQOS1 | flagWillRetain
+	}
+
 	m := &Message{}
 	m.WriteByte(CONNECT)
-	m.WriteLen(16 + len(clientID) + len(username) + len(password))
+	m.WriteLen(size)
 
 	m.WriteString("MQTT")
 	m.WriteByte(4) // MQTT version
-	m.WriteByte(flagCleanStart | flagUsername | flagPassword)
+	m.WriteByte(flags)
 	m.WriteUint16(30) // keepalive
 
 	m.WriteString(clientID)
+	if will != nil {
+		m.WriteString(will.Topic)
+		m.WriteUint16(uint16(len(will.Payload)))
+		m.WriteBytes(will.Payload)
+	}
 	m.WriteString(username)
 	m.WriteString(password)
 	return m
@@ -94,8 +117,17 @@ func NewPublish(topic string, payload []byte) *Message {
 }
 
 func NewPublishQOS1(mid uint16, topic string, payload []byte) *Message {
+	return newPublishQOS1(PUBLISH|QOS1, mid, topic, payload)
+}
+
+// NewPublishRetain - publish with QoS 1 and retain flag
+func NewPublishRetain(mid uint16, topic string, payload []byte) *Message {
+	return newPublishQOS1(PUBLISH|QOS1|RETAIN, mid, topic, payload)
+}
+
+func newPublishQOS1(header byte, mid uint16, topic string, payload []byte) *Message {
 	m := &Message{}
-	m.WriteByte(PUBLISH | QOS1)
+	m.WriteByte(header)
 	m.WriteLen(4 + len(topic) + len(payload))
 
 	m.WriteString(topic)
@@ -20,6 +20,8 @@ type Client struct {
 	deviceURL string
 	mediaURL  string
 	imaginURL string
+	ptzURL    string
+	eventsURL string
 }
 
 func NewClient(rawURL string) (*Client, error) {
@@ -44,28 +46,21 @@ func NewClient(rawURL string) (*Client, error) {
 	s = FindTagValue(b, "Imaging.+?XAddr")
 	client.imaginURL = baseURL + GetPath(s, "/onvif/imaging_service")
 
+	// PTZ and Events services are optional, empty URL means unsupported
+	client.ptzURL = client.serviceURL(FindTagValue(b, "PTZ.+?XAddr"))
+	client.eventsURL = client.serviceURL(FindTagValue(b, "Events.+?XAddr"))
+
 	return client, nil
 }
 
 func (c *Client) GetURI() (string, error) {
-	query := c.url.Query()
-
-	token := query.Get("subtype")
-
-	// support empty
-	if i := atoi(token); i >= 0 {
-		tokens, err := c.GetProfilesTokens()
-		if err != nil {
-			return "", err
-		}
-		if i >= len(tokens) {
-			return "", errors.New("onvif: wrong subtype")
-		}
-		token = tokens[i]
+	token, err := c.GetProfileToken()
+	if err != nil {
+		return "", err
 	}
 
 	getUri := c.GetStreamUri
-	if query.Has("snapshot") {
+	if c.url.Query().Has("snapshot") {
 		getUri = c.GetSnapshotUri
 	}
 
@@ -89,6 +84,25 @@ func (c *Client) GetURI() (string, error) {
 	return u.String(), nil
 }
 
+// GetProfileToken - return profile token from subtype param (token or index)
+func (c *Client) GetProfileToken() (string, error) {
+	token := c.url.Query().Get("subtype")
+
+	// support empty
+	if i := atoi(token); i >= 0 {
+		tokens, err := c.GetProfilesTokens()
+		if err != nil {
+			return "", err
+		}
+		if i >= len(tokens) {
+			return "", errors.New("onvif: wrong subtype")
+		}
+		token = tokens[i]
+	}
+
+	return token, nil
+}
+
 func (c *Client) GetName() (string, error) {
 	b, err := c.DeviceRequest(DeviceGetDeviceInformation)
 	if err != nil {
@@ -157,6 +171,19 @@ func (c *Client) GetServiceCapabilities() ([]byte, error) {
 	)
 }
 
+// serviceURL - service address from the camera response with host from the client URL,
+// because camera may respond with wrong host (ex. behind NAT)
+func (c *Client) serviceURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(html.UnescapeString(rawURL)))
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	if u.Scheme != "https" {
+		u.Scheme = "http"
+	}
+	return u.Scheme + "://" + c.url.Host + u.RequestURI()
+}
+
 func (c *Client) DeviceRequest(operation string) ([]byte, error) {
 	switch operation {
 	case DeviceGetServices:
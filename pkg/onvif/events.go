@@ -0,0 +1,146 @@
+package onvif
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message - parsed notification from the camera, ex. motion or tamper
+type Message struct {
+	Topic     string            `json:"topic"`               // ex. RuleEngine/CellMotionDetector/Motion
+	Source    map[string]string `json:"source,omitempty"`    // ex. VideoSourceConfigurationToken: 1
+	Data      map[string]string `json:"data,omitempty"`      // ex. IsMotion: true
+	State     string            `json:"state,omitempty"`     // value of the first data item
+	Operation string            `json:"operation,omitempty"` // Initialized, Changed, Deleted
+	Time      time.Time         `json:"time"`
+}
+
+func (c *Client) HasEvents() bool {
+	return c.eventsURL != ""
+}
+
+// CreatePullPointSubscription - returns subscription address for other requests
+func (c *Client) CreatePullPointSubscription(termination time.Duration) (string, error) {
+	b, err := c.Request(c.eventsURL, `<tev:CreatePullPointSubscription>
+	<tev:InitialTerminationTime>`+duration(termination)+`</tev:InitialTerminationTime>
+</tev:CreatePullPointSubscription>`)
+	if err != nil {
+		return "", err
+	}
+
+	if address := c.serviceURL(FindTagValue(b, "Address")); address != "" {
+		return address, nil
+	}
+	return c.eventsURL, nil
+}
+
+// PullMessages - wait for messages up to timeout
+func (c *Client) PullMessages(address string, timeout time.Duration, limit int) ([]*Message, error) {
+	b, err := c.Request(address, `<tev:PullMessages>
+	<tev:Timeout>`+duration(timeout)+`</tev:Timeout>
+	<tev:MessageLimit>`+strconv.Itoa(limit)+`</tev:MessageLimit>
+</tev:PullMessages>`)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMessages(b), nil
+}
+
+func (c *Client) Renew(address string, termination time.Duration) error {
+	_, err := c.Request(address, `<wsnt:Renew>
+	<wsnt:TerminationTime>`+duration(termination)+`</wsnt:TerminationTime>
+</wsnt:Renew>`)
+	return err
+}
+
+func (c *Client) Unsubscribe(address string) error {
+	_, err := c.Request(address, `<wsnt:Unsubscribe/>`)
+	return err
+}
+
+func ParseMessages(b []byte) []*Message {
+	var messages []*Message
+
+	re := regexp.MustCompile(`(?s)<(?:\w+:)?NotificationMessage\b.*?</(?:\w+:)?NotificationMessage>`)
+	for _, m := range re.FindAll(b, -1) {
+		msg := &Message{Topic: parseTopic(FindTagValue(m, "Topic"))}
+
+		if attrs := findAttrs(m, "Message", "UtcTime"); attrs != nil {
+			msg.Time, _ = time.Parse(time.RFC3339Nano, attrs["UtcTime"])
+			msg.Operation = attrs["PropertyOperation"]
+		}
+
+		msg.Source, _ = parseItems(m, "Source")
+		msg.Data, msg.State = parseItems(m, "Data")
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+// parseTopic - remove namespaces: tns1:RuleEngine/tnsaxis:Motion => RuleEngine/Motion
+func parseTopic(s string) string {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	for i, part := range parts {
+		if j := strings.IndexByte(part, ':'); j >= 0 {
+			parts[i] = part[j+1:]
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// parseItems - SimpleItem names and values from the tag, and value of the first item
+func parseItems(b []byte, tag string) (map[string]string, string) {
+	re := regexp.MustCompile(`(?s)<(?:\w+:)?` + tag + `>(.*?)</(?:\w+:)?` + tag + `>`)
+	m := re.FindSubmatch(b)
+	if m == nil {
+		return nil, ""
+	}
+
+	var items map[string]string
+	var first string
+
+	re = regexp.MustCompile(`<(?:\w+:)?SimpleItem\b([^>]*)>`)
+	for _, item := range re.FindAllSubmatch(m[1], -1) {
+		attrs := parseAttrs(item[1])
+		name, ok := attrs["Name"]
+		if !ok {
+			continue
+		}
+		if items == nil {
+			items = map[string]string{}
+			first = attrs["Value"]
+		}
+		items[name] = attrs["Value"]
+	}
+
+	return items, first
+}
+
+// findAttrs - attributes of the first tag with required attribute
+func findAttrs(b []byte, tag, attr string) map[string]string {
+	re := regexp.MustCompile(`<(?:\w+:)?` + tag + `\b([^>]*\b` + attr + `="[^>]*)>`)
+	m := re.FindSubmatch(b)
+	if m == nil {
+		return nil
+	}
+	return parseAttrs(m[1])
+}
+
+func parseAttrs(b []byte) map[string]string {
+	attrs := map[string]string{}
+	re := regexp.MustCompile(`([\w:]+)="([^"]*)"`)
+	for _, m := range re.FindAllSubmatch(b, -1) {
+		attrs[string(m[1])] = html.UnescapeString(string(m[2]))
+	}
+	return attrs
+}
+
+// duration - xs:duration in seconds, ex. PT60S
+func duration(d time.Duration) string {
+	return "PT" + strconv.Itoa(int(d.Seconds())) + "S"
+}
@@ -0,0 +1,139 @@
+package onvif
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessages(t *testing.T) {
+	// Hikvision DS-2CD2xx
+	b := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tev="http://www.onvif.org/ver10/events/wsdl" xmlns:tns1="http://www.onvif.org/ver10/topics">
+<env:Body>
+<tev:PullMessagesResponse>
+<tev:CurrentTime>2024-05-01T10:00:05Z</tev:CurrentTime>
+<tev:TerminationTime>2024-05-01T10:01:05Z</tev:TerminationTime>
+<wsnt:NotificationMessage>
+<wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:RuleEngine/CellMotionDetector/Motion</wsnt:Topic>
+<wsnt:Message>
+<tt:Message UtcTime="2024-05-01T10:00:04Z" PropertyOperation="Changed">
+<tt:Source>
+<tt:SimpleItem Name="VideoSourceConfigurationToken" Value="VideoSourceToken"/>
+<tt:SimpleItem Name="VideoAnalyticsConfigurationToken" Value="VideoAnalyticsToken"/>
+<tt:SimpleItem Name="Rule" Value="MyMotionDetectorRule"/>
+</tt:Source>
+<tt:Data>
+<tt:SimpleItem Name="IsMotion" Value="true"/>
+</tt:Data>
+</tt:Message>
+</wsnt:Message>
+</wsnt:NotificationMessage>
+<wsnt:NotificationMessage>
+<wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:VideoSource/GlobalSceneChange/ImagingService</wsnt:Topic>
+<wsnt:Message>
+<tt:Message UtcTime="2024-05-01T10:00:05.123Z">
+<tt:Source><tt:SimpleItem Value="VideoSourceToken" Name="Source"/></tt:Source>
+<tt:Data><tt:SimpleItem Value="false" Name="State"/></tt:Data>
+</tt:Message>
+</wsnt:Message>
+</wsnt:NotificationMessage>
+</tev:PullMessagesResponse>
+</env:Body>
+</env:Envelope>`)
+
+	messages := ParseMessages(b)
+	require.Len(t, messages, 2)
+
+	msg := messages[0]
+	require.Equal(t, "RuleEngine/CellMotionDetector/Motion", msg.Topic)
+	require.Equal(t, "Changed", msg.Operation)
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 4, 0, time.UTC), msg.Time)
+	require.Equal(t, "MyMotionDetectorRule", msg.Source["Rule"])
+	require.Equal(t, map[string]string{"IsMotion": "true"}, msg.Data)
+	require.Equal(t, "true", msg.State)
+
+	msg = messages[1]
+	require.Equal(t, "VideoSource/GlobalSceneChange/ImagingService", msg.Topic)
+	require.Equal(t, "", msg.Operation)
+	require.Equal(t, map[string]string{"Source": "VideoSourceToken"}, msg.Source)
+	require.Equal(t, "false", msg.State)
+}
+
+func TestPullPoint(t *testing.T) {
+	var requests []string
+	bodies := map[string]string{} // checked in the test goroutine
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		action := GetRequestAction(b)
+		requests = append(requests, r.URL.RequestURI()+" "+action)
+		bodies[action] = string(b)
+
+		e := NewEnvelope()
+
+		switch action {
+		case DeviceGetCapabilities:
+			e.Append(`<tds:GetCapabilitiesResponse><tds:Capabilities>
+<tt:Events><tt:XAddr>http://`, r.Host, `/onvif/event_service</tt:XAddr></tt:Events>
+<tt:Media><tt:XAddr>http://`, r.Host, `/onvif/media_service</tt:XAddr></tt:Media>
+</tds:Capabilities></tds:GetCapabilitiesResponse>`)
+		case "CreatePullPointSubscription":
+			// camera may return wrong host
+			e.Append(`<tev:CreatePullPointSubscriptionResponse><tev:SubscriptionReference>
+<wsa5:Address>http://192.168.1.123/onvif/Subscription?Idx=6</wsa5:Address>
+</tev:SubscriptionReference></tev:CreatePullPointSubscriptionResponse>`)
+		case "PullMessages":
+			e.Append(`<tev:PullMessagesResponse><wsnt:NotificationMessage>
+<wsnt:Topic>tns1:Device/Trigger/DigitalInput</wsnt:Topic>
+<wsnt:Message><tt:Message UtcTime="2024-05-01T10:00:00Z" PropertyOperation="Initialized">
+<tt:Source><tt:SimpleItem Name="InputToken" Value="1"/></tt:Source>
+<tt:Data><tt:SimpleItem Name="LogicalState" Value="false"/></tt:Data>
+</tt:Message></wsnt:Message>
+</wsnt:NotificationMessage></tev:PullMessagesResponse>`)
+		case "Renew":
+			e.Append(`<wsnt:RenewResponse/>`)
+		case "Unsubscribe":
+			e.Append(`<wsnt:UnsubscribeResponse/>`)
+		default:
+			http.Error(w, "unsupported", http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write(e.Bytes())
+	}))
+	defer server.Close()
+
+	client, err := NewClient("onvif://admin:admin@" + server.Listener.Addr().String())
+	require.Nil(t, err)
+	require.True(t, client.HasEvents())
+	require.False(t, client.HasPTZ())
+
+	address, err := client.CreatePullPointSubscription(time.Minute)
+	require.Nil(t, err)
+	require.Equal(t, "http://"+server.Listener.Addr().String()+"/onvif/Subscription?Idx=6", address)
+	require.Contains(t, bodies["CreatePullPointSubscription"], `<tev:InitialTerminationTime>PT60S</tev:InitialTerminationTime>`)
+
+	messages, err := client.PullMessages(address, 10*time.Second, 10)
+	require.Nil(t, err)
+	require.Contains(t, bodies["PullMessages"], `<tev:Timeout>PT10S</tev:Timeout>`)
+	require.Len(t, messages, 1)
+	require.Equal(t, "Device/Trigger/DigitalInput", messages[0].Topic)
+	require.Equal(t, "Initialized", messages[0].Operation)
+	require.Equal(t, "false", messages[0].State)
+
+	require.Nil(t, client.Renew(address, time.Minute))
+	require.Nil(t, client.Unsubscribe(address))
+
+	require.Equal(t, []string{
+		"/onvif/device_service GetCapabilities",
+		"/onvif/event_service CreatePullPointSubscription",
+		"/onvif/Subscription?Idx=6 PullMessages",
+		"/onvif/Subscription?Idx=6 Renew",
+		"/onvif/Subscription?Idx=6 Unsubscribe",
+	}, requests)
+}
@@ -0,0 +1,99 @@
+package onvif
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+var presetRe = regexp.MustCompile(`(?s)<(?:\w+:)?Preset\b[^>]*?token="([^"]+)"[^>]*>(.*?)</(?:\w+:)?Preset>`)
+
+func (c *Client) HasPTZ() bool {
+	return c.ptzURL != ""
+}
+
+// ContinuousMove - move with velocity (-1..1) until Stop
+func (c *Client) ContinuousMove(token string, pan, tilt, zoom float64) error {
+	_, err := c.Request(c.ptzURL, `<tptz:ContinuousMove>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:Velocity>`+vector(pan, tilt, zoom)+`</tptz:Velocity>
+</tptz:ContinuousMove>`)
+	return err
+}
+
+// RelativeMove - move relative to current position
+func (c *Client) RelativeMove(token string, pan, tilt, zoom float64) error {
+	_, err := c.Request(c.ptzURL, `<tptz:RelativeMove>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:Translation>`+vector(pan, tilt, zoom)+`</tptz:Translation>
+</tptz:RelativeMove>`)
+	return err
+}
+
+// AbsoluteMove - move to position in camera coordinates
+func (c *Client) AbsoluteMove(token string, pan, tilt, zoom float64) error {
+	_, err := c.Request(c.ptzURL, `<tptz:AbsoluteMove>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:Position>`+vector(pan, tilt, zoom)+`</tptz:Position>
+</tptz:AbsoluteMove>`)
+	return err
+}
+
+func (c *Client) Stop(token string) error {
+	_, err := c.Request(c.ptzURL, `<tptz:Stop>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:PanTilt>true</tptz:PanTilt>
+	<tptz:Zoom>true</tptz:Zoom>
+</tptz:Stop>`)
+	return err
+}
+
+func (c *Client) GotoPreset(token, preset string) error {
+	_, err := c.Request(c.ptzURL, `<tptz:GotoPreset>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:PresetToken>`+html.EscapeString(preset)+`</tptz:PresetToken>
+</tptz:GotoPreset>`)
+	return err
+}
+
+func (c *Client) GetPresets(token string) ([]core.PTZPreset, error) {
+	b, err := c.Request(
+		c.ptzURL, `<tptz:GetPresets><tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken></tptz:GetPresets>`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	presets := []core.PTZPreset{}
+
+	for _, m := range presetRe.FindAllSubmatch(b, -1) {
+		presets = append(presets, core.PTZPreset{
+			Token: html.UnescapeString(string(m[1])),
+			Name:  html.UnescapeString(FindTagValue(m[2], "Name")),
+		})
+	}
+
+	return presets, nil
+}
+
+// SetPreset - save current position as preset, returns preset token
+func (c *Client) SetPreset(token, name string) (string, error) {
+	b, err := c.Request(c.ptzURL, `<tptz:SetPreset>
+	<tptz:ProfileToken>`+html.EscapeString(token)+`</tptz:ProfileToken>
+	<tptz:PresetName>`+html.EscapeString(name)+`</tptz:PresetName>
+</tptz:SetPreset>`)
+	if err != nil {
+		return "", err
+	}
+	return html.UnescapeString(FindTagValue(b, "PresetToken")), nil
+}
+
+func vector(pan, tilt, zoom float64) string {
+	return `<tt:PanTilt x="` + ftoa(pan) + `" y="` + ftoa(tilt) + `"/><tt:Zoom x="` + ftoa(zoom) + `"/>`
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
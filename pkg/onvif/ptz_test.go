@@ -0,0 +1,99 @@
+package onvif
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPTZ(t *testing.T) {
+	var requests []string
+	bodies := map[string]string{} // checked in the test goroutine
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		action := GetRequestAction(b)
+		requests = append(requests, r.URL.Path+" "+action)
+		bodies[action] = string(b)
+
+		e := NewEnvelope()
+
+		switch action {
+		case DeviceGetCapabilities:
+			e.Append(`<tds:GetCapabilitiesResponse><tds:Capabilities>
+<tt:Media><tt:XAddr>http://`, r.Host, `/onvif/media_service</tt:XAddr></tt:Media>
+<tt:PTZ><tt:XAddr>http://`, r.Host, `/onvif/ptz_service</tt:XAddr></tt:PTZ>
+</tds:Capabilities></tds:GetCapabilitiesResponse>`)
+		case MediaGetProfiles:
+			e.Append(`<trt:GetProfilesResponse><trt:Profiles token="main" fixed="true"><tt:Name>main</tt:Name></trt:Profiles></trt:GetProfilesResponse>`)
+		case "ContinuousMove":
+			e.Append(`<tptz:ContinuousMoveResponse/>`)
+		case "Stop":
+			e.Append(`<tptz:StopResponse/>`)
+		case "GetPresets":
+			e.Append(`<tptz:GetPresetsResponse>
+<tptz:Preset token="1"><tt:Name>Home</tt:Name><tt:PTZPosition><tt:PanTilt x="0" y="0"/></tt:PTZPosition></tptz:Preset>
+<tptz:Preset token="2"><tt:Name>Gate &amp; Door</tt:Name></tptz:Preset>
+</tptz:GetPresetsResponse>`)
+		case "SetPreset":
+			e.Append(`<tptz:SetPresetResponse><tptz:PresetToken>3</tptz:PresetToken></tptz:SetPresetResponse>`)
+		case "GotoPreset":
+			e.Append(`<tptz:GotoPresetResponse/>`)
+		default:
+			http.Error(w, "unsupported", http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write(e.Bytes())
+	}))
+	defer server.Close()
+
+	client, err := NewClient("onvif://admin:admin@" + server.Listener.Addr().String())
+	require.Nil(t, err)
+	require.True(t, client.HasPTZ())
+
+	token, err := client.GetProfileToken()
+	require.Nil(t, err)
+	require.Equal(t, "main", token)
+
+	require.Nil(t, client.ContinuousMove(token, 0.5, -0.25, 0))
+	require.Contains(t, bodies["ContinuousMove"], `<tptz:ProfileToken>main</tptz:ProfileToken>`)
+	require.Contains(t, bodies["ContinuousMove"], `<tt:PanTilt x="0.5" y="-0.25"/><tt:Zoom x="0"/>`)
+	require.Nil(t, client.Stop(token))
+
+	presets, err := client.GetPresets(token)
+	require.Nil(t, err)
+	require.Len(t, presets, 2)
+	require.Equal(t, "1", presets[0].Token)
+	require.Equal(t, "Home", presets[0].Name)
+	require.Equal(t, "Gate & Door", presets[1].Name)
+
+	preset, err := client.SetPreset(token, "Garage")
+	require.Nil(t, err)
+	require.Equal(t, "3", preset)
+	require.Contains(t, bodies["SetPreset"], `<tptz:PresetName>Garage</tptz:PresetName>`)
+
+	require.Nil(t, client.GotoPreset(token, preset))
+	require.Contains(t, bodies["GotoPreset"], `<tptz:PresetToken>3</tptz:PresetToken>`)
+
+	require.Equal(t, []string{
+		"/onvif/device_service GetCapabilities",
+		"/onvif/media_service GetProfiles",
+		"/onvif/ptz_service ContinuousMove",
+		"/onvif/ptz_service Stop",
+		"/onvif/ptz_service GetPresets",
+		"/onvif/ptz_service SetPreset",
+		"/onvif/ptz_service GotoPreset",
+	}, requests)
+}
+
+func TestServiceURL(t *testing.T) {
+	client := &Client{url: &url.URL{Host: "192.168.1.123:8443"}}
+	require.Equal(t, "https://192.168.1.123:8443/onvif/ptz_service", client.serviceURL("https://10.0.0.1/onvif/ptz_service"))
+	require.Equal(t, "http://192.168.1.123:8443/onvif/ptz_service", client.serviceURL("http://10.0.0.1/onvif/ptz_service"))
+	require.Equal(t, "", client.serviceURL(""))
+}
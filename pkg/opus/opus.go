@@ -0,0 +1,24 @@
+package opus
+
+import "encoding/binary"
+
+// EncodeHead - Opus ID header (OpusHead), RFC 7845 section 5.1
+func EncodeHead(channels uint8, sampleRate uint32) []byte {
+	b := make([]byte, 19)
+	copy(b, "OpusHead")
+	b[8] = 1 // version
+	b[9] = channels
+	binary.LittleEndian.PutUint16(b[10:], 0) // pre-skip
+	binary.LittleEndian.PutUint32(b[12:], sampleRate)
+	binary.LittleEndian.PutUint16(b[16:], 0) // output gain
+	b[18] = 0                                // channel mapping family
+	return b
+}
+
+// HeadChannels - channels count from OpusHead or zero for wrong header
+func HeadChannels(b []byte) uint8 {
+	if len(b) < 19 || string(b[:8]) != "OpusHead" {
+		return 0
+	}
+	return b[9]
+}
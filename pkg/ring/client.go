@@ -14,6 +14,8 @@ import (
 )
 
 type Client struct {
+	core.Listener
+
 	api       *RingApi
 	wsClient  *WSClient
 	prod      core.Producer
@@ -302,6 +304,12 @@ func (c *Client) onWSMessage(msg WSMessage) {
 
 	case "pong":
 		// Ignore
+		return
+	}
+
+	// session events for the listeners: session_created, session_started, close...
+	if msg.Method != "sdp" && msg.Method != "ice" {
+		c.Fire(msg.Method)
 	}
 }
 
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"strings"
 	"sync"
 
+	"github.com/AlexxIT/go2rtc/pkg/flv"
 	"github.com/AlexxIT/go2rtc/pkg/flv/amf"
 )
 
@@ -25,6 +27,7 @@ type Conn struct {
 	App    string
 	Stream string
 	Intent string
+	Query  url.Values // server side params from app and stream name
 
 	rdPacketSize uint32
 	wrPacketSize uint32
@@ -40,6 +43,8 @@ type Conn struct {
 	rdBuf []byte
 	wrBuf []byte
 	mu    sync.Mutex
+
+	auth func() bool
 }
 
 func (c *Conn) Close() error {
@@ -245,6 +250,8 @@ func (c *Conn) writeConnect() error {
 		"app":      c.App,
 		"flashVer": "FMLE/3.0 (compatible; FMSc/1.0)",
 		"tcUrl":    c.url,
+		// Enhanced RTMP, supported video codecs
+		"fourCcList": []string{flv.FourCCAV1, flv.FourCCVP9, flv.FourCCHEVC},
 	})
 	if err := c.writeMessage(3, TypeCommand, 0, b); err != nil {
 		return err
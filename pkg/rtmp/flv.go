@@ -75,22 +75,22 @@ func (c *Conn) Write(p []byte) (n int, err error) {
 
 	if p[0] == 'F' {
 		p = p[9+4:] // skip first msg with FLV header
+	}
+
+	// p can contain several tags (ex. sequence start before keyframe)
+	for len(p) > 0 {
+		// decode FLV: 11 bytes header + payload + 4 byte size
+		size := 11 + (int(p[1])<<16 | int(p[2])<<8 | int(p[3]))
+		tagType := p[0]
+		timeMS := uint32(p[4])<<16 | uint32(p[5])<<8 | uint32(p[6]) | uint32(p[7])<<24
+		payload := p[11:size]
 
-		for len(p) > 0 {
-			size := 11 + uint16(p[2])<<8 + uint16(p[3]) + 4
-			if _, err = c.Write(p[:size]); err != nil {
-				return 0, err
-			}
-			p = p[size:]
+		if err = c.writeMessage(4, tagType, timeMS, payload); err != nil {
+			return 0, err
 		}
-		return
-	}
 
-	// decode FLV: 11 bytes header + payload + 4 byte size
-	tagType := p[0]
-	timeMS := uint32(p[4])<<16 | uint32(p[5])<<8 | uint32(p[6]) | uint32(p[7])<<24
-	payload := p[11 : len(p)-4]
+		p = p[size+4:]
+	}
 
-	err = c.writeMessage(4, tagType, timeMS, payload)
 	return
 }
@@ -8,12 +8,17 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/flv/amf"
 )
 
+// FailedAuth - publish or play rejected by the Auth check
+var FailedAuth = errors.New("rtmp: failed authentication")
+
 func NewServer(conn net.Conn) (*Conn, error) {
 	c := &Conn{
 		conn: conn,
@@ -174,9 +179,26 @@ func (c *Conn) acceptCommand(b []byte) error {
 		return c.writeMessage(3, TypeCommand, 0, payload)
 
 	case CommandPublish, CommandPlay: // response later
-		c.Intent = cmd
 		c.streamID = 1
 
+		if len(items) >= 4 {
+			c.Stream, _ = items[3].(string)
+		}
+
+		c.parseQuery()
+
+		// if app is empty - will use stream name as app, ex. play from FFmpeg
+		if c.App == "" {
+			c.App = c.Stream
+		}
+
+		c.Intent = cmd
+
+		if c.auth != nil && !c.auth() {
+			_ = c.writeStatus(cmd, false)
+			return FailedAuth
+		}
+
 	default:
 		println("rtmp: unknown command: " + cmd)
 	}
@@ -185,17 +207,56 @@ func (c *Conn) acceptCommand(b []byte) error {
 }
 
 func (c *Conn) WriteStart() error {
+	return c.writeStatus(c.Intent, true)
+}
+
+func (c *Conn) writeStatus(cmd string, ok bool) error {
 	var code string
-	if c.Intent == CommandPublish {
+	switch {
+	case cmd == CommandPublish && ok:
 		code = "NetStream.Publish.Start"
-	} else {
+	case cmd == CommandPublish:
+		code = "NetStream.Publish.BadName" // OBS shows "Invalid stream key" for this code
+	case ok:
 		code = "NetStream.Play.Start"
+	default:
+		code = "NetStream.Play.Failed"
 	}
 
-	payload := amf.EncodeItems("onStatus", 0, nil, map[string]any{"code": code})
+	info := map[string]any{"code": code}
+	if !ok {
+		info["level"] = "error"
+	}
+
+	payload := amf.EncodeItems("onStatus", 0, nil, info)
 	return c.writeMessage(3, TypeCommand, 0, payload)
 }
 
+// Auth - set check for publish and play commands. Check can use App, Stream, Intent and Query values.
+func (c *Conn) Auth(check func() bool) {
+	c.auth = check
+}
+
+// parseQuery - move query params from app and stream name to Query,
+// ex. rtmp://192.168.1.123/camera1?user=admin&password=pass
+func (c *Conn) parseQuery() {
+	c.Query = url.Values{}
+
+	for _, s := range []*string{&c.App, &c.Stream} {
+		name, query, ok := strings.Cut(*s, "?")
+		if !ok {
+			continue
+		}
+
+		*s = name
+
+		values, _ := url.ParseQuery(query)
+		for k, v := range values {
+			c.Query[k] = append(c.Query[k], v...)
+		}
+	}
+}
+
 func nowMS() uint32 {
 	return uint32(time.Now().UnixNano() / int64(time.Millisecond))
 }
@@ -30,6 +30,13 @@ func NewClient(uri string) *Conn {
 	}
 }
 
+// NewPublisher - client for sending consumer tracks to the RTSP server with ANNOUNCE and RECORD
+func NewPublisher(uri string) *Conn {
+	c := NewClient(uri)
+	c.mode = core.ModeActiveConsumer
+	return c
+}
+
 func (c *Conn) Dial() (err error) {
 	if c.URL, err = url.Parse(c.uri); err != nil {
 		return
@@ -251,6 +258,51 @@ func (c *Conn) Record() (err error) {
 	return
 }
 
+// Publish - ANNOUNCE > SETUP... > RECORD for all consumer tracks
+func (c *Conn) Publish() (err error) {
+	if err = c.Dial(); err != nil {
+		return
+	}
+
+	// drop packets from tracks until RECORD response
+	c.state = StateNone
+
+	// convert tracks to real output medias, one media for each track
+	var medias []*core.Media
+	for i, sender := range c.Senders {
+		medias = append(medias, &core.Media{
+			Kind:   core.GetKind(sender.Codec.Name),
+			Codecs: []*core.Codec{sender.Codec},
+			ID:     "trackID=" + strconv.Itoa(i),
+		})
+	}
+	c.Medias = medias
+
+	if err = c.Announce(); err != nil {
+		return
+	}
+
+	for i, media := range c.Medias {
+		var channel byte
+		if channel, err = c.SetupMedia(media); err != nil {
+			return
+		}
+		// senders already use channel from the track position
+		if channel != byte(i*2) {
+			return fmt.Errorf("rtsp: wrong channel %d for track %d", channel, i)
+		}
+	}
+
+	if err = c.Record(); err != nil {
+		return
+	}
+
+	c.playOK.Store(true)
+	c.state = StatePlay
+
+	return nil
+}
+
 func (c *Conn) SetupMedia(media *core.Media) (byte, error) {
 	var transport string
 
@@ -282,6 +334,10 @@ func (c *Conn) SetupMedia(media *core.Media) (byte, error) {
 		return 0, fmt.Errorf("wrong media: %v", media)
 	}
 
+	if c.mode == core.ModeActiveConsumer {
+		transport += ";mode=record"
+	}
+
 	rawURL := media.ID // control
 	if !strings.Contains(rawURL, "://") {
 		rawURL = c.URL.String()
@@ -395,19 +451,27 @@ func (c *Conn) Teardown() (err error) {
 }
 
 func (c *Conn) Close() error {
-	if c.mode == core.ModeActiveProducer {
+	if (c.mode == core.ModeActiveProducer || c.mode == core.ModeActiveConsumer) && c.conn != nil {
 		_ = c.Teardown()
 	}
 	if c.OnClose != nil {
 		_ = c.OnClose()
 	}
 	for _, conn := range c.udpConn {
-		_ = conn.Close()
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+	if c.conn == nil {
+		return nil // multicast sender
 	}
 	return c.conn.Close()
 }
 
 func (c *Conn) WriteToUDP(b []byte, channel byte) (int, error) {
+	if int(channel) >= len(c.udpAddr) || c.udpConn[channel] == nil {
+		return 0, nil // channel without UDP transport
+	}
 	return c.udpConn[channel].WriteToUDP(b, c.udpAddr[channel])
 }
 
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/tcp"
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -93,3 +96,76 @@ Session: 1
 	require.Nil(t, err)
 	require.Equal(t, ch, byte(4))
 }
+
+func TestPublish(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	packets := make(chan *rtp.Packet, 10)
+
+	rawURL := newTestServer(t, func(server *Conn) {
+		server.Listen(func(msg any) {
+			if msg == MethodAnnounce {
+				require.Len(t, server.Receivers, 1)
+				require.Equal(t, core.CodecH264, server.Receivers[0].Codec.Name)
+
+				sender := core.NewSender(server.Medias[0], server.Receivers[0].Codec)
+				sender.Handler = func(packet *rtp.Packet) {
+					packets <- packet
+				}
+				sender.HandleRTP(server.Receivers[0])
+			}
+		})
+		if err := server.Accept(); err != nil {
+			return
+		}
+		_ = server.Handle()
+	})
+
+	client := NewPublisher(rawURL)
+	require.Nil(t, client.AddTrack(media, media.Codecs[0], track))
+	require.Nil(t, client.Publish())
+
+	go func() { _ = client.Handle() }()
+	defer client.Close()
+
+	track.WriteRTP(&rtp.Packet{
+		Header:  rtp.Header{Version: 2, Marker: true, SequenceNumber: 1, Timestamp: 100},
+		Payload: []byte{0x65, 1, 2, 3},
+	})
+
+	select {
+	case packet := <-packets:
+		require.Equal(t, uint32(100), packet.Timestamp)
+		require.Equal(t, []byte{0x65, 1, 2, 3}, packet.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("no packets")
+	}
+}
+
+func TestPublishUDP(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	transport := make(chan string, 1)
+
+	rawURL := newTestServer(t, func(server *Conn) {
+		server.Listen(func(msg any) {
+			if req, ok := msg.(*tcp.Request); ok && req.Method == MethodSetup {
+				transport <- req.Header.Get("Transport")
+			}
+		})
+		_ = server.Accept()
+	})
+
+	client := NewPublisher(rawURL)
+	client.Transport = "udp"
+	require.Nil(t, client.AddTrack(media, media.Codecs[0], track))
+	defer client.Close()
+
+	// server supports only TCP transport for incoming streams
+	require.NotNil(t, client.Publish())
+	require.Regexp(t, `^RTP/AVP;unicast;client_port=\d+-\d+;mode=record$`, <-transport)
+}
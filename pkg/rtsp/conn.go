@@ -10,11 +10,13 @@ import (
 	"net/url"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
 	"github.com/AlexxIT/go2rtc/pkg/tcp"
 	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
 )
 
 type Conn struct {
@@ -26,6 +28,8 @@ type Conn struct {
 	Backchannel bool
 	Media       string
 	OnClose     func() error
+	OnAuth      func(req *tcp.Request, user, pass string) bool // custom server auth check for each request
+	OnMulticast func() (*Conn, error)                          // shared sender for the server multicast transport
 	PacketSize  uint16
 	SessionName string
 	Timeout     int
@@ -39,7 +43,7 @@ type Conn struct {
 	conn      net.Conn
 	keepalive int
 	mode      core.Mode
-	playOK    bool
+	playOK    atomic.Bool // written by reader, read by senders
 	playErr   error
 	reader    *bufio.Reader
 	sequence  int
@@ -51,6 +55,14 @@ type Conn struct {
 
 	udpConn []*net.UDPConn
 	udpAddr []*net.UDPAddr
+
+	group     *net.UDPAddr // multicast sender
+	groupConn *net.UDPConn
+	groupTTL  int
+
+	secure   bool            // server over TLS
+	srtpKeys [][]byte        // SDES keys for each track
+	srtpCtx  []*srtp.Context // SRTP for each UDP channel
 }
 
 const (
@@ -92,7 +104,7 @@ func (c *Conn) Handle() (err error) {
 	var timeout time.Duration
 
 	switch c.mode {
-	case core.ModeActiveProducer:
+	case core.ModeActiveProducer, core.ModeActiveConsumer:
 		var keepaliveDT time.Duration
 
 		if c.keepalive > 5 {
@@ -110,7 +122,7 @@ func (c *Conn) Handle() (err error) {
 			timeout = time.Second * 5
 
 			if len(c.Receivers) == 0 || c.Transport == "udp" {
-				// if we only send audio to camera
+				// if we only send audio to camera or publish to server
 				// https://github.com/AlexxIT/go2rtc/issues/659
 				timeout += keepaliveDT
 			}
@@ -169,6 +181,9 @@ func (c *Conn) handleKeepalive(ctx context.Context, d time.Duration) {
 func (c *Conn) handleUDPData(channel byte) {
 	// TODO: handle timeouts and drop TCP connection after any error
 	conn := c.udpConn[channel]
+	if conn == nil {
+		return // channel without UDP transport
+	}
 
 	for {
 		// TP-Link Tapo camera has crazy 10000 bytes packet size
@@ -210,7 +225,7 @@ func (c *Conn) handleTCPData() error {
 			}
 			c.Fire(res)
 			// for playing backchannel only after OK response on play
-			c.playOK = true
+			c.playOK.Store(true)
 			return nil
 
 		case "OPTI", "TEAR", "DESC", "SETU", "PLAY", "PAUS", "RECO", "ANNO", "GET_", "SET_":
@@ -37,7 +37,7 @@ func (c *Conn) AddTrack(media *core.Media, codec *core.Codec, track *core.Receiv
 
 		c.state = StateSetup
 
-	case core.ModePassiveConsumer:
+	case core.ModePassiveConsumer, core.ModeActiveConsumer:
 		channel = byte(len(c.Senders)) * 2
 
 		// for consumer is better to use original track codec
@@ -45,6 +45,10 @@ func (c *Conn) AddTrack(media *core.Media, codec *core.Codec, track *core.Receiv
 		// generate new payload type, starting from 96
 		codec.PayloadType = byte(96 + len(c.Senders))
 
+		if c.group != nil {
+			c.addMulticast(channel)
+		}
+
 	default:
 		panic(core.Caller())
 	}
@@ -138,10 +142,10 @@ func (c *Conn) packetWriter(codec *core.Codec, channel, payloadType uint8) core.
 
 		n += 4 + size
 
-		if !packet.Marker || !c.playOK {
+		if !packet.Marker || !c.playOK.Load() {
 			// collect continious video packets to buffer
 			// or wait OK for PLAY command for backchannel
-			//log.Printf("[rtsp] collecting buffer ok=%t", c.playOK)
+			//log.Printf("[rtsp] collecting buffer ok=%t", c.playOK.Load())
 			return
 		}
 
@@ -176,22 +180,35 @@ func (c *Conn) packetWriter(codec *core.Codec, channel, payloadType uint8) core.
 }
 
 func (c *Conn) writeInterleavedData(data []byte) error {
-	if c.Transport != "udp" {
+	if c.udpConn == nil {
 		_ = c.conn.SetWriteDeadline(time.Now().Add(Timeout))
 		_, err := c.conn.Write(data)
 		return err
 	}
 
+	// transport is selected for each track, so some channels may be TCP interleaved
 	for len(data) >= 4 && data[0] == '$' {
 		channel := data[1]
 		size := uint16(data[2])<<8 | uint16(data[3])
-		rtpData := data[4 : 4+size]
+		packet := data[:4+size]
+		data = data[4+size:]
 
-		if _, err := c.WriteToUDP(rtpData, channel); err != nil {
+		if int(channel) >= len(c.udpConn) || c.udpConn[channel] == nil {
+			_ = c.conn.SetWriteDeadline(time.Now().Add(Timeout))
+			if _, err := c.conn.Write(packet); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rtpData, err := c.encryptRTP(channel, packet[4:])
+		if err != nil {
 			return err
 		}
 
-		data = data[4+size:]
+		if _, err = c.WriteToUDP(rtpData, channel); err != nil {
+			return err
+		}
 	}
 
 	return nil
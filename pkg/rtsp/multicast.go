@@ -0,0 +1,82 @@
+package rtsp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"golang.org/x/net/ipv4"
+)
+
+// NewMulticast - shared sender for all multicast viewers of the RTSP server stream.
+// Each track uses two ports (RTP and RTCP), starting from the group port.
+func NewMulticast(group *net.UDPAddr, ttl int) (*Conn, error) {
+	if !group.IP.IsMulticast() || group.Port&1 != 0 {
+		return nil, errors.New("rtsp: wrong multicast group: " + group.String())
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		if err = ipv4.NewPacketConn(conn).SetMulticastTTL(ttl); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	c := &Conn{
+		Connection: core.Connection{
+			ID:         core.NewID(),
+			FormatName: "rtsp",
+			Protocol:   "rtsp+multicast",
+			RemoteAddr: group.String(),
+			Transport:  conn,
+		},
+		Transport: "udp",
+		mode:      core.ModePassiveConsumer,
+		state:     StatePlay,
+		group:     group,
+		groupConn: conn,
+		groupTTL:  ttl,
+	}
+	c.playOK.Store(true) // shared sender doesn't wait PLAY response
+	return c, nil
+}
+
+// addMulticast - RTP and RTCP group ports for the new sender channel
+func (c *Conn) addMulticast(channel byte) {
+	for i := len(c.udpConn); i <= int(channel)+1; i++ {
+		c.udpConn = append(c.udpConn, c.groupConn)
+		c.udpAddr = append(c.udpAddr, &net.UDPAddr{IP: c.group.IP, Port: c.group.Port + i})
+	}
+}
+
+// setupMulticast - transport for the server consumer track from the shared multicast sender
+func (c *Conn) setupMulticast(i int) (string, error) {
+	if c.OnMulticast == nil {
+		return "", errors.New("rtsp: multicast not supported")
+	}
+
+	mc, err := c.OnMulticast()
+	if err != nil {
+		return "", err
+	}
+
+	// multicast sender should have the same tracks as in the DESCRIBE response
+	if i >= len(c.Senders) || i >= len(mc.Senders) || *c.Senders[i].Codec != *mc.Senders[i].Codec {
+		return "", errors.New("rtsp: multicast track not matched")
+	}
+
+	tr := fmt.Sprintf(
+		"RTP/AVP;multicast;destination=%s;port=%d-%d",
+		mc.group.IP, mc.group.Port+i*2, mc.group.Port+i*2+1,
+	)
+	if mc.groupTTL > 0 {
+		tr += fmt.Sprintf(";ttl=%d", mc.groupTTL)
+	}
+	return tr, nil
+}
@@ -2,6 +2,8 @@ package rtsp
 
 import (
 	"bufio"
+	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -16,7 +18,7 @@ import (
 var FailedAuth = errors.New("failed authentication")
 
 func NewServer(conn net.Conn) *Conn {
-	return &Conn{
+	c := &Conn{
 		Connection: core.Connection{
 			ID:         core.NewID(),
 			FormatName: "rtsp",
@@ -26,6 +28,11 @@ func NewServer(conn net.Conn) *Conn {
 		conn:   conn,
 		reader: bufio.NewReader(conn),
 	}
+	if _, ok := conn.(*tls.Conn); ok {
+		c.Protocol = "rtsps+tcp"
+		c.secure = true
+	}
+	return c
 }
 
 func (c *Conn) Auth(username, password string) {
@@ -33,6 +40,24 @@ func (c *Conn) Auth(username, password string) {
 	c.auth = tcp.NewAuth(info)
 }
 
+// validate - check request with OnAuth handler or with static username and password
+func (c *Conn) validate(req *tcp.Request) (valid, empty bool) {
+	if c.OnAuth == nil {
+		return c.auth.Validate(req)
+	}
+
+	header := req.Header.Get("Authorization")
+
+	var user, pass string
+	if s, ok := strings.CutPrefix(header, "Basic "); ok {
+		if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+			user, pass, _ = strings.Cut(string(b), ":")
+		}
+	}
+
+	return c.OnAuth(req, user, pass), header == ""
+}
+
 func (c *Conn) Accept() error {
 	for {
 		req, err := c.ReadRequest()
@@ -47,7 +72,7 @@ func (c *Conn) Accept() error {
 
 		c.Fire(req)
 
-		if valid, empty := c.auth.Validate(req); !valid {
+		if valid, empty := c.validate(req); !valid {
 			res := &tcp.Response{
 				Status:  "401 Unauthorized",
 				Header:  map[string][]string{"Www-Authenticate": {`Basic realm="go2rtc"`}},
@@ -151,6 +176,12 @@ func (c *Conn) Accept() error {
 				return err
 			}
 
+			if c.secure {
+				if res.Body, err = c.addCrypto(res.Body); err != nil {
+					return err
+				}
+			}
+
 			c.SDP = string(res.Body) // for info
 
 			if err = c.WriteResponse(res); err != nil {
@@ -163,28 +194,27 @@ func (c *Conn) Accept() error {
 				Request: req,
 			}
 
-			// Test if client requests TCP transport, otherwise return 461 Transport not supported
-			// This allows smart clients who initially requested UDP to fall back on TCP transport
-			if tr := req.Header.Get("Transport"); strings.HasPrefix(tr, "RTP/AVP/TCP") {
-				c.session = core.RandString(8, 10)
-				c.state = StateSetup
+			tr := req.Header.Get("Transport")
 
-				if c.mode == core.ModePassiveConsumer {
-					if i := reqTrackID(req); i >= 0 && i < len(c.Senders)+len(c.Receivers) {
-						if i < len(c.Senders) {
-							c.Senders[i].Media.ID = MethodSetup
-						} else {
-							c.Receivers[i-len(c.Senders)].Media.ID = MethodSetup
-						}
-						tr = fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", i*2, i*2+1)
+			if c.mode == core.ModePassiveConsumer {
+				if i := reqTrackID(req); i >= 0 && i < len(c.Senders)+len(c.Receivers) {
+					if tr, err = c.setupConsumer(i, tr); err == nil {
+						c.session = core.RandString(8, 10)
+						c.state = StateSetup
 						res.Header.Set("Transport", tr)
 					} else {
-						res.Status = "400 Bad Request"
+						// This allows smart clients who initially requested UDP to fall back on TCP transport
+						res.Status = "461 Unsupported transport"
 					}
 				} else {
-					res.Header.Set("Transport", tr)
+					res.Status = "400 Bad Request"
 				}
+			} else if strings.HasPrefix(tr, "RTP/AVP/TCP") {
+				c.session = core.RandString(8, 10)
+				c.state = StateSetup
+				res.Header.Set("Transport", tr)
 			} else {
+				// Test if client requests TCP transport, otherwise return 461 Transport not supported
 				res.Status = "461 Unsupported transport"
 			}
 
@@ -204,7 +234,7 @@ func (c *Conn) Accept() error {
 
 			res := &tcp.Response{Request: req}
 			err = c.WriteResponse(res)
-			c.playOK = true
+			c.playOK.Store(true)
 			return err
 
 		case MethodTeardown:
@@ -219,6 +249,98 @@ func (c *Conn) Accept() error {
 	}
 }
 
+// setupConsumer - TCP interleaved, UDP unicast (RTP or SRTP) or UDP multicast transport for the track
+func (c *Conn) setupConsumer(i int, tr string) (string, error) {
+	var media *core.Media
+	if i < len(c.Senders) {
+		media = c.Senders[i].Media
+	} else {
+		media = c.Receivers[i-len(c.Senders)].Media
+	}
+
+	switch {
+	case strings.HasPrefix(tr, "RTP/SAVP"):
+		// SRTP only for UDP unicast, TCP interleaved is already inside TLS
+		if strings.HasPrefix(tr, "RTP/SAVP/TCP") || !strings.Contains(tr, "client_port=") {
+			return "", errors.New("rtsp: unsupported transport: " + tr)
+		}
+		if err := c.setupSRTP(i, byte(i*2)); err != nil {
+			return "", err
+		}
+		var err error
+		if tr, err = c.setupUDP(byte(i*2), tr); err != nil {
+			return "", err
+		}
+		tr = "RTP/SAVP" + tr[len("RTP/AVP"):]
+
+	case strings.HasPrefix(tr, "RTP/AVP/TCP"):
+		tr = fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", i*2, i*2+1)
+
+	case strings.Contains(tr, "multicast"):
+		// track will be sent by the shared multicast sender, so the own sender stays unconfigured
+		return c.setupMulticast(i)
+
+	case strings.Contains(tr, "client_port="):
+		var err error
+		if tr, err = c.setupUDP(byte(i*2), tr); err != nil {
+			return "", err
+		}
+
+	default:
+		return "", errors.New("rtsp: unsupported transport: " + tr)
+	}
+
+	media.ID = MethodSetup
+
+	return tr, nil
+}
+
+// setupUDP - RTP/AVP;unicast;client_port=5000-5001 or RTP/AVP/UDP;unicast;client_port=5000-5001
+func (c *Conn) setupUDP(channel byte, tr string) (string, error) {
+	s := tr[strings.Index(tr, "client_port=")+len("client_port="):]
+	if i := strings.IndexByte(s, ';'); i > 0 {
+		s = s[:i]
+	}
+
+	s1, s2, _ := strings.Cut(s, "-")
+	port1, err := strconv.Atoi(s1)
+	if err != nil {
+		return "", err
+	}
+	port2 := port1 + 1
+	if s2 != "" {
+		if port2, err = strconv.Atoi(s2); err != nil {
+			return "", err
+		}
+	}
+
+	// send only to the RTSP client IP, even if the client requests another destination
+	host, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(host)
+
+	conn1, conn2, err := ListenUDPPair()
+	if err != nil {
+		return "", err
+	}
+
+	for len(c.udpConn) <= int(channel)+1 {
+		c.udpConn = append(c.udpConn, nil)
+		c.udpAddr = append(c.udpAddr, nil)
+	}
+
+	c.udpConn[channel], c.udpConn[channel+1] = conn1, conn2
+	c.udpAddr[channel] = &net.UDPAddr{IP: ip, Port: port1}
+	c.udpAddr[channel+1] = &net.UDPAddr{IP: ip, Port: port2}
+
+	c.Protocol = "rtsp+udp"
+
+	port := conn1.LocalAddr().(*net.UDPAddr).Port
+	return fmt.Sprintf("RTP/AVP;unicast;client_port=%d-%d;server_port=%d-%d", port1, port2, port, port+1), nil
+}
+
 func reqTrackID(req *tcp.Request) int {
 	var s string
 	if req.URL.RawQuery != "" {
@@ -0,0 +1,309 @@
+package rtsp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/tcp"
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handle func(conn *Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	accepted := make(chan net.Conn, 1)
+	done := make(chan struct{})
+
+	// wait server goroutine, so the next test can change Timeout without race
+	t.Cleanup(func() {
+		_ = ln.Close()
+		select {
+		case conn := <-accepted:
+			_ = conn.Close()
+		case <-done:
+		}
+		<-done
+	})
+
+	go func() {
+		defer close(done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn
+		handle(NewServer(conn))
+	}()
+
+	return "rtsp://" + ln.Addr().String() + "/stream"
+}
+
+func newTestTrack() (*core.Media, *core.Receiver) {
+	media := &core.Media{
+		Kind:      core.KindVideo,
+		Direction: core.DirectionSendonly,
+		Codecs:    []*core.Codec{{Name: core.CodecH264, ClockRate: 90000, PayloadType: 96}},
+	}
+	return media, core.NewReceiver(media, &core.Codec{Name: core.CodecH264, ClockRate: 90000, PayloadType: 96})
+}
+
+func TestServerUDP(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	rawURL := newTestServer(t, func(server *Conn) {
+		server.Listen(func(msg any) {
+			if msg == MethodDescribe {
+				require.Nil(t, server.AddTrack(media, media.Codecs[0], track))
+			}
+		})
+		if err := server.Accept(); err != nil {
+			return
+		}
+		_ = server.Handle()
+	})
+
+	client := NewClient(rawURL)
+	client.Transport = "udp"
+	require.Nil(t, client.Dial())
+	require.Nil(t, client.Describe())
+	require.Len(t, client.Medias, 1)
+
+	recv, err := client.GetTrack(client.Medias[0], client.Medias[0].Codecs[0])
+	require.Nil(t, err)
+	require.Nil(t, client.Play())
+
+	packets := make(chan *rtp.Packet, 10)
+	sender := core.NewSender(client.Medias[0], recv.Codec)
+	sender.Handler = func(packet *rtp.Packet) {
+		packets <- packet
+	}
+	sender.HandleRTP(recv)
+
+	go func() { _ = client.Handle() }()
+	defer client.Close()
+
+	// wait PLAY response before sending packets
+	time.Sleep(100 * time.Millisecond)
+
+	track.WriteRTP(&rtp.Packet{
+		Header:  rtp.Header{Version: 2, Marker: true, SequenceNumber: 1, Timestamp: 100},
+		Payload: []byte{0x65, 1, 2, 3},
+	})
+
+	select {
+	case packet := <-packets:
+		require.Equal(t, uint32(100), packet.Timestamp)
+		require.Equal(t, []byte{0x65, 1, 2, 3}, packet.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("no packets")
+	}
+}
+
+func TestServerMulticast(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	group := &net.UDPAddr{IP: net.IPv4(239, 255, 0, 1), Port: 50000}
+	mc, err := NewMulticast(group, 2)
+	require.Nil(t, err)
+	require.Nil(t, mc.AddTrack(media, media.Codecs[0], track))
+	defer mc.Stop()
+
+	_, err = NewMulticast(&net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 50000}, 0)
+	require.NotNil(t, err)
+
+	rawURL := newTestServer(t, func(server *Conn) {
+		server.Listen(func(msg any) {
+			if msg == MethodDescribe {
+				require.Nil(t, server.AddTrack(media, media.Codecs[0], track))
+				server.OnMulticast = func() (*Conn, error) {
+					return mc, nil
+				}
+			}
+		})
+		_ = server.Accept()
+	})
+
+	client := NewClient(rawURL)
+	require.Nil(t, client.Dial())
+	require.Nil(t, client.Describe())
+
+	req := &tcp.Request{
+		Method: MethodSetup,
+		URL:    client.URL.JoinPath("trackID=0"),
+		Header: map[string][]string{"Transport": {"RTP/AVP;multicast"}},
+	}
+	res, err := client.Do(req)
+	require.Nil(t, err)
+	require.Equal(t, "RTP/AVP;multicast;destination=239.255.0.1;port=50000-50001;ttl=2", res.Header.Get("Transport"))
+
+	req.Header.Set("Transport", "RTP/AVP;unicast;client_port=6000-6001")
+	req.URL = client.URL.JoinPath("trackID=1")
+	res, err = client.Do(req)
+	require.NotNil(t, err)
+	require.Equal(t, 400, res.StatusCode)
+}
+
+func TestServerSRTP(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	rawURL := newTestServer(t, func(server *Conn) {
+		server.secure = true // same as for TLS connection
+		server.Listen(func(msg any) {
+			if msg == MethodDescribe {
+				require.Nil(t, server.AddTrack(media, media.Codecs[0], track))
+			}
+		})
+		if err := server.Accept(); err != nil {
+			return
+		}
+		_ = server.Handle()
+	})
+
+	client := NewClient(rawURL)
+	require.Nil(t, client.Dial())
+	require.Nil(t, client.Describe())
+	defer client.Close()
+
+	// a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:...
+	s := core.Between(client.SDP, "a=crypto:1 "+srtpSuite+" inline:", "\r\n")
+	key, err := base64.StdEncoding.DecodeString(s)
+	require.Nil(t, err)
+	require.Len(t, key, 30)
+
+	udp, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.Nil(t, err)
+	defer udp.Close()
+
+	port := udp.LocalAddr().(*net.UDPAddr).Port
+	req := &tcp.Request{
+		Method: MethodSetup,
+		URL:    client.URL.JoinPath("trackID=0"),
+		Header: map[string][]string{"Transport": {fmt.Sprintf("RTP/SAVP;unicast;client_port=%d-%d", port, port+1)}},
+	}
+	res, err := client.Do(req)
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(res.Header.Get("Transport"), "RTP/SAVP;unicast;client_port="))
+
+	// SRTP not supported for TCP
+	req.Header.Set("Transport", "RTP/SAVP/TCP;unicast;interleaved=0-1")
+	res, err = client.Do(req)
+	require.NotNil(t, err)
+	require.Equal(t, 461, res.StatusCode)
+
+	require.Nil(t, client.Play())
+
+	// wait PLAY response before sending packets
+	time.Sleep(100 * time.Millisecond)
+
+	track.WriteRTP(&rtp.Packet{
+		Header:  rtp.Header{Version: 2, Marker: true, SequenceNumber: 1, Timestamp: 100},
+		Payload: []byte{0x65, 1, 2, 3},
+	})
+
+	b := make([]byte, 1500)
+	_ = udp.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := udp.Read(b)
+	require.Nil(t, err)
+
+	ctx, err := srtp.CreateContext(key[:16], key[16:], srtp.ProtectionProfileAes128CmHmacSha1_80)
+	require.Nil(t, err)
+
+	b, err = ctx.DecryptRTP(nil, b[:n], nil)
+	require.Nil(t, err)
+
+	packet := &rtp.Packet{}
+	require.Nil(t, packet.Unmarshal(b))
+	require.Equal(t, uint32(100), packet.Timestamp)
+	require.Equal(t, []byte{0x65, 1, 2, 3}, packet.Payload)
+}
+
+func TestServerOnAuth(t *testing.T) {
+	Timeout = time.Second
+
+	media, track := newTestTrack()
+
+	handle := func(server *Conn) {
+		server.OnAuth = func(req *tcp.Request, user, pass string) bool {
+			return user == "admin" && pass == "secret" || req.URL.Query().Get("token") == "secret"
+		}
+		server.Listen(func(msg any) {
+			if msg == MethodDescribe {
+				require.Nil(t, server.AddTrack(media, media.Codecs[0], track))
+			}
+		})
+		_ = server.Accept()
+	}
+
+	for _, test := range []struct {
+		user, query string
+		ok          bool
+	}{
+		{"admin:secret@", "", true},
+		{"", "?token=secret", true},
+		{"admin:wrong@", "", false},
+		{"", "", false},
+	} {
+		rawURL := newTestServer(t, handle)
+		rawURL = strings.Replace(rawURL, "rtsp://", "rtsp://"+test.user, 1) + test.query
+
+		client := NewClient(rawURL)
+		require.Nil(t, client.Dial())
+		err := client.Describe()
+		require.Equal(t, test.ok, err == nil, rawURL)
+		_ = client.Close()
+	}
+}
+
+func TestServerMixedTransport(t *testing.T) {
+	Timeout = time.Second
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	require.Nil(t, err)
+	defer client.Close()
+
+	conn, err := ln.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.Nil(t, err)
+	defer udp.Close()
+
+	// first track (channel 0) over TCP interleaved, second track (channel 2) over UDP
+	server := NewServer(conn)
+	port := udp.LocalAddr().(*net.UDPAddr).Port
+	_, err = server.setupUDP(2, fmt.Sprintf("RTP/AVP;unicast;client_port=%d-%d", port, port+1))
+	require.Nil(t, err)
+
+	require.Nil(t, server.writeInterleavedData([]byte{'$', 0, 0, 2, 1, 2, '$', 2, 0, 2, 3, 4}))
+
+	buf := make([]byte, 16)
+
+	_ = client.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := client.Read(buf)
+	require.Nil(t, err)
+	require.Equal(t, []byte{'$', 0, 0, 2, 1, 2}, buf[:n])
+
+	_ = udp.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err = udp.ReadFromUDP(buf)
+	require.Nil(t, err)
+	require.Equal(t, []byte{3, 4}, buf[:n])
+}
@@ -0,0 +1,64 @@
+package rtsp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/srtp/v3"
+)
+
+// SRTP for the RTSPS server. Keys are sent inside the DESCRIBE response (SDES, RFC 4568),
+// so they are used only for TLS connections, because SDP itself is not encrypted.
+
+const srtpSuite = "AES_CM_128_HMAC_SHA1_80"
+
+// addCrypto - new master key and salt for each track of the SDP
+func (c *Conn) addCrypto(body []byte) ([]byte, error) {
+	sd := &sdp.SessionDescription{}
+	if err := sd.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	c.srtpKeys = nil
+
+	for _, md := range sd.MediaDescriptions {
+		key := make([]byte, 30) // 16 bytes master key + 14 bytes master salt
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		c.srtpKeys = append(c.srtpKeys, key)
+
+		md.WithValueAttribute("crypto", "1 "+srtpSuite+" inline:"+base64.StdEncoding.EncodeToString(key))
+	}
+
+	return sd.Marshal()
+}
+
+// setupSRTP - encryption context for the RTP channel of the track
+func (c *Conn) setupSRTP(i int, channel byte) error {
+	if i >= len(c.srtpKeys) {
+		return errors.New("rtsp: SRTP keys not exchanged")
+	}
+
+	key := c.srtpKeys[i]
+	ctx, err := srtp.CreateContext(key[:16], key[16:], srtp.ProtectionProfileAes128CmHmacSha1_80)
+	if err != nil {
+		return err
+	}
+
+	for len(c.srtpCtx) <= int(channel) {
+		c.srtpCtx = append(c.srtpCtx, nil)
+	}
+	c.srtpCtx[channel] = ctx
+
+	return nil
+}
+
+func (c *Conn) encryptRTP(channel byte, data []byte) ([]byte, error) {
+	if int(channel) >= len(c.srtpCtx) || c.srtpCtx[channel] == nil {
+		return data, nil
+	}
+	return c.srtpCtx[channel].EncryptRTP(nil, data, nil)
+}
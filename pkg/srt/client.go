@@ -0,0 +1,475 @@
+package srt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+)
+
+const (
+	ModeCaller     = "caller"
+	ModeListener   = "listener"
+	ModeRendezvous = "rendezvous"
+
+	handshakeRetry  = 250 * time.Millisecond
+	listenerTimeout = 30 * time.Second // wait for the caller in listener mode
+
+	mtu = 1500
+)
+
+type Config struct {
+	Latency    time.Duration
+	Passphrase string
+	KeyLength  int    // 16, 24 or 32 bytes, default 16
+	StreamID   string // for caller and rendezvous modes
+	LocalPort  int    // for caller and rendezvous modes
+
+	// Accept - check stream ID in listener mode, returns reject reason or zero
+	Accept func(streamID string) int
+}
+
+// ParseURL - srt://host:port?mode=caller&streamid=xxx&latency=120&passphrase=xxx&pbkeylen=16&localport=0
+func ParseURL(rawURL string) (address, mode string, conf *Config, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	query := u.Query()
+
+	conf = &Config{
+		StreamID:   query.Get("streamid"),
+		Passphrase: query.Get("passphrase"),
+		KeyLength:  core.Atoi(query.Get("pbkeylen")),
+		LocalPort:  core.Atoi(query.Get("localport")),
+	}
+
+	// latency in milliseconds, same as srt-live-transmit
+	if s := query.Get("latency"); s != "" {
+		conf.Latency = time.Duration(core.Atoi(s)) * time.Millisecond
+	}
+
+	switch mode = query.Get("mode"); mode {
+	case "":
+		if u.Hostname() == "" {
+			mode = ModeListener
+		} else {
+			mode = ModeCaller
+		}
+	case ModeCaller, ModeListener, ModeRendezvous:
+	default:
+		return "", "", nil, errors.New("srt: unsupported mode: " + mode)
+	}
+
+	return u.Host, mode, conf, nil
+}
+
+// Dial - connect in caller or rendezvous mode, or wait for one caller in listener mode
+func Dial(rawURL string) (*Conn, error) {
+	address, mode, conf, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ModeListener {
+		ln, err := Listen(address, conf)
+		if err != nil {
+			return nil, err
+		}
+		ln.single = true
+
+		conn, err := ln.acceptTimeout(listenerTimeout)
+		if err != nil {
+			_ = ln.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	laddr := &net.UDPAddr{Port: conf.LocalPort}
+	if mode == ModeRendezvous && laddr.Port == 0 {
+		// both sides use the same port by default
+		laddr.Port = raddr.Port
+	}
+
+	pc, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &handshaker{
+		pc:         pc,
+		raddr:      raddr,
+		conf:       conf,
+		localID:    randUint32(),
+		isn:        randUint32() & seqMask,
+		cookie:     randUint32(),
+		rendezvous: mode == ModeRendezvous,
+	}
+
+	conn, err := h.run()
+	if err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+
+	conn.onClose = func() {
+		_ = pc.Close()
+	}
+
+	go readLoop(pc, conn)
+
+	return conn, nil
+}
+
+// handshaker - caller and rendezvous handshake (HSv5)
+type handshaker struct {
+	pc    *net.UDPConn
+	raddr *net.UDPAddr
+	conf  *Config
+
+	localID uint32
+	peerID  uint32
+	isn     uint32
+	cookie  uint32
+
+	crypto *Crypto
+	km     []byte
+
+	rendezvous bool
+	initiator  bool
+	contested  bool
+
+	// rendezvous responder: initiator request and our response
+	request *Handshake
+	hsReply []byte
+}
+
+func (h *handshaker) run() (*Conn, error) {
+	if h.conf.Passphrase != "" {
+		var err error
+		if h.crypto, err = NewCrypto(h.conf.KeyLength); err != nil {
+			return nil, err
+		}
+		if h.km, err = h.crypto.MarshalKM(h.conf.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &Handshake{Version: 4, Extension: 2, Type: HandshakeInduction, ISN: h.isn, MTU: mtu, Window: windowSize}
+	if h.rendezvous {
+		req = &Handshake{Version: 5, Type: HandshakeWaveahand, ISN: h.isn, MTU: mtu, Window: windowSize, Cookie: h.cookie}
+	}
+
+	deadline := time.Now().Add(core.ConnDialTimeout)
+	b := make([]byte, mtu)
+
+	for time.Now().Before(deadline) {
+		if err := h.write(req); err != nil {
+			return nil, err
+		}
+
+		_ = h.pc.SetReadDeadline(time.Now().Add(handshakeRetry))
+
+	read:
+		for {
+			n, addr, err := h.pc.ReadFromUDP(b)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break // repeat last request
+				}
+				return nil, err
+			}
+
+			if addr.String() != h.raddr.String() {
+				continue
+			}
+
+			p, err := Unmarshal(b[:n])
+			if err != nil || (p.SocketID != h.localID && p.SocketID != 0) {
+				continue
+			}
+
+			if !p.Control || p.Type != ControlHandshake {
+				// rendezvous responder: initiator is already connected
+				if h.request != nil {
+					_ = h.pc.SetReadDeadline(time.Time{})
+					conn := h.newConn(h.request)
+					conn.handle(p)
+					return conn, nil
+				}
+				continue
+			}
+
+			res := &Handshake{}
+			if err = res.Unmarshal(p.Payload); err != nil {
+				continue
+			}
+
+			if res.IsReject() {
+				return nil, errors.New("srt: rejected with reason " + strconv.Itoa(int(res.Type-HandshakeReject)))
+			}
+
+			next, conn, err := h.next(res)
+			if err != nil {
+				return nil, err
+			}
+			if conn != nil {
+				_ = h.pc.SetReadDeadline(time.Time{})
+				return conn, nil
+			}
+			if next != nil {
+				req = next
+				break read
+			}
+		}
+	}
+
+	return nil, errors.New("srt: handshake timeout")
+}
+
+// next - returns next request or established connection
+func (h *handshaker) next(res *Handshake) (*Handshake, *Conn, error) {
+	switch res.Type {
+	case HandshakeInduction:
+		// caller: listener response with cookie
+		if h.rendezvous {
+			return nil, nil, nil
+		}
+		if res.Version != 5 || res.Extension != srtMagic {
+			return nil, nil, errors.New("srt: unsupported peer version")
+		}
+		h.cookie = res.Cookie
+		return h.conclusion(), nil, nil
+
+	case HandshakeWaveahand:
+		if !h.contest(res) {
+			return nil, nil, nil
+		}
+		if h.initiator {
+			return h.conclusion(), nil, nil
+		}
+		return &Handshake{Version: 5, Type: HandshakeConclusion, ISN: h.isn, MTU: mtu, Window: windowSize, Cookie: h.cookie}, nil, nil
+
+	case HandshakeConclusion:
+		if !h.rendezvous {
+			// caller: listener response with HSRSP
+			if res.HSRsp == nil {
+				return nil, nil, errors.New("srt: unsupported peer version")
+			}
+			conn, err := h.finish(res)
+			return nil, conn, err
+		}
+
+		// peer can miss our waveahand and start from conclusion
+		if !h.contest(res) {
+			return nil, nil, nil
+		}
+
+		if h.initiator {
+			if res.HSRsp == nil {
+				return h.conclusion(), nil, nil // responder doesn't know its role yet
+			}
+			conn, err := h.finish(res)
+			if err != nil {
+				return nil, nil, err
+			}
+			// repeat agreement for retransmitted conclusions
+			agreement := &Handshake{Version: 5, Type: HandshakeAgreement, ISN: h.isn, MTU: mtu, Window: windowSize, Cookie: h.cookie}
+			conn.hsReply = h.marshal(agreement)
+			_ = h.write(agreement)
+			return nil, conn, nil
+		}
+
+		if res.HSReq == nil {
+			return nil, nil, nil
+		}
+
+		// responder: answer with HSRSP and wait for agreement
+		rsp, crypto, latency, reason := acceptHandshake(h.conf, res)
+		if reason != 0 {
+			return nil, nil, errors.New("srt: rejected with reason " + strconv.Itoa(reason))
+		}
+		rsp.ISN = h.isn
+		rsp.Cookie = h.cookie
+		h.crypto = crypto
+		h.conf.Latency = latency
+		h.request = res
+		h.hsReply = h.marshal(rsp)
+		return rsp, nil, nil
+
+	case HandshakeAgreement:
+		if h.request == nil {
+			return nil, nil, nil
+		}
+		return nil, h.newConn(h.request), nil
+	}
+
+	return nil, nil, nil
+}
+
+// contest - rendezvous cookie contest, bigger cookie is the initiator
+func (h *handshaker) contest(res *Handshake) bool {
+	if !h.rendezvous || res.Cookie == h.cookie {
+		return false
+	}
+	h.peerID = res.SocketID
+	if !h.contested {
+		h.contested = true
+		h.initiator = int32(h.cookie) > int32(res.Cookie)
+	}
+	return true
+}
+
+func (h *handshaker) conclusion() *Handshake {
+	req := &Handshake{
+		Version:   5,
+		Extension: flagHSReq,
+		ISN:       h.isn,
+		MTU:       mtu,
+		Window:    windowSize,
+		Type:      HandshakeConclusion,
+		Cookie:    h.cookie,
+		HSReq:     marshalHSExt(srtFlags, h.latency()),
+		KMReq:     h.km,
+		StreamID:  h.conf.StreamID,
+	}
+	if h.crypto != nil {
+		req.Encryption = uint16(h.crypto.keyLen / 8)
+		req.Extension |= flagKMReq
+	}
+	if req.StreamID != "" {
+		req.Extension |= flagCfg
+	}
+	return req
+}
+
+// finish - check HSRSP and KMRSP from the listener or rendezvous responder
+func (h *handshaker) finish(res *Handshake) (*Conn, error) {
+	_, latency, err := parseHSExt(res.HSRsp)
+	if err != nil {
+		return nil, err
+	}
+	h.conf.Latency = max(h.latency(), latency)
+
+	// KMRSP with error state has only one word
+	if h.crypto != nil && len(res.KMRsp) <= 4 {
+		return nil, errBadSecret
+	}
+
+	return h.newConn(res), nil
+}
+
+func (h *handshaker) newConn(peer *Handshake) *Conn {
+	conn := newConn(h.pc, h.raddr, h.localID, peer.SocketID, h.isn, peer.ISN, h.latency())
+	conn.StreamID = h.conf.StreamID
+	conn.crypto = h.crypto
+	conn.passphrase = h.conf.Passphrase
+	conn.hsReply = h.hsReply
+	return conn
+}
+
+func (h *handshaker) latency() time.Duration {
+	if h.conf.Latency > 0 {
+		return h.conf.Latency
+	}
+	return DefaultLatency
+}
+
+func (h *handshaker) marshal(hs *Handshake) []byte {
+	hs.SocketID = h.localID
+	p := &Packet{Control: true, Type: ControlHandshake, SocketID: h.peerID, Payload: hs.Marshal()}
+	return p.Marshal()
+}
+
+func (h *handshaker) write(hs *Handshake) error {
+	_, err := h.pc.WriteToUDP(h.marshal(hs), h.raddr)
+	return err
+}
+
+// acceptHandshake - check conclusion request from the caller or rendezvous initiator,
+// returns conclusion response or reject reason
+func acceptHandshake(conf *Config, req *Handshake) (*Handshake, *Crypto, time.Duration, int) {
+	if req.Version != 5 || req.HSReq == nil {
+		return nil, nil, 0, RejectPeer
+	}
+
+	_, latency, err := parseHSExt(req.HSReq)
+	if err != nil {
+		return nil, nil, 0, RejectPeer
+	}
+	latency = max(latency, conf.Latency, DefaultLatency)
+
+	var crypto *Crypto
+	switch {
+	case req.KMReq != nil && conf.Passphrase != "":
+		if crypto, err = ParseKM(req.KMReq, conf.Passphrase); err != nil {
+			return nil, nil, 0, RejectBadSecret
+		}
+	case req.KMReq != nil || conf.Passphrase != "":
+		return nil, nil, 0, RejectUnsecure
+	}
+
+	if conf.Accept != nil {
+		if reason := conf.Accept(req.StreamID); reason != 0 {
+			return nil, nil, 0, reason
+		}
+	}
+
+	rsp := &Handshake{
+		Version:    5,
+		Encryption: req.Encryption,
+		Extension:  flagHSReq,
+		ISN:        req.ISN,
+		MTU:        min(req.MTU, mtu),
+		Window:     min(req.Window, windowSize),
+		Type:       HandshakeConclusion,
+		HSRsp:      marshalHSExt(srtFlags, latency),
+		KMRsp:      req.KMReq,
+	}
+	if rsp.KMRsp != nil {
+		rsp.Extension |= flagKMReq
+	}
+
+	return rsp, crypto, latency, 0
+}
+
+func readLoop(pc net.PacketConn, conn *Conn) {
+	b := make([]byte, mtu)
+	for {
+		n, addr, err := pc.ReadFrom(b)
+		if err != nil {
+			conn.close(err)
+			return
+		}
+
+		if addr.String() != conn.raddr.String() {
+			continue
+		}
+
+		p, err := Unmarshal(b[:n])
+		if err != nil || (p.SocketID != conn.localID && p.SocketID != 0) {
+			continue
+		}
+
+		conn.handle(p)
+	}
+}
+
+func randUint32() uint32 {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return binary.BigEndian.Uint32(b) | 1
+}
@@ -0,0 +1,620 @@
+package srt
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLatency = 120 * time.Millisecond
+
+	tickInterval      = 10 * time.Millisecond
+	keepaliveInterval = time.Second
+	idleTimeout       = 5 * time.Second
+	minNAKInterval    = 20 * time.Millisecond
+
+	windowSize = 8192 // flow window and receive buffer in packets
+	queueSize  = 1024 // delivered messages for Read
+)
+
+var errTimeout = errors.New("srt: connection timeout")
+
+// Conn - SRT connection in live mode, each Write is split into messages up to 1316 bytes
+type Conn struct {
+	StreamID string
+
+	pc      net.PacketConn
+	raddr   net.Addr
+	localID uint32
+	peerID  uint32
+	latency time.Duration
+	start   time.Time
+
+	crypto     *Crypto
+	passphrase string
+
+	// handshake reply for retransmitted peer handshake
+	hsReply []byte
+
+	mu sync.Mutex
+
+	// sender
+	sendSeq  uint32
+	msgNo    uint32
+	sendBuf  []*sendPacket // ordered by seq
+	lastSend time.Time
+	lastACK  time.Time // last ACK with new packets or retransmit by timeout
+
+	// receiver
+	recvBuf  map[uint32]*recvPacket
+	recvNext uint32 // next seq for delivery
+	recvAck  uint32 // next seq after continuously received
+	recvLast uint32 // next seq after highest received
+	recvAny  bool
+	lost     map[uint32]time.Time // lost seq and time of the last NAK
+	lastRecv time.Time
+
+	ackNo    uint32
+	ackSent  uint32
+	ackTimes map[uint32]time.Time
+	rtt      time.Duration
+	rttVar   time.Duration
+
+	tsBase time.Time
+	tsLast uint32
+	tsWrap time.Duration
+
+	rate      int // received packets for the last second
+	rateSize  int
+	rateTime  time.Time
+	ratePkts  int
+	rateBytes int
+
+	queue chan []byte
+	buf   []byte
+
+	done    chan struct{}
+	err     error
+	onClose func()
+}
+
+type sendPacket struct {
+	seq  uint32
+	b    []byte
+	time time.Time // first send time
+	sent time.Time // last send or retransmit time
+}
+
+type recvPacket struct {
+	payload []byte
+	time    time.Time // delivery time
+}
+
+// retransmit - copy of the packet with retransmitted flag
+func (p *sendPacket) retransmit(now time.Time) []byte {
+	p.sent = now
+	b := append([]byte(nil), p.b...)
+	b[4] |= 0x04
+	return b
+}
+
+func newConn(pc net.PacketConn, raddr net.Addr, localID, peerID, sendISN, recvISN uint32, latency time.Duration) *Conn {
+	now := time.Now()
+	c := &Conn{
+		pc:       pc,
+		raddr:    raddr,
+		localID:  localID,
+		peerID:   peerID,
+		latency:  latency,
+		start:    now,
+		sendSeq:  sendISN,
+		msgNo:    1,
+		lastSend: now,
+		lastACK:  now,
+		recvBuf:  map[uint32]*recvPacket{},
+		recvNext: recvISN,
+		recvAck:  recvISN,
+		recvLast: recvISN,
+		lost:     map[uint32]time.Time{},
+		lastRecv: now,
+		ackNo:    1,
+		ackSent:  recvISN,
+		ackTimes: map[uint32]time.Time{},
+		rtt:      100 * time.Millisecond,
+		rttVar:   50 * time.Millisecond,
+		rateTime: now,
+		queue:    make(chan []byte, queueSize),
+		done:     make(chan struct{}),
+	}
+	go c.worker()
+	return c
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.pc.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+func (c *Conn) Latency() time.Duration {
+	return c.latency
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	if len(c.buf) == 0 {
+		select {
+		case c.buf = <-c.queue:
+		case <-c.done:
+			// read delivered messages before close
+			select {
+			case c.buf = <-c.queue:
+			default:
+				return 0, c.err
+			}
+		}
+	}
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	for n := 0; n < len(b); {
+		select {
+		case <-c.done:
+			return n, c.err
+		default:
+		}
+
+		size := min(len(b)-n, MaxPayloadSize)
+		payload := make([]byte, size)
+		copy(payload, b[n:])
+		n += size
+
+		c.mu.Lock()
+		p := &Packet{Seq: c.sendSeq, MsgNo: c.msgNo, Timestamp: c.timestamp(), SocketID: c.peerID}
+		if c.crypto != nil {
+			p.KeyFlag = keyEven
+			_ = c.crypto.XOR(payload, p.Seq, p.KeyFlag)
+		}
+		p.Payload = payload
+
+		raw := p.Marshal()
+		now := time.Now()
+		c.sendBuf = append(c.sendBuf, &sendPacket{seq: p.Seq, b: raw, time: now, sent: now})
+		c.sendSeq = seqNext(c.sendSeq)
+		c.msgNo = (c.msgNo + 1) & msgMask
+		c.lastSend = now
+		c.mu.Unlock()
+
+		if _, err := c.pc.WriteTo(raw, c.raddr); err != nil {
+			return n, err
+		}
+	}
+	return len(b), nil
+}
+
+func (c *Conn) Close() error {
+	select {
+	case <-c.done:
+	default:
+		c.sendControl(ControlShutdown, 0, 0, make([]byte, 4))
+	}
+	c.close(io.EOF)
+	return nil
+}
+
+func (c *Conn) close(err error) {
+	c.mu.Lock()
+	select {
+	case <-c.done:
+		c.mu.Unlock()
+		return
+	default:
+	}
+	c.err = err
+	close(c.done)
+	c.mu.Unlock()
+
+	if c.onClose != nil {
+		c.onClose()
+	}
+}
+
+// timestamp - microseconds from connection start
+func (c *Conn) timestamp() uint32 {
+	return uint32(time.Since(c.start).Microseconds())
+}
+
+func (c *Conn) sendControl(typ, subtype uint16, info uint32, payload []byte) {
+	p := &Packet{
+		Control: true, Type: typ, SubType: subtype, Info: info,
+		Timestamp: c.timestamp(), SocketID: c.peerID, Payload: payload,
+	}
+	_, _ = c.pc.WriteTo(p.Marshal(), c.raddr)
+}
+
+// handle - process packet from the peer
+func (c *Conn) handle(p *Packet) {
+	c.mu.Lock()
+	c.lastRecv = time.Now()
+	c.mu.Unlock()
+
+	if !p.Control {
+		c.handleData(p)
+		return
+	}
+
+	switch p.Type {
+	case ControlACK:
+		c.handleACK(p)
+	case ControlNAK:
+		c.handleNAK(p)
+	case ControlACKACK:
+		c.handleACKACK(p)
+	case ControlDropReq:
+		if len(p.Payload) >= 8 {
+			c.mu.Lock()
+			first := binary.BigEndian.Uint32(p.Payload) & seqMask
+			last := binary.BigEndian.Uint32(p.Payload[4:]) & seqMask
+			for seq := range c.lost {
+				if seqDiff(seq, first) >= 0 && seqDiff(last, seq) >= 0 {
+					delete(c.lost, seq)
+				}
+			}
+			c.mu.Unlock()
+		}
+	case ControlShutdown:
+		c.close(io.EOF)
+	case ControlHandshake:
+		if c.hsReply != nil {
+			_, _ = c.pc.WriteTo(c.hsReply, c.raddr)
+		}
+	case ControlUserDefine:
+		c.handleKM(p)
+	}
+}
+
+func (c *Conn) handleData(p *Packet) {
+	payload := make([]byte, len(p.Payload))
+	copy(payload, p.Payload)
+
+	if p.KeyFlag != 0 {
+		// key can be refreshed by the peer at any time
+		c.mu.Lock()
+		crypto := c.crypto
+		c.mu.Unlock()
+
+		if crypto == nil || crypto.XOR(payload, p.Seq, p.KeyFlag) != nil {
+			return
+		}
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.recvAny {
+		c.recvAny = true
+		c.tsBase = now.Add(-time.Duration(p.Timestamp) * time.Microsecond)
+		c.tsLast = p.Timestamp
+		// protection from wrong ISN in the handshake
+		if d := seqDiff(p.Seq, c.recvNext); d < 0 || d > windowSize {
+			c.recvNext, c.recvAck, c.recvLast, c.ackSent = p.Seq, p.Seq, p.Seq, p.Seq
+		}
+	}
+
+	d := seqDiff(p.Seq, c.recvNext)
+	if d < 0 || d > windowSize {
+		return // too late or duplicate
+	}
+	if _, ok := c.recvBuf[p.Seq]; ok {
+		return
+	}
+
+	c.ratePkts++
+	c.rateBytes += len(payload)
+
+	c.recvBuf[p.Seq] = &recvPacket{
+		payload: payload,
+		time:    c.tsBase.Add(c.extTimestamp(p.Timestamp) + c.latency),
+	}
+
+	if seqDiff(p.Seq, c.recvLast) >= 0 {
+		var lost []uint32
+		for seq := c.recvLast; seq != p.Seq; seq = seqNext(seq) {
+			lost = append(lost, seq)
+			c.lost[seq] = now
+		}
+		c.recvLast = seqNext(p.Seq)
+
+		if lost != nil {
+			c.sendControl(ControlNAK, 0, 0, encodeLoss(lost))
+		}
+	} else {
+		delete(c.lost, p.Seq)
+	}
+
+	c.updateAck()
+	c.deliver(now)
+}
+
+// extTimestamp - packet timestamp with 32-bit wraparound (about 71 minutes)
+func (c *Conn) extTimestamp(ts uint32) time.Duration {
+	const wrap = time.Duration(1<<32) * time.Microsecond
+
+	wrapped := c.tsWrap
+	if ts < c.tsLast && c.tsLast-ts > 1<<31 {
+		c.tsWrap += wrap // new wraparound
+		wrapped = c.tsWrap
+		c.tsLast = ts
+	} else if ts > c.tsLast && ts-c.tsLast > 1<<31 {
+		wrapped -= wrap // retransmitted packet before wraparound
+	} else if ts > c.tsLast {
+		c.tsLast = ts
+	}
+
+	return wrapped + time.Duration(ts)*time.Microsecond
+}
+
+func (c *Conn) updateAck() {
+	if seqDiff(c.recvAck, c.recvNext) < 0 {
+		c.recvAck = c.recvNext
+	}
+	for c.recvAck != c.recvLast {
+		if _, ok := c.recvBuf[c.recvAck]; !ok {
+			break
+		}
+		c.recvAck = seqNext(c.recvAck)
+	}
+}
+
+// deliver - send packets to the reader with latency (TSBPD), skip lost packets that are too late
+func (c *Conn) deliver(now time.Time) {
+	for {
+		if p := c.recvBuf[c.recvNext]; p != nil {
+			if now.Before(p.time) {
+				return
+			}
+
+			delete(c.recvBuf, c.recvNext)
+			c.recvNext = seqNext(c.recvNext)
+
+			select {
+			case c.queue <- p.payload:
+			default: // reader is too slow
+			}
+			continue
+		}
+
+		if len(c.recvBuf) == 0 {
+			return
+		}
+
+		// head packet is lost, drop it when the next packet should be delivered
+		var first uint32
+		var firstDiff int32 = -1
+		for seq := range c.recvBuf {
+			if d := seqDiff(seq, c.recvNext); firstDiff < 0 || d < firstDiff {
+				first, firstDiff = seq, d
+			}
+		}
+
+		if now.Before(c.recvBuf[first].time) {
+			return
+		}
+
+		for seq := c.recvNext; seq != first; seq = seqNext(seq) {
+			delete(c.lost, seq)
+		}
+		c.recvNext = first
+		c.updateAck()
+	}
+}
+
+func (c *Conn) handleACK(p *Packet) {
+	if len(p.Payload) < 4 {
+		return
+	}
+
+	seq := binary.BigEndian.Uint32(p.Payload) & seqMask
+
+	c.mu.Lock()
+	i := 0
+	for ; i < len(c.sendBuf) && seqDiff(c.sendBuf[i].seq, seq) < 0; i++ {
+	}
+	if i > 0 {
+		c.sendBuf = c.sendBuf[i:]
+		c.lastACK = time.Now()
+	}
+
+	if len(p.Payload) >= 12 {
+		if rtt := binary.BigEndian.Uint32(p.Payload[4:]); rtt > 0 {
+			c.rtt = time.Duration(rtt) * time.Microsecond
+			c.rttVar = time.Duration(binary.BigEndian.Uint32(p.Payload[8:])) * time.Microsecond
+		}
+	}
+	c.mu.Unlock()
+
+	// light ACK doesn't need ACKACK
+	if len(p.Payload) > 4 {
+		c.sendControl(ControlACKACK, 0, p.Info, make([]byte, 4))
+	}
+}
+
+func (c *Conn) handleACKACK(p *Packet) {
+	c.mu.Lock()
+	if sent, ok := c.ackTimes[p.Info]; ok {
+		delete(c.ackTimes, p.Info)
+
+		rtt := time.Since(sent)
+		diff := c.rtt - rtt
+		if diff < 0 {
+			diff = -diff
+		}
+		c.rttVar = (3*c.rttVar + diff) / 4
+		c.rtt = (7*c.rtt + rtt) / 8
+	}
+	c.mu.Unlock()
+}
+
+func (c *Conn) handleNAK(p *Packet) {
+	var resend [][]byte
+
+	c.mu.Lock()
+	if len(c.sendBuf) > 0 {
+		first := c.sendBuf[0].seq
+		now := time.Now()
+		for _, seq := range decodeLoss(p.Payload) {
+			i := int(seqDiff(seq, first))
+			if i < 0 || i >= len(c.sendBuf) {
+				continue
+			}
+			resend = append(resend, c.sendBuf[i].retransmit(now))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, b := range resend {
+		_, _ = c.pc.WriteTo(b, c.raddr)
+	}
+}
+
+// handleKM - key material refresh from the peer
+func (c *Conn) handleKM(p *Packet) {
+	if p.SubType != extKMReq || c.passphrase == "" {
+		return
+	}
+
+	crypto, err := ParseKM(p.Payload, c.passphrase)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.crypto = crypto
+	c.mu.Unlock()
+
+	c.sendControl(ControlUserDefine, extKMRsp, 0, p.Payload)
+}
+
+func (c *Conn) worker() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			if !c.tick(now) {
+				c.close(errTimeout)
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) tick(now time.Time) bool {
+	c.mu.Lock()
+
+	if now.Sub(c.lastRecv) > idleTimeout {
+		c.mu.Unlock()
+		return false
+	}
+
+	c.deliver(now)
+
+	if now.Sub(c.rateTime) >= time.Second {
+		c.rate, c.rateSize = c.ratePkts, c.rateBytes
+		c.ratePkts, c.rateBytes = 0, 0
+		c.rateTime = now
+	}
+
+	// full ACK every tick if something new was received
+	var ack []byte
+	var ackNo uint32
+	if c.recvAck != c.ackSent {
+		ackNo = c.ackNo
+		c.ackNo++
+		c.ackSent = c.recvAck
+		c.ackTimes[ackNo] = now
+		if len(c.ackTimes) > 100 {
+			clear(c.ackTimes)
+		}
+
+		ack = make([]byte, 28)
+		binary.BigEndian.PutUint32(ack, c.recvAck)
+		binary.BigEndian.PutUint32(ack[4:], uint32(c.rtt.Microseconds()))
+		binary.BigEndian.PutUint32(ack[8:], uint32(c.rttVar.Microseconds()))
+		binary.BigEndian.PutUint32(ack[12:], uint32(max(windowSize-len(c.recvBuf), 2)))
+		binary.BigEndian.PutUint32(ack[16:], uint32(c.rate))
+		binary.BigEndian.PutUint32(ack[20:], uint32(max(c.rate, 1000))) // estimated link capacity
+		binary.BigEndian.PutUint32(ack[24:], uint32(c.rateSize))
+	}
+
+	// periodic NAK for packets that are still lost
+	var lost []uint32
+	interval := max(c.rtt+4*c.rttVar, minNAKInterval)
+	for seq, sent := range c.lost {
+		if now.Sub(sent) >= interval {
+			lost = append(lost, seq)
+			c.lost[seq] = now
+		}
+	}
+
+	// drop packets that are too late for the receiver
+	i := 0
+	for ; i < len(c.sendBuf) && now.Sub(c.sendBuf[i].time) > c.latency+time.Second; i++ {
+	}
+	c.sendBuf = c.sendBuf[i:]
+
+	// losses are retransmitted by NAK, but receiver can't detect loss of the last packets,
+	// so retransmit packets without ACK only if ACK doesn't move forward for RTO
+	var resend [][]byte
+	rto := interval + 2*tickInterval
+	if now.Sub(c.lastACK) >= rto {
+		for _, p := range c.sendBuf {
+			if now.Sub(p.sent) >= rto {
+				resend = append(resend, p.retransmit(now))
+			}
+		}
+		c.lastACK = now // wait another RTO before next retransmit
+	}
+
+	keepalive := now.Sub(c.lastSend) >= keepaliveInterval
+	if keepalive {
+		c.lastSend = now
+	}
+
+	c.mu.Unlock()
+
+	if ack != nil {
+		c.sendControl(ControlACK, 0, ackNo, ack)
+	}
+
+	for _, b := range resend {
+		_, _ = c.pc.WriteTo(b, c.raddr)
+	}
+
+	if lost != nil {
+		sort.Slice(lost, func(i, j int) bool {
+			return seqDiff(lost[i], lost[j]) < 0
+		})
+		c.sendControl(ControlNAK, 0, 0, encodeLoss(lost))
+	}
+
+	if keepalive {
+		c.sendControl(ControlKeepalive, 0, 0, make([]byte, 4))
+	}
+
+	return true
+}
@@ -0,0 +1,229 @@
+package srt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	keyEven = 1
+	keyOdd  = 2
+
+	saltSize = 16
+	kmHeader = 16 // KM message header size before salt
+)
+
+var errBadSecret = errors.New("srt: wrong passphrase")
+
+// Crypto - AES-CTR encryption with even and odd stream encrypting keys (SEK)
+type Crypto struct {
+	salt   []byte
+	keyLen int
+	blocks [2]cipher.Block // even and odd keys
+	keys   [2][]byte
+}
+
+// NewCrypto - new random salt and even key
+func NewCrypto(keyLen int) (*Crypto, error) {
+	switch keyLen {
+	case 0:
+		keyLen = 16
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("srt: wrong key length")
+	}
+
+	c := &Crypto{salt: make([]byte, saltSize), keyLen: keyLen}
+	key := make([]byte, keyLen)
+	if _, err := rand.Read(c.salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := c.setKey(0, key); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Crypto) setKey(i int, key []byte) (err error) {
+	c.keys[i] = key
+	c.blocks[i], err = aes.NewCipher(key)
+	return
+}
+
+// XOR - encrypt or decrypt payload, IV depends on salt and packet sequence number
+func (c *Crypto) XOR(payload []byte, seq uint32, keyFlag byte) error {
+	block := c.blocks[0]
+	if keyFlag == keyOdd {
+		block = c.blocks[1]
+	}
+	if block == nil {
+		return errors.New("srt: no key for packet")
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint32(iv[10:], seq)
+	subtle.XORBytes(iv[:14], iv[:14], c.salt[:14])
+
+	cipher.NewCTR(block, iv).XORKeyStream(payload, payload)
+	return nil
+}
+
+// MarshalKM - key material message with keys wrapped by passphrase
+func (c *Crypto) MarshalKM(passphrase string) ([]byte, error) {
+	kek, err := c.kek(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var kk byte
+	var keys []byte
+	if c.keys[0] != nil {
+		kk |= keyEven
+		keys = append(keys, c.keys[0]...)
+	}
+	if c.keys[1] != nil {
+		kk |= keyOdd
+		keys = append(keys, c.keys[1]...)
+	}
+
+	wrapped, err := keyWrap(kek, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, kmHeader, kmHeader+saltSize+len(wrapped))
+	b[0] = 0x12 // S=0, V=1, PT=2 (KM)
+	b[1], b[2] = 0x20, 0x29
+	b[3] = kk
+	b[8] = 2  // AES-CTR
+	b[10] = 2 // stream encapsulation: MPEG-TS/SRT
+	b[14] = saltSize / 4
+	b[15] = byte(c.keyLen / 4)
+	b = append(b, c.salt...)
+	return append(b, wrapped...), nil
+}
+
+// ParseKM - unwrap keys from key material message with passphrase
+func ParseKM(b []byte, passphrase string) (*Crypto, error) {
+	if len(b) >= 4 && b[2] == 0x20 && b[1] == 0x29 {
+		b = swapWords(b) // some versions send KM as host order words
+	}
+	if len(b) < kmHeader+saltSize || b[0] != 0x12 || b[1] != 0x20 || b[2] != 0x29 {
+		return nil, errors.New("srt: wrong key material")
+	}
+
+	kk := b[3] & 0b11
+	saltLen := int(b[14]) * 4
+	keyLen := int(b[15]) * 4
+	if b[8] != 2 || saltLen != saltSize || kk == 0 {
+		return nil, errors.New("srt: unsupported key material")
+	}
+
+	c := &Crypto{salt: append([]byte(nil), b[kmHeader:kmHeader+saltSize]...), keyLen: keyLen}
+
+	kek, err := c.kek(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := keyUnwrap(kek, b[kmHeader+saltSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, flag := range []byte{keyEven, keyOdd} {
+		if kk&flag == 0 {
+			continue
+		}
+		if len(keys) < keyLen {
+			return nil, errors.New("srt: wrong key material")
+		}
+		if err = c.setKey(i, keys[:keyLen]); err != nil {
+			return nil, err
+		}
+		keys = keys[keyLen:]
+	}
+
+	return c, nil
+}
+
+// kek - key encrypting key from passphrase and last 8 bytes of salt
+func (c *Crypto) kek(passphrase string) ([]byte, error) {
+	if len(passphrase) < 10 || len(passphrase) > 79 {
+		return nil, errors.New("srt: passphrase should be 10-79 chars")
+	}
+	return pbkdf2.Key(sha1.New, passphrase, c.salt[8:], 2048, c.keyLen)
+}
+
+// keyWrap - AES key wrap (RFC 3394)
+func keyWrap(kek, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(plain) / 8
+	b := make([]byte, 8+len(plain))
+	copy(b, defaultIV)
+	copy(b[8:], plain)
+
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, b[:8])
+			copy(buf[8:], b[i*8:])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(buf)^t)
+			copy(b[i*8:], buf[8:])
+		}
+	}
+
+	return b, nil
+}
+
+func keyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("srt: wrong wrapped key")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	b := make([]byte, len(wrapped))
+	copy(b, wrapped)
+
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf, binary.BigEndian.Uint64(b)^t)
+			copy(buf[8:], b[i*8:i*8+8])
+			block.Decrypt(buf, buf)
+
+			copy(b, buf[:8])
+			copy(b[i*8:], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(b[:8], defaultIV) != 1 {
+		return nil, errBadSecret
+	}
+
+	return b[8:], nil
+}
+
+var defaultIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
@@ -0,0 +1,185 @@
+package srt
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	HandshakeDone       = 0xFFFFFFFD
+	HandshakeAgreement  = 0xFFFFFFFE
+	HandshakeConclusion = 0xFFFFFFFF
+	HandshakeWaveahand  = 0x00000000
+	HandshakeInduction  = 0x00000001
+
+	// HandshakeReject - rejection reason is added to this value
+	HandshakeReject = 1000
+)
+
+const (
+	RejectPeer      = 2
+	RejectBadSecret = 10
+	RejectUnsecure  = 11
+	RejectNotFound  = 1404 // user defined, same as HTTP 404
+)
+
+const (
+	extHSReq  = 1
+	extHSRsp  = 2
+	extKMReq  = 3
+	extKMRsp  = 4
+	extSID    = 5
+	flagHSReq = 0x1
+	flagKMReq = 0x2
+	flagCfg   = 0x4
+
+	srtMagic   = 0x4A17
+	srtVersion = 0x00010503 // 1.5.3
+
+	// TSBPDSND | TSBPDRCV | CRYPT | TLPKTDROP | PERIODICNAK | REXMITFLG
+	srtFlags = 0x3F
+
+	handshakeSize = 48
+)
+
+type Handshake struct {
+	Version    uint32
+	Encryption uint16 // key length / 8 for v5
+	Extension  uint16 // socket type for v4, magic or flags for v5
+	ISN        uint32 // initial sequence number
+	MTU        uint32
+	Window     uint32
+	Type       uint32
+	SocketID   uint32
+	Cookie     uint32
+	PeerIP     [16]byte
+
+	// extensions
+	HSReq    []byte
+	HSRsp    []byte
+	KMReq    []byte
+	KMRsp    []byte
+	StreamID string
+}
+
+func (h *Handshake) Marshal() []byte {
+	b := make([]byte, handshakeSize, 128)
+	binary.BigEndian.PutUint32(b, h.Version)
+	binary.BigEndian.PutUint16(b[4:], h.Encryption)
+	binary.BigEndian.PutUint16(b[6:], h.Extension)
+	binary.BigEndian.PutUint32(b[8:], h.ISN)
+	binary.BigEndian.PutUint32(b[12:], h.MTU)
+	binary.BigEndian.PutUint32(b[16:], h.Window)
+	binary.BigEndian.PutUint32(b[20:], h.Type)
+	binary.BigEndian.PutUint32(b[24:], h.SocketID)
+	binary.BigEndian.PutUint32(b[28:], h.Cookie)
+	copy(b[32:], h.PeerIP[:])
+
+	b = appendExt(b, extHSReq, h.HSReq)
+	b = appendExt(b, extHSRsp, h.HSRsp)
+	b = appendExt(b, extKMReq, h.KMReq)
+	b = appendExt(b, extKMRsp, h.KMRsp)
+	if h.StreamID != "" {
+		b = appendExt(b, extSID, swapWords([]byte(h.StreamID)))
+	}
+	return b
+}
+
+func (h *Handshake) Unmarshal(b []byte) error {
+	if len(b) < handshakeSize {
+		return errors.New("srt: wrong handshake size")
+	}
+
+	h.Version = binary.BigEndian.Uint32(b)
+	h.Encryption = binary.BigEndian.Uint16(b[4:])
+	h.Extension = binary.BigEndian.Uint16(b[6:])
+	h.ISN = binary.BigEndian.Uint32(b[8:])
+	h.MTU = binary.BigEndian.Uint32(b[12:])
+	h.Window = binary.BigEndian.Uint32(b[16:])
+	h.Type = binary.BigEndian.Uint32(b[20:])
+	h.SocketID = binary.BigEndian.Uint32(b[24:])
+	h.Cookie = binary.BigEndian.Uint32(b[28:])
+	copy(h.PeerIP[:], b[32:])
+
+	if h.Version < 5 || h.Type == HandshakeInduction {
+		return nil
+	}
+
+	for b = b[handshakeSize:]; len(b) >= 4; {
+		typ := binary.BigEndian.Uint16(b)
+		size := 4 * int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+size {
+			return errors.New("srt: wrong handshake extension")
+		}
+
+		data := b[4 : 4+size]
+		b = b[4+size:]
+
+		switch typ {
+		case extHSReq:
+			h.HSReq = data
+		case extHSRsp:
+			h.HSRsp = data
+		case extKMReq:
+			h.KMReq = data
+		case extKMRsp:
+			h.KMRsp = data
+		case extSID:
+			sid := swapWords(data)
+			for len(sid) > 0 && sid[len(sid)-1] == 0 {
+				sid = sid[:len(sid)-1]
+			}
+			h.StreamID = string(sid)
+		}
+	}
+
+	return nil
+}
+
+// IsReject - handshake with rejection reason instead of handshake type
+func (h *Handshake) IsReject() bool {
+	return h.Type >= HandshakeReject && h.Type < HandshakeDone
+}
+
+func appendExt(b []byte, typ uint16, data []byte) []byte {
+	if data == nil {
+		return b
+	}
+	size := (len(data) + 3) / 4
+	b = binary.BigEndian.AppendUint16(b, typ)
+	b = binary.BigEndian.AppendUint16(b, uint16(size))
+	b = append(b, data...)
+	return append(b, make([]byte, size*4-len(data))...)
+}
+
+// swapWords - reverse bytes in each 32-bit word, libsrt sends strings in this order
+func swapWords(src []byte) []byte {
+	dst := make([]byte, (len(src)+3)/4*4)
+	copy(dst, src)
+	for i := 0; i < len(dst); i += 4 {
+		dst[i], dst[i+1], dst[i+2], dst[i+3] = dst[i+3], dst[i+2], dst[i+1], dst[i]
+	}
+	return dst
+}
+
+// marshalHSExt - HSREQ and HSRSP content: version, flags, receiver and sender latency
+func marshalHSExt(flags uint32, latency time.Duration) []byte {
+	ms := uint32(latency.Milliseconds())
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b, srtVersion)
+	binary.BigEndian.PutUint32(b[4:], flags)
+	binary.BigEndian.PutUint32(b[8:], ms<<16|ms)
+	return b
+}
+
+// parseHSExt - returns max latency from peer receiver and sender latencies
+func parseHSExt(b []byte) (flags uint32, latency time.Duration, err error) {
+	if len(b) < 12 {
+		return 0, 0, errors.New("srt: wrong handshake request")
+	}
+	flags = binary.BigEndian.Uint32(b[4:])
+	recv := binary.BigEndian.Uint16(b[8:])
+	send := binary.BigEndian.Uint16(b[10:])
+	return flags, time.Duration(max(recv, send)) * time.Millisecond, nil
+}
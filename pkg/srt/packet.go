@@ -0,0 +1,152 @@
+package srt
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// https://datatracker.ietf.org/doc/html/draft-sharabayko-srt
+
+const (
+	ControlHandshake  = 0x0000
+	ControlKeepalive  = 0x0001
+	ControlACK        = 0x0002
+	ControlNAK        = 0x0003
+	ControlShutdown   = 0x0005
+	ControlACKACK     = 0x0006
+	ControlDropReq    = 0x0007
+	ControlPeerError  = 0x0008
+	ControlUserDefine = 0x7FFF
+)
+
+const (
+	headerSize = 16
+
+	// MaxPayloadSize - 7 MPEG-TS packets, default for live mode
+	MaxPayloadSize = 1316
+
+	seqMask = 0x7FFFFFFF
+	msgMask = 0x03FFFFFF
+)
+
+// Packet - data or control packet
+type Packet struct {
+	Control bool
+
+	// data packet
+	Seq       uint32
+	MsgNo     uint32
+	KeyFlag   byte // 0 - no encryption, 1 - even key, 2 - odd key
+	Retrans   bool
+	Timestamp uint32
+	SocketID  uint32 // destination socket ID
+
+	// control packet
+	Type    uint16
+	SubType uint16
+	Info    uint32 // type-specific information
+
+	Payload []byte // data or control information field
+}
+
+func (p *Packet) Marshal() []byte {
+	b := make([]byte, headerSize+len(p.Payload))
+
+	if p.Control {
+		binary.BigEndian.PutUint32(b, 0x80000000|uint32(p.Type)<<16|uint32(p.SubType))
+		binary.BigEndian.PutUint32(b[4:], p.Info)
+	} else {
+		binary.BigEndian.PutUint32(b, p.Seq&seqMask)
+
+		// PP=11 - solo packet, O=0 - no order, KK, R
+		word := 0b11<<30 | uint32(p.KeyFlag&0b11)<<27 | p.MsgNo&msgMask
+		if p.Retrans {
+			word |= 1 << 26
+		}
+		binary.BigEndian.PutUint32(b[4:], word)
+	}
+
+	binary.BigEndian.PutUint32(b[8:], p.Timestamp)
+	binary.BigEndian.PutUint32(b[12:], p.SocketID)
+	copy(b[headerSize:], p.Payload)
+	return b
+}
+
+func Unmarshal(b []byte) (*Packet, error) {
+	if len(b) < headerSize {
+		return nil, errors.New("srt: packet too short")
+	}
+
+	p := &Packet{
+		Timestamp: binary.BigEndian.Uint32(b[8:]),
+		SocketID:  binary.BigEndian.Uint32(b[12:]),
+		Payload:   b[headerSize:],
+	}
+
+	word := binary.BigEndian.Uint32(b)
+	if p.Control = word&0x80000000 != 0; p.Control {
+		p.Type = uint16(word>>16) & 0x7FFF
+		p.SubType = uint16(word)
+		p.Info = binary.BigEndian.Uint32(b[4:])
+	} else {
+		p.Seq = word & seqMask
+		word = binary.BigEndian.Uint32(b[4:])
+		p.KeyFlag = byte(word>>27) & 0b11
+		p.Retrans = word&(1<<26) != 0
+		p.MsgNo = word & msgMask
+	}
+
+	return p, nil
+}
+
+// seqDiff - distance between 31-bit sequence numbers with wraparound
+func seqDiff(a, b uint32) int32 {
+	return int32((a-b)<<1) >> 1
+}
+
+func seqNext(seq uint32) uint32 {
+	return (seq + 1) & seqMask
+}
+
+// encodeLoss - NAK list, range is encoded as first number with high bit and last number
+func encodeLoss(lost []uint32) []byte {
+	var b []byte
+	for i := 0; i < len(lost); {
+		j := i
+		for j+1 < len(lost) && lost[j+1] == seqNext(lost[j]) {
+			j++
+		}
+		if i == j {
+			b = binary.BigEndian.AppendUint32(b, lost[i])
+		} else {
+			b = binary.BigEndian.AppendUint32(b, lost[i]|0x80000000)
+			b = binary.BigEndian.AppendUint32(b, lost[j])
+		}
+		i = j + 1
+	}
+	return b
+}
+
+func decodeLoss(b []byte) (lost []uint32) {
+	for len(b) >= 4 {
+		seq := binary.BigEndian.Uint32(b)
+		b = b[4:]
+
+		if seq&0x80000000 == 0 {
+			lost = append(lost, seq)
+			continue
+		}
+
+		if len(b) < 4 {
+			break
+		}
+		last := binary.BigEndian.Uint32(b)
+		b = b[4:]
+
+		// protection from broken ranges
+		for seq &= seqMask; seqDiff(last, seq) >= 0 && len(lost) < 0x10000; seq = seqNext(seq) {
+			lost = append(lost, seq)
+		}
+	}
+	return
+}
@@ -0,0 +1,251 @@
+package srt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Listener struct {
+	pc     *net.UDPConn
+	conf   *Config
+	secret []byte // for SYN cookies
+
+	mu    sync.Mutex
+	conns map[uint32]*Conn // by local socket ID
+
+	accept chan *Conn
+	done   chan struct{}
+
+	// single - accept only one connection and close listener with it
+	single bool
+}
+
+func Listen(address string, conf *Config) (*Listener, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf == nil {
+		conf = &Config{}
+	}
+
+	l := &Listener{
+		pc:     pc,
+		conf:   conf,
+		secret: make([]byte, 16),
+		conns:  map[uint32]*Conn{},
+		accept: make(chan *Conn, 8),
+		done:   make(chan struct{}),
+	}
+	_, _ = rand.Read(l.secret)
+
+	go l.serve()
+
+	return l, nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return l.pc.LocalAddr()
+}
+
+func (l *Listener) Accept() (*Conn, error) {
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *Listener) acceptTimeout(timeout time.Duration) (*Conn, error) {
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-time.After(timeout):
+		return nil, errors.New("srt: wait for caller timeout")
+	}
+}
+
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	select {
+	case <-l.done:
+		l.mu.Unlock()
+		return nil
+	default:
+	}
+	close(l.done)
+
+	conns := make([]*Conn, 0, len(l.conns))
+	for _, conn := range l.conns {
+		conns = append(conns, conn)
+	}
+	l.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+
+	return l.pc.Close()
+}
+
+func (l *Listener) serve() {
+	b := make([]byte, mtu)
+	for {
+		n, addr, err := l.pc.ReadFromUDP(b)
+		if err != nil {
+			_ = l.Close()
+			return
+		}
+
+		p, err := Unmarshal(b[:n])
+		if err != nil {
+			continue
+		}
+
+		if p.SocketID == 0 {
+			if p.Control && p.Type == ControlHandshake {
+				l.handshake(p, addr)
+			}
+			continue
+		}
+
+		l.mu.Lock()
+		conn := l.conns[p.SocketID]
+		l.mu.Unlock()
+
+		if conn != nil && conn.raddr.String() == addr.String() {
+			conn.handle(p)
+		}
+	}
+}
+
+func (l *Listener) handshake(p *Packet, addr *net.UDPAddr) {
+	req := &Handshake{}
+	if err := req.Unmarshal(p.Payload); err != nil {
+		return
+	}
+
+	switch req.Type {
+	case HandshakeInduction:
+		res := &Handshake{
+			Version:   5,
+			Extension: srtMagic,
+			ISN:       req.ISN,
+			MTU:       req.MTU,
+			Window:    req.Window,
+			Type:      HandshakeInduction,
+			SocketID:  req.SocketID,
+			Cookie:    l.cookie(addr, 0),
+		}
+		l.write(res, req.SocketID, addr)
+
+	case HandshakeConclusion:
+		if req.Cookie != l.cookie(addr, 0) && req.Cookie != l.cookie(addr, -1) {
+			return
+		}
+
+		// retransmitted conclusion for an established connection
+		if conn := l.find(addr, req.SocketID); conn != nil {
+			conn.handle(p)
+			return
+		}
+
+		l.mu.Lock()
+		busy := l.single && len(l.conns) > 0
+		l.mu.Unlock()
+
+		if busy {
+			l.reject(req, RejectPeer, addr)
+			return
+		}
+
+		rsp, crypto, latency, reason := acceptHandshake(l.conf, req)
+		if reason != 0 {
+			l.reject(req, reason, addr)
+			return
+		}
+
+		localID := randUint32()
+		rsp.SocketID = localID
+
+		conn := newConn(l.pc, addr, localID, req.SocketID, req.ISN, req.ISN, latency)
+		conn.StreamID = req.StreamID
+		conn.crypto = crypto
+		conn.passphrase = l.conf.Passphrase
+		conn.hsReply = (&Packet{Control: true, Type: ControlHandshake, SocketID: req.SocketID, Payload: rsp.Marshal()}).Marshal()
+		conn.onClose = func() {
+			l.mu.Lock()
+			delete(l.conns, localID)
+			l.mu.Unlock()
+
+			if l.single {
+				_ = l.Close()
+			}
+		}
+
+		l.mu.Lock()
+		l.conns[localID] = conn
+		l.mu.Unlock()
+
+		_, _ = l.pc.WriteToUDP(conn.hsReply, addr)
+
+		select {
+		case l.accept <- conn:
+		default:
+			_ = conn.Close() // nobody accepts connections
+		}
+	}
+}
+
+func (l *Listener) find(addr *net.UDPAddr, peerID uint32) *Conn {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		if conn.peerID == peerID && conn.raddr.String() == addr.String() {
+			return conn
+		}
+	}
+	return nil
+}
+
+func (l *Listener) reject(req *Handshake, reason int, addr *net.UDPAddr) {
+	res := &Handshake{
+		Version:  5,
+		ISN:      req.ISN,
+		MTU:      req.MTU,
+		Window:   req.Window,
+		Type:     HandshakeReject + uint32(reason),
+		SocketID: req.SocketID,
+		Cookie:   req.Cookie,
+	}
+	l.write(res, req.SocketID, addr)
+}
+
+func (l *Listener) write(hs *Handshake, peerID uint32, addr *net.UDPAddr) {
+	p := &Packet{Control: true, Type: ControlHandshake, SocketID: peerID, Payload: hs.Marshal()}
+	_, _ = l.pc.WriteToUDP(p.Marshal(), addr)
+}
+
+// cookie - SYN cookie from remote address and current minute, offset -1 for previous minute
+func (l *Listener) cookie(addr *net.UDPAddr, offset int64) uint32 {
+	minute := time.Now().Unix()/60 + offset
+	h := hmac.New(sha256.New, l.secret)
+	h.Write([]byte(addr.String() + "/" + strconv.FormatInt(minute, 10)))
+	return binary.BigEndian.Uint32(h.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package srt
+
+import (
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyWrap(t *testing.T) {
+	// RFC 3394 4.1 Wrap 128 bits of Key Data with a 128-bit KEK
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+
+	wrapped, err := keyWrap(kek, key)
+	require.Nil(t, err)
+	require.Equal(t, "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5", hex.EncodeToString(wrapped))
+
+	unwrapped, err := keyUnwrap(kek, wrapped)
+	require.Nil(t, err)
+	require.Equal(t, key, unwrapped)
+
+	kek[0] ^= 1
+	_, err = keyUnwrap(kek, wrapped)
+	require.Equal(t, errBadSecret, err)
+}
+
+func TestKeyMaterial(t *testing.T) {
+	c1, err := NewCrypto(32)
+	require.Nil(t, err)
+
+	km, err := c1.MarshalKM("0123456789")
+	require.Nil(t, err)
+
+	c2, err := ParseKM(km, "0123456789")
+	require.Nil(t, err)
+	require.Equal(t, c1.keys[0], c2.keys[0])
+
+	b := []byte("hello world")
+	require.Nil(t, c1.XOR(b, 123, keyEven))
+	require.NotEqual(t, "hello world", string(b))
+	require.Nil(t, c2.XOR(b, 123, keyEven))
+	require.Equal(t, "hello world", string(b))
+
+	_, err = ParseKM(km, "9876543210")
+	require.Equal(t, errBadSecret, err)
+}
+
+func TestHandshake(t *testing.T) {
+	hs := &Handshake{
+		Version:   5,
+		Extension: flagHSReq | flagCfg,
+		ISN:       12345,
+		Type:      HandshakeConclusion,
+		SocketID:  1,
+		Cookie:    2,
+		HSReq:     marshalHSExt(srtFlags, 200*time.Millisecond),
+		StreamID:  "#!::r=camera1,m=publish",
+	}
+
+	var hs2 Handshake
+	require.Nil(t, hs2.Unmarshal(hs.Marshal()))
+	require.Equal(t, hs.StreamID, hs2.StreamID)
+	require.Equal(t, hs.HSReq, hs2.HSReq)
+
+	_, latency, err := parseHSExt(hs2.HSReq)
+	require.Nil(t, err)
+	require.Equal(t, 200*time.Millisecond, latency)
+
+	// libsrt reverses bytes in each word of the stream ID
+	require.Equal(t, "::!#", string(hs.Marshal()[handshakeSize+20:handshakeSize+24]))
+
+	hs2.Type = HandshakeReject + RejectBadSecret
+	require.True(t, hs2.IsReject())
+}
+
+func TestLoss(t *testing.T) {
+	lost := []uint32{1, 2, 3, 5, seqMask, 0}
+	b := encodeLoss(lost)
+	require.Len(t, b, 20)
+	require.Equal(t, lost, decodeLoss(b))
+	require.Equal(t, int32(2), seqDiff(1, seqMask))
+}
+
+func TestConnect(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0", &Config{
+		Passphrase: "0123456789",
+		Accept: func(streamID string) int {
+			if streamID != "camera1" {
+				return RejectNotFound
+			}
+			return 0
+		},
+	})
+	require.Nil(t, err)
+	defer ln.Close()
+
+	addr := ln.Addr().String()
+
+	_, err = Dial("srt://" + addr + "?streamid=camera1&passphrase=9876543210")
+	require.NotNil(t, err)
+
+	_, err = Dial("srt://" + addr + "?streamid=camera2&passphrase=0123456789")
+	require.NotNil(t, err)
+
+	client, err := Dial("srt://" + addr + "?streamid=camera1&passphrase=0123456789&latency=50")
+	require.Nil(t, err)
+
+	server, err := ln.Accept()
+	require.Nil(t, err)
+	require.Equal(t, "camera1", server.StreamID)
+	require.Equal(t, DefaultLatency, server.Latency())
+
+	data := make([]byte, 100*MaxPayloadSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	go func() {
+		_, _ = client.Write(data)
+		time.Sleep(time.Second)
+		_ = client.Close()
+	}()
+
+	b, err := io.ReadAll(server)
+	require.Nil(t, err)
+	require.Equal(t, len(data), len(b))
+	require.Equal(t, data, b)
+}
+
+func TestRendezvous(t *testing.T) {
+	type result struct {
+		conn *Conn
+		err  error
+	}
+
+	ch := make(chan result)
+	go func() {
+		conn, err := Dial("srt://127.0.0.1:18921?mode=rendezvous&localport=18920&passphrase=0123456789")
+		ch <- result{conn, err}
+	}()
+
+	conn1, err := Dial("srt://127.0.0.1:18920?mode=rendezvous&localport=18921&passphrase=0123456789")
+	require.Nil(t, err)
+
+	res := <-ch
+	require.Nil(t, res.err)
+	conn2 := res.conn
+
+	_, err = conn1.Write([]byte("hello"))
+	require.Nil(t, err)
+
+	b := make([]byte, 100)
+	n, err := conn2.Read(b)
+	require.Nil(t, err)
+	require.Equal(t, "hello", string(b[:n]))
+
+	_ = conn1.Close()
+	_, err = conn2.Read(b)
+	require.Equal(t, io.EOF, err)
+}
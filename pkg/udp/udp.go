@@ -0,0 +1,212 @@
+package udp
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/rtp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// PacketSize - 7 MPEG-TS packets, default for IPTV
+	PacketSize = 7 * 188
+
+	// PayloadTypeMP2T - static RTP payload type for MPEG-TS (RFC 2250)
+	PayloadTypeMP2T = 33
+
+	readBufferSize = 2 * 1024 * 1024
+)
+
+// Conn - MPEG-TS over plain UDP or RTP, unicast or multicast
+type Conn struct {
+	conn  *net.UDPConn
+	raddr *net.UDPAddr
+
+	rtp  bool
+	seq  uint16
+	ssrc uint32
+
+	recv []byte
+	buf  []byte
+}
+
+// Listen - udp://@239.0.0.1:1234?iface=eth0 or udp://:1234 for unicast
+func Listen(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn *net.UDPConn
+
+	if addr.IP.IsMulticast() {
+		ifi, err := parseInterface(u.Query().Get("iface"))
+		if err != nil {
+			return nil, err
+		}
+		if conn, err = net.ListenMulticastUDP("udp4", ifi, addr); err != nil {
+			return nil, err
+		}
+	} else {
+		if conn, err = net.ListenUDP("udp4", addr); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = conn.SetReadBuffer(readBufferSize)
+
+	return &Conn{conn: conn, rtp: u.Scheme == "rtp", recv: make([]byte, 0xFFFF)}, nil
+}
+
+// Dial - udp://239.0.0.1:1234?ttl=4&iface=eth0 or rtp://192.168.1.123:5004
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp4", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	pc := ipv4.NewPacketConn(conn)
+
+	if raddr.IP.IsMulticast() {
+		if s := query.Get("ttl"); s != "" {
+			err = pc.SetMulticastTTL(core.Atoi(s))
+		}
+		if s := query.Get("iface"); s != "" && err == nil {
+			var ifi *net.Interface
+			if ifi, err = parseInterface(s); err == nil {
+				err = pc.SetMulticastInterface(ifi)
+			}
+		}
+	} else if s := query.Get("ttl"); s != "" {
+		err = pc.SetTTL(core.Atoi(s))
+	}
+
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: conn, raddr: raddr, rtp: u.Scheme == "rtp", ssrc: rand.Uint32()}, nil
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	for len(c.buf) == 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(core.ConnDeadline))
+
+		n, err := c.conn.Read(c.recv)
+		if err != nil {
+			return 0, err
+		}
+
+		c.buf = c.recv[:n]
+
+		// RTP packet with MPEG-TS inside, also autodetect for udp scheme
+		if c.rtp || (n > 12 && c.buf[0] != 0x47 && c.buf[0]&0xC0 == 0x80) {
+			var pkt rtp.Packet
+			if err = pkt.Unmarshal(c.buf); err != nil {
+				c.buf = nil
+				continue
+			}
+			c.buf = pkt.Payload
+		}
+	}
+
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// Write - send data in packets of 7 MPEG-TS packets
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.raddr == nil {
+		return 0, errors.New("udp: conn is not for output")
+	}
+
+	for n := 0; n < len(b); {
+		size := min(len(b)-n, PacketSize)
+		payload := b[n : n+size]
+		n += size
+
+		if c.rtp {
+			pkt := rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    PayloadTypeMP2T,
+					SequenceNumber: c.seq,
+					Timestamp:      core.Now90000(),
+					SSRC:           c.ssrc,
+				},
+				Payload: payload,
+			}
+			c.seq++
+
+			var err error
+			if payload, err = pkt.Marshal(); err != nil {
+				return n, err
+			}
+		}
+
+		if _, err := c.conn.WriteToUDP(payload, c.raddr); err != nil {
+			return n, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// parseInterface - interface by name or by IP address, nil for default
+func parseInterface(s string) (*net.Interface, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return net.InterfaceByName(s)
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range ifaces {
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &iface, nil
+			}
+		}
+	}
+
+	return nil, errors.New("udp: can't find interface: " + s)
+}
@@ -0,0 +1,55 @@
+package udp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDP(t *testing.T) {
+	for _, scheme := range []string{"udp", "rtp"} {
+		rd, err := Listen(scheme + "://127.0.0.1:0")
+		require.Nil(t, err)
+
+		wr, err := Dial(scheme + "://" + rd.LocalAddr().String() + "?ttl=4")
+		require.Nil(t, err)
+
+		// 10 MPEG-TS packets will be split to 7 + 3
+		data := bytes.Repeat([]byte{0x47, 1, 2, 3}, 10*188/4)
+		n, err := wr.Write(data)
+		require.Nil(t, err)
+		require.Equal(t, len(data), n)
+
+		b := make([]byte, 2000)
+		n, err = rd.Read(b)
+		require.Nil(t, err)
+		require.Equal(t, data[:PacketSize], b[:n])
+
+		n, err = rd.Read(b)
+		require.Nil(t, err)
+		require.Equal(t, data[PacketSize:], b[:n])
+
+		_ = wr.Close()
+		_ = rd.Close()
+	}
+}
+
+func TestAutodetectRTP(t *testing.T) {
+	rd, err := Listen("udp://127.0.0.1:0")
+	require.Nil(t, err)
+	defer rd.Close()
+
+	wr, err := Dial("rtp://" + rd.LocalAddr().String())
+	require.Nil(t, err)
+	defer wr.Close()
+
+	data := bytes.Repeat([]byte{0x47}, 188)
+	_, err = wr.Write(data)
+	require.Nil(t, err)
+
+	b := make([]byte, 2000)
+	n, err := rd.Read(b)
+	require.Nil(t, err)
+	require.Equal(t, data, b[:n])
+}
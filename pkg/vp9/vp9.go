@@ -0,0 +1,85 @@
+// Package vp9 - VP9 frames related functions
+package vp9
+
+import (
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+// Profile - profile from uncompressed frame header
+func Profile(b []byte) byte {
+	// frame_marker (2), profile_low_bit (1), profile_high_bit (1)
+	return (b[0]>>5)&1 | (b[0]>>3)&0b10
+}
+
+// IsKeyframe - check frame_type in uncompressed frame header
+func IsKeyframe(b []byte) bool {
+	if len(b) == 0 || b[0]>>6 != 2 {
+		return false // wrong frame_marker
+	}
+
+	i := byte(4) // bit position after frame_marker and profile bits
+	if Profile(b) == 3 {
+		i++ // reserved_zero
+	}
+
+	showExisting := b[0] & (0x80 >> i)
+	frameType := b[0] & (0x80 >> (i + 1))
+
+	return showExisting == 0 && frameType == 0
+}
+
+// EncodeConfig - VPCodecConfigurationRecord (vpcC) with version and flags from keyframe,
+// https://www.webmproject.org/vp9/mp4/#vp-codec-configuration-box
+func EncodeConfig(keyframe []byte) []byte {
+	profile := Profile(keyframe)
+
+	var bitDepth byte = 8
+	if profile >= 2 {
+		// frame header (1 byte), frame_sync_code (3 bytes), ten_or_twelve_bit (1 bit)
+		if len(keyframe) > 4 && keyframe[4]&0x80 != 0 {
+			bitDepth = 12
+		} else {
+			bitDepth = 10
+		}
+	}
+
+	return []byte{
+		1, 0, 0, 0, // version 1, flags
+		profile,
+		0,                  // level (undefined)
+		bitDepth<<4 | 1<<1, // bit depth, 4:2:0 colocated with luma, limited range
+		2, 2, 2,            // colour primaries, transfer characteristics, matrix (unspecified)
+		0, 0, // codec initialization data size
+	}
+}
+
+// RTPDepay - join RTP packets into frames
+func RTPDepay(handler core.HandlerFunc) core.HandlerFunc {
+	var buf []byte
+
+	return func(packet *rtp.Packet) {
+		var depack codecs.VP9Packet
+		b, err := depack.Unmarshal(packet.Payload)
+		if err != nil {
+			buf = nil
+			return
+		}
+
+		if depack.B {
+			buf = buf[:0]
+		}
+
+		buf = append(buf, b...)
+
+		if !depack.E && !packet.Marker {
+			return
+		}
+
+		clone := *packet
+		clone.Payload = buf
+		buf = nil
+		handler(&clone)
+	}
+}
@@ -2,8 +2,11 @@ package webrtc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -108,3 +111,89 @@ func TestUnmarshalICEServers(t *testing.T) {
 	require.Nil(t, err)
 	require.Len(t, servers, 2)
 }
+
+func TestPublish(t *testing.T) {
+	api, err := NewAPI()
+	require.Nil(t, err)
+
+	pc, err := api.NewPeerConnection(webrtc.Configuration{})
+	require.Nil(t, err)
+
+	cons := NewConn(pc)
+	cons.Mode = core.ModeActiveConsumer
+
+	offer, err := cons.CreateCompleteOffer([]*core.Media{
+		{Kind: core.KindVideo, Direction: core.DirectionSendonly},
+	})
+	require.Nil(t, err)
+
+	pc, err = api.NewPeerConnection(webrtc.Configuration{})
+	require.Nil(t, err)
+
+	prod := NewConn(pc)
+	prod.Mode = core.ModePassiveProducer
+	require.Nil(t, prod.SetOffer(offer))
+
+	answer, err := prod.GetCompleteAnswer(nil, nil)
+	require.Nil(t, err)
+
+	defer cons.Close()
+	defer prod.Close()
+
+	// answer medias from the server side will be used for consumer tracks
+	sd := &sdp.SessionDescription{}
+	require.Nil(t, sd.Unmarshal([]byte(answer)))
+	cons.Medias = UnmarshalMedias(sd.MediaDescriptions)
+
+	require.Len(t, cons.GetMedias(), 1)
+	media := cons.GetMedias()[0]
+	require.Equal(t, core.DirectionSendonly, media.Direction)
+
+	var codec *core.Codec
+	for _, codec = range media.Codecs {
+		if codec.Name == core.CodecH264 {
+			break
+		}
+	}
+	require.Equal(t, core.CodecH264, codec.Name)
+
+	track := core.NewReceiver(media, &core.Codec{Name: core.CodecH264, ClockRate: 90000, PayloadType: 96})
+	require.Nil(t, cons.AddTrack(media, codec, track))
+
+	// server track for the same payload type
+	var recv *core.Receiver
+	for _, prodCodec := range prod.Medias[0].Codecs {
+		if prodCodec.PayloadType == codec.PayloadType {
+			recv, err = prod.GetTrack(prod.Medias[0], prodCodec)
+			require.Nil(t, err)
+		}
+	}
+	require.NotNil(t, recv)
+
+	received := make(chan *rtp.Packet, 10)
+	sender := core.NewSender(prod.Medias[0], recv.Codec)
+	sender.Handler = func(packet *rtp.Packet) {
+		received <- packet
+	}
+	sender.HandleRTP(recv)
+
+	// start ICE after all tracks added
+	require.Nil(t, cons.SetAnswer(answer))
+
+	deadline := time.After(5 * time.Second)
+	for seq := uint16(0); ; seq++ {
+		track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, Marker: true, SequenceNumber: seq, Timestamp: uint32(seq) * 3000},
+			Payload: []byte{0x65, 1, 2, 3},
+		})
+
+		select {
+		case packet := <-received:
+			require.Equal(t, []byte{0x65, 1, 2, 3}, packet.Payload)
+			return
+		case <-deadline:
+			t.Fatal("no packets")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
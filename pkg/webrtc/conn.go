@@ -22,6 +22,9 @@ type Conn struct {
 
 	offer  string
 	closed core.Waiter
+
+	layers      *Layers
+	layersCodec *core.Codec
 }
 
 func NewConn(pc *webrtc.PeerConnection) *Conn {
@@ -34,12 +37,7 @@ func NewConn(pc *webrtc.PeerConnection) *Conn {
 		pc: pc,
 	}
 
-	pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
-		// last candidate will be empty
-		if candidate != nil {
-			c.Fire(candidate)
-		}
-	})
+	pc.OnICECandidate(c.onICECandidate)
 
 	pc.OnDataChannel(func(channel *webrtc.DataChannel) {
 		c.Fire(channel)
@@ -147,6 +145,13 @@ func NewConn(pc *webrtc.PeerConnection) *Conn {
 	return c
 }
 
+func (c *Conn) onICECandidate(candidate *webrtc.ICECandidate) {
+	// last candidate will be empty
+	if candidate != nil {
+		c.Fire(candidate)
+	}
+}
+
 func (c *Conn) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.Connection)
 }
@@ -28,6 +28,7 @@ func (c *Conn) AddTrack(media *core.Media, codec *core.Codec, track *core.Receiv
 	case core.ModePassiveConsumer: // video/audio for browser
 	case core.ModeActiveProducer: // go2rtc as WebRTC client (backchannel)
 	case core.ModePassiveProducer: // WebRTC/WHIP
+	case core.ModeActiveConsumer: // go2rtc as WHIP client (answer is set after AddTrack)
 	default:
 		panic(core.Caller())
 	}
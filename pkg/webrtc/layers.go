@@ -0,0 +1,333 @@
+package webrtc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/h264"
+	"github.com/AlexxIT/go2rtc/pkg/h265"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// LayerAuto - switch layers based on bandwidth estimates from the viewer
+const LayerAuto = "auto"
+
+const (
+	layersInterval = 2 * time.Second // bandwidth estimate interval
+	layersUpgrade  = 5               // good intervals before switch to the better layer
+)
+
+// Layers - quality layers (ex. main and sub streams of the camera) for one video track of the viewer.
+// All layers are received at the same time, but only the active layer is sent to the viewer.
+// Layer switches on the keyframe of the new layer, so renegotiation isn't needed.
+// Layers should be added from the best quality to the worst.
+type Layers struct {
+	names []string
+	bytes []int // received bytes for the current interval
+	rates []int // bits per second for the last interval
+
+	active  int
+	pending int // waits for keyframe, -1 if none
+	auto    bool
+	good    int // intervals without congestion
+
+	// bandwidth estimates from RTCP for the current interval
+	remb      float32
+	lost      int
+	received  int
+	fraction  uint8 // max fraction lost from receiver reports
+	estimated time.Time
+
+	// new layer has own timestamps, so they continue from the last sent frame
+	clockRate uint32
+	offset    uint32
+	lastTS    uint32
+	lastTime  time.Time
+
+	output   core.HandlerFunc
+	outputMu sync.Mutex // output is stateful (RTP payloader), but can be slow (WriteRTP)
+	mu       sync.Mutex
+}
+
+func NewLayers(clockRate uint32, output core.HandlerFunc) *Layers {
+	return &Layers{
+		pending:   -1,
+		auto:      true,
+		estimated: time.Now(),
+		clockRate: clockRate,
+		output:    output,
+	}
+}
+
+// Handler - add new layer and return handler for its frames
+func (l *Layers) Handler(name string, isKeyframe func([]byte) bool) core.HandlerFunc {
+	l.mu.Lock()
+	i := len(l.names)
+	l.names = append(l.names, name)
+	l.bytes = append(l.bytes, 0)
+	l.rates = append(l.rates, 0)
+	l.mu.Unlock()
+
+	return func(packet *rtp.Packet) {
+		l.mu.Lock()
+
+		l.bytes[i] += len(packet.Payload)
+
+		if i == l.pending && len(packet.Payload) > 4 && isKeyframe(packet.Payload) {
+			l.active, l.pending = i, -1
+			if !l.lastTime.IsZero() {
+				elapsed := uint32(time.Since(l.lastTime).Milliseconds()) * (l.clockRate / 1000)
+				l.offset = l.lastTS + elapsed - packet.Timestamp
+			}
+		}
+
+		if i != l.active {
+			l.mu.Unlock()
+			return
+		}
+
+		clone := *packet
+		clone.Timestamp += l.offset
+		l.lastTS, l.lastTime = clone.Timestamp, time.Now()
+
+		output := l.output
+		l.mu.Unlock()
+
+		// slow viewer shouldn't block other layers and RTCP handler
+		l.outputMu.Lock()
+		output(&clone)
+		l.outputMu.Unlock()
+	}
+}
+
+// Active - name of the active layer
+func (l *Layers) Active() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.names[l.active]
+}
+
+// Switch - select layer by name or "auto" mode. Layer changes on its next keyframe.
+func (l *Layers) Switch(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if name == LayerAuto {
+		l.auto = true
+		l.good = 0
+		return nil
+	}
+
+	for i, s := range l.names {
+		if s == name {
+			l.auto = false
+			if i != l.active {
+				l.pending = i
+			} else {
+				l.pending = -1
+			}
+			return nil
+		}
+	}
+
+	return errors.New("webrtc: unknown layer: " + name)
+}
+
+// HandleRTCP - collect bandwidth estimates from REMB, TWCC and receiver reports
+func (l *Layers) HandleRTCP(packets []rtcp.Packet) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, packet := range packets {
+		switch packet := packet.(type) {
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			if l.remb == 0 || packet.Bitrate < l.remb {
+				l.remb = packet.Bitrate
+			}
+		case *rtcp.TransportLayerCC:
+			lost, received := countTWCC(packet)
+			l.lost += lost
+			l.received += received
+		case *rtcp.ReceiverReport:
+			for _, report := range packet.Reports {
+				if report.FractionLost > l.fraction {
+					l.fraction = report.FractionLost
+				}
+			}
+		}
+	}
+
+	if elapsed := time.Since(l.estimated); elapsed >= layersInterval {
+		l.estimated = time.Now()
+		l.estimate(elapsed)
+	}
+}
+
+func (l *Layers) estimate(elapsed time.Duration) {
+	for i, n := range l.bytes {
+		l.rates[i] = int(float64(n*8) / elapsed.Seconds())
+		l.bytes[i] = 0
+	}
+
+	// TWCC has more accurate loss than receiver reports
+	var loss float64
+	if total := l.lost + l.received; total > 0 {
+		loss = float64(l.lost) / float64(total)
+	} else {
+		loss = float64(l.fraction) / 256
+	}
+
+	remb := int(l.remb)
+	l.remb, l.lost, l.received, l.fraction = 0, 0, 0, 0
+
+	if !l.auto {
+		return
+	}
+
+	switch {
+	case loss > 0.1 || remb > 0 && remb < l.rates[l.active]:
+		// congestion - switch to the worse layer
+		l.good = 0
+		if l.active+1 < len(l.names) {
+			l.pending = l.active + 1
+		}
+	case loss < 0.02 && l.active > 0 && (remb == 0 || remb > l.rates[l.active-1]*5/4):
+		// no congestion for some time - try the better layer
+		if l.good++; l.good >= layersUpgrade {
+			l.good = 0
+			l.pending = l.active - 1
+		}
+	default:
+		l.good = 0
+	}
+}
+
+func countTWCC(packet *rtcp.TransportLayerCC) (lost, received int) {
+	count := func(symbol uint16) {
+		if lost+received >= int(packet.PacketStatusCount) {
+			return // skip padding of the last chunk
+		}
+		if symbol == rtcp.TypeTCCPacketNotReceived {
+			lost++
+		} else {
+			received++
+		}
+	}
+
+	for _, chunk := range packet.PacketChunks {
+		switch chunk := chunk.(type) {
+		case *rtcp.RunLengthChunk:
+			for range chunk.RunLength {
+				count(chunk.PacketStatusSymbol)
+			}
+		case *rtcp.StatusVectorChunk:
+			for _, symbol := range chunk.SymbolList {
+				count(symbol)
+			}
+		}
+	}
+
+	return
+}
+
+// AddLayer - same as AddTrack, but the track is one of the quality layers of the video media
+func (c *Conn) AddLayer(name string, media *core.Media, codec *core.Codec, track *core.Receiver) error {
+	core.Assert(media.Direction == core.DirectionSendonly)
+
+	var isKeyframe func([]byte) bool
+
+	switch track.Codec.Name {
+	case core.CodecH264:
+		isKeyframe = h264.IsKeyframe
+	case core.CodecH265:
+		isKeyframe = h265.IsKeyframe
+	}
+
+	if isKeyframe == nil || c.Mode != core.ModePassiveConsumer {
+		// only first layer can be used as simple track
+		for _, sender := range c.Senders {
+			if sender.Codec == codec {
+				return errors.New("webrtc: layers not supported for codec: " + track.Codec.Name)
+			}
+		}
+		return c.AddTrack(media, codec, track)
+	}
+
+	if c.layers == nil {
+		localTrack := c.GetSenderTrack(media.ID)
+		if localTrack == nil {
+			return errors.New("webrtc: can't get track")
+		}
+
+		payloadType := codec.PayloadType
+
+		var output core.HandlerFunc = func(packet *rtp.Packet) {
+			c.Send += packet.MarshalSize()
+			_ = localTrack.WriteRTP(payloadType, packet)
+		}
+
+		if codec.Name == core.CodecH264 {
+			output = h264.RTPPay(1200, output)
+		} else {
+			output = h265.RTPPay(1200, output)
+		}
+
+		c.layers = NewLayers(codec.ClockRate, output)
+		c.layersCodec = codec
+
+		if tr := c.getTranseiver(media.ID); tr != nil {
+			go func() {
+				for {
+					packets, _, err := tr.Sender().ReadRTCP()
+					if err != nil {
+						return
+					}
+					c.layers.HandleRTCP(packets)
+				}
+			}()
+		}
+	} else if c.layersCodec != codec {
+		return errors.New("webrtc: layers should have same codec")
+	}
+
+	sender := core.NewSender(media, codec)
+	sender.Handler = c.layers.Handler(name, isKeyframe)
+
+	if track.Codec.Name == core.CodecH264 {
+		if track.Codec.IsRTP() {
+			sender.Handler = h264.RTPDepay(track.Codec, sender.Handler)
+		} else {
+			sender.Handler = h264.RepairAVCC(track.Codec, sender.Handler)
+		}
+	} else {
+		if track.Codec.IsRTP() {
+			sender.Handler = h265.RTPDepay(track.Codec, sender.Handler)
+		} else {
+			sender.Handler = h265.RepairAVCC(track.Codec, sender.Handler)
+		}
+	}
+
+	sender.Bind(track)
+
+	c.Senders = append(c.Senders, sender)
+	return nil
+}
+
+// Layer - active quality layer or empty string if the connection has no layers
+func (c *Conn) Layer() string {
+	if c.layers == nil {
+		return ""
+	}
+	return c.layers.Active()
+}
+
+// SetLayer - switch quality layer by name or "auto" mode
+func (c *Conn) SetLayer(name string) error {
+	if c.layers == nil {
+		return errors.New("webrtc: connection has no layers")
+	}
+	return c.layers.Switch(name)
+}
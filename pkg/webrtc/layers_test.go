@@ -0,0 +1,89 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/h264"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayers(t *testing.T) {
+	var output []*rtp.Packet
+
+	layers := NewLayers(90000, func(packet *rtp.Packet) {
+		output = append(output, packet)
+	})
+
+	high := layers.Handler("high", h264.IsKeyframe)
+	low := layers.Handler("low", h264.IsKeyframe)
+
+	keyframe := []byte{0, 0, 0, 2, 0x65, 0}
+	frame := []byte{0, 0, 0, 2, 0x41, 0}
+
+	high(&rtp.Packet{Header: rtp.Header{Timestamp: 1000}, Payload: keyframe})
+	low(&rtp.Packet{Header: rtp.Header{Timestamp: 500000}, Payload: keyframe})
+	require.Len(t, output, 1)
+	require.Equal(t, "high", layers.Active())
+
+	require.NotNil(t, layers.Switch("medium"))
+	require.Nil(t, layers.Switch("low"))
+
+	// switch only on keyframe of the new layer
+	low(&rtp.Packet{Header: rtp.Header{Timestamp: 503000}, Payload: frame})
+	high(&rtp.Packet{Header: rtp.Header{Timestamp: 4000}, Payload: frame})
+	require.Len(t, output, 2)
+	require.Equal(t, "high", layers.Active())
+
+	low(&rtp.Packet{Header: rtp.Header{Timestamp: 506000}, Payload: keyframe})
+	high(&rtp.Packet{Header: rtp.Header{Timestamp: 7000}, Payload: frame})
+	require.Len(t, output, 3)
+	require.Equal(t, "low", layers.Active())
+
+	// timestamps continue from the last sent frame
+	require.Equal(t, output[2].Payload, keyframe)
+	require.InDelta(t, 4000, output[2].Timestamp, 900)
+
+	low(&rtp.Packet{Header: rtp.Header{Timestamp: 509000}, Payload: frame})
+	require.Equal(t, output[2].Timestamp+3000, output[3].Timestamp)
+}
+
+func TestLayersAuto(t *testing.T) {
+	layers := NewLayers(90000, func(packet *rtp.Packet) {})
+	high := layers.Handler("high", h264.IsKeyframe)
+	_ = layers.Handler("low", h264.IsKeyframe)
+
+	high(&rtp.Packet{Payload: []byte{0, 0, 0, 2, 0x65, 0}})
+
+	// 20% loss from TWCC feedback
+	layers.estimated = time.Now().Add(-layersInterval)
+	layers.HandleRTCP([]rtcp.Packet{
+		&rtcp.TransportLayerCC{
+			PacketStatusCount: 10,
+			PacketChunks: []rtcp.PacketStatusChunk{
+				&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta, RunLength: 8},
+				&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketNotReceived, RunLength: 2},
+			},
+		},
+	})
+	require.Equal(t, 1, layers.pending)
+
+	// manual layer disables auto mode
+	require.Nil(t, layers.Switch("high"))
+	layers.estimated = time.Now().Add(-layersInterval)
+	layers.HandleRTCP([]rtcp.Packet{
+		&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 128}}},
+	})
+	require.Equal(t, -1, layers.pending)
+
+	// REMB lower than bitrate of the active layer
+	require.Nil(t, layers.Switch(LayerAuto))
+	high(&rtp.Packet{Payload: make([]byte, 100_000)})
+	layers.estimated = time.Now().Add(-layersInterval)
+	layers.HandleRTCP([]rtcp.Packet{
+		&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 100_000},
+	})
+	require.Equal(t, 1, layers.pending)
+}
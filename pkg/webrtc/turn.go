@@ -0,0 +1,182 @@
+package webrtc
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/AlexxIT/go2rtc/pkg/xnet"
+	"github.com/pion/turn/v4"
+	"github.com/pion/webrtc/v4"
+)
+
+type TURNConfig struct {
+	Listen     string        `yaml:"listen"`
+	Host       string        `yaml:"host"`        // host for ICE server URLs, default relay IP
+	RelayIP    string        `yaml:"relay_ip"`    // public IP for relay addresses, default "stun"
+	RelayPorts string        `yaml:"relay_ports"` // ex. 50000-50100
+	Secret     string        `yaml:"secret"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// TURNServer - UDP and TCP TURN server for WebRTC viewers behind restrictive NATs.
+// Uses time-limited credentials with HMAC of the expiry time (TURN REST API),
+// so credentials can be given to any viewer and will expire later.
+// Relay allowed only to the IP addresses of this host.
+type TURNServer struct {
+	URLs []string
+
+	secret string
+	ttl    time.Duration
+	server *turn.Server
+}
+
+func NewTURNServer(conf *TURNConfig) (*TURNServer, error) {
+	relayIP, err := turnRelayIP(conf.RelayIP)
+	if err != nil {
+		return nil, err
+	}
+
+	relay, err := turnRelayGenerator(relayIP, conf.RelayPorts)
+	if err != nil {
+		return nil, err
+	}
+
+	udpConn, err := net.ListenPacket("udp4", conf.Listen)
+	if err != nil {
+		return nil, err
+	}
+
+	tcpLn, err := net.Listen("tcp4", conf.Listen)
+	if err != nil {
+		_ = udpConn.Close()
+		return nil, err
+	}
+
+	s := &TURNServer{secret: conf.Secret, ttl: conf.TTL}
+	if s.secret == "" {
+		s.secret = core.RandString(32, 62)
+	}
+
+	permission := turnPermission(relayIP)
+
+	s.server, err = turn.NewServer(turn.ServerConfig{
+		Realm:       "go2rtc",
+		AuthHandler: s.auth,
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{PacketConn: udpConn, RelayAddressGenerator: relay, PermissionHandler: permission},
+		},
+		ListenerConfigs: []turn.ListenerConfig{
+			{Listener: tcpLn, RelayAddressGenerator: relay, PermissionHandler: permission},
+		},
+	})
+	if err != nil {
+		_ = udpConn.Close()
+		_ = tcpLn.Close()
+		return nil, err
+	}
+
+	host := conf.Host
+	if host == "" {
+		host = relayIP.String()
+	}
+
+	_, port, _ := net.SplitHostPort(conf.Listen)
+	address := net.JoinHostPort(host, port)
+
+	s.URLs = []string{
+		"turn:" + address + "?transport=udp",
+		"turn:" + address + "?transport=tcp",
+	}
+
+	return s, nil
+}
+
+// ICEServer - with new time-limited credentials
+func (s *TURNServer) ICEServer() webrtc.ICEServer {
+	username := strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10) + ":go2rtc"
+	return webrtc.ICEServer{
+		URLs:       s.URLs,
+		Username:   username,
+		Credential: s.password(username),
+	}
+}
+
+func (s *TURNServer) Close() error {
+	return s.server.Close()
+}
+
+func (s *TURNServer) password(username string) string {
+	mac := hmac.New(sha1.New, []byte(s.secret))
+	mac.Write([]byte(username))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *TURNServer) auth(username, realm string, _ net.Addr) ([]byte, bool) {
+	ts, _, _ := strings.Cut(username, ":")
+	expiry, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || expiry < time.Now().Unix() {
+		return nil, false
+	}
+	return turn.GenerateAuthKey(username, realm, s.password(username)), true
+}
+
+func turnRelayIP(s string) (net.IP, error) {
+	if s == "" || s == "stun" {
+		return GetCachedPublicIP()
+	}
+
+	if ip := net.ParseIP(s); ip != nil {
+		return ip, nil
+	}
+
+	return nil, errors.New("webrtc: wrong TURN relay IP: " + s)
+}
+
+func turnRelayGenerator(relayIP net.IP, ports string) (turn.RelayAddressGenerator, error) {
+	if ports == "" {
+		return &turn.RelayAddressGeneratorStatic{RelayAddress: relayIP, Address: "0.0.0.0"}, nil
+	}
+
+	s1, s2, _ := strings.Cut(ports, "-")
+	minPort, err1 := strconv.ParseUint(s1, 10, 16)
+	maxPort, err2 := strconv.ParseUint(s2, 10, 16)
+	if err1 != nil || err2 != nil || minPort > maxPort {
+		return nil, errors.New("webrtc: wrong TURN relay ports: " + ports)
+	}
+
+	return &turn.RelayAddressGeneratorPortRange{
+		RelayAddress: relayIP,
+		MinPort:      uint16(minPort),
+		MaxPort:      uint16(maxPort),
+		Address:      "0.0.0.0",
+	}, nil
+}
+
+// turnPermission - allow relay only to this host, so it can't be used as open relay
+func turnPermission(relayIP net.IP) turn.PermissionHandler {
+	ips := []net.IP{relayIP}
+	if nets, err := xnet.IPNets(nil); err == nil {
+		for _, ipNet := range nets {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+
+	return func(_ net.Addr, peerIP net.IP) bool {
+		if peerIP.IsLoopback() {
+			return true
+		}
+		for _, ip := range ips {
+			if ip.Equal(peerIP) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package webrtc
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pion/turn/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTURNServer(t *testing.T) {
+	s, err := NewTURNServer(&TURNConfig{
+		Listen:  "127.0.0.1:34780",
+		RelayIP: "127.0.0.1",
+		Secret:  "secret",
+		TTL:     time.Hour,
+	})
+	require.Nil(t, err)
+	defer s.Close()
+
+	require.Equal(t, []string{
+		"turn:127.0.0.1:34780?transport=udp",
+		"turn:127.0.0.1:34780?transport=tcp",
+	}, s.URLs)
+
+	server := s.ICEServer()
+
+	allocate := func(username, password string) error {
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		require.Nil(t, err)
+		defer conn.Close()
+
+		client, err := turn.NewClient(&turn.ClientConfig{
+			TURNServerAddr: "127.0.0.1:34780",
+			Username:       username,
+			Password:       password,
+			Conn:           conn,
+			RTO:            100 * time.Millisecond,
+		})
+		require.Nil(t, err)
+		defer client.Close()
+
+		require.Nil(t, client.Listen())
+
+		relay, err := client.Allocate()
+		if err != nil {
+			return err
+		}
+		defer relay.Close()
+
+		// relay allowed only to this host
+		require.Nil(t, client.CreatePermission(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}))
+		require.NotNil(t, client.CreatePermission(&net.UDPAddr{IP: net.IPv4(8, 8, 8, 8), Port: 1234}))
+
+		return nil
+	}
+
+	require.Nil(t, allocate(server.Username, server.Credential.(string)))
+	require.NotNil(t, allocate(server.Username, "wrong"))
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10) + ":go2rtc"
+	require.NotNil(t, allocate(expired, s.password(expired)))
+}
@@ -0,0 +1,167 @@
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// MimeSDPFrag - trickle ICE and ICE restart for WHIP (RFC 9725) and WHEP resources
+const MimeSDPFrag = "application/trickle-ice-sdpfrag"
+
+// SDPFrag - SDP fragment with ICE credentials and candidates (RFC 8840)
+type SDPFrag struct {
+	Ufrag      string
+	Pwd        string
+	Mid        string
+	Candidates []string // in "candidate:..." format, without "a="
+	End        bool     // a=end-of-candidates
+}
+
+// ParseSDPFrag - also can parse ICE credentials from full SDP
+func ParseSDPFrag(s string) *SDPFrag {
+	frag := &SDPFrag{}
+
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "a=ice-ufrag:"):
+			frag.Ufrag = line[len("a=ice-ufrag:"):]
+		case strings.HasPrefix(line, "a=ice-pwd:"):
+			frag.Pwd = line[len("a=ice-pwd:"):]
+		case strings.HasPrefix(line, "a=mid:"):
+			if frag.Mid == "" {
+				frag.Mid = line[len("a=mid:"):]
+			}
+		case strings.HasPrefix(line, "a=candidate:"):
+			frag.Candidates = append(frag.Candidates, line[len("a="):])
+		case line == "a=end-of-candidates":
+			frag.End = true
+		}
+	}
+
+	return frag
+}
+
+func (f *SDPFrag) Marshal() string {
+	s := "a=ice-ufrag:" + f.Ufrag + "\r\na=ice-pwd:" + f.Pwd + "\r\n"
+
+	if len(f.Candidates) == 0 && !f.End {
+		return s
+	}
+
+	// candidates should be inside media section
+	s += "m=audio 9 RTP/AVP 0\r\na=mid:" + f.Mid + "\r\n"
+	for _, candidate := range f.Candidates {
+		s += "a=" + candidate + "\r\n"
+	}
+	if f.End {
+		s += "a=end-of-candidates\r\n"
+	}
+
+	return s
+}
+
+// LocalICE - local ICE credentials and first media mid from local description
+func (c *Conn) LocalICE() *SDPFrag {
+	if desc := c.pc.LocalDescription(); desc != nil {
+		frag := ParseSDPFrag(desc.SDP)
+		frag.Candidates = nil
+		return frag
+	}
+	return &SDPFrag{}
+}
+
+// gatherTimeout - max time for gathering local candidates on ICE restart
+const gatherTimeout = 5 * time.Second
+
+// RestartICE - ICE restart with new remote credentials from the remote offer side (WHIP/WHEP PATCH).
+// Return new local credentials with all gathered candidates.
+func (c *Conn) RestartICE(ufrag, pwd string, candidates []string, filter func(*webrtc.ICECandidate) bool) (*SDPFrag, error) {
+	remote := c.pc.RemoteDescription()
+	if remote == nil || remote.Type != webrtc.SDPTypeOffer {
+		return nil, errors.New("webrtc: ICE restart supported only for remote offer")
+	}
+
+	var done = make(chan struct{}, 1)
+	var mu sync.Mutex
+
+	c.pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
+		// trickle listeners also get new candidates
+		c.onICECandidate(candidate)
+
+		if candidate != nil {
+			if filter == nil || filter(candidate) {
+				mu.Lock()
+				candidates = append(candidates, candidate.ToJSON().Candidate)
+				mu.Unlock()
+			}
+		} else {
+			select {
+			case done <- struct{}{}:
+			default:
+			}
+		}
+	})
+	// restore default handler after gathering
+	defer c.pc.OnICECandidate(c.onICECandidate)
+
+	var lines []string
+	for _, line := range strings.SplitAfter(remote.SDP, "\n") {
+		switch {
+		case strings.HasPrefix(line, "a=ice-ufrag:"):
+			line = "a=ice-ufrag:" + ufrag + "\r\n"
+		case strings.HasPrefix(line, "a=ice-pwd:"):
+			line = "a=ice-pwd:" + pwd + "\r\n"
+		}
+		lines = append(lines, line)
+	}
+
+	desc := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: strings.Join(lines, "")}
+	if err := c.pc.SetRemoteDescription(desc); err != nil {
+		return nil, err
+	}
+
+	answer, err := c.pc.CreateAnswer(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.pc.SetLocalDescription(answer); err != nil {
+		return nil, err
+	}
+
+	// don't wait forever if gathering never completes, return candidates gathered so far
+	var end bool
+	select {
+	case <-done:
+		end = true
+	case <-time.After(gatherTimeout):
+	}
+
+	frag := c.LocalICE()
+	mu.Lock()
+	frag.Candidates = slices.Clone(candidates)
+	mu.Unlock()
+	frag.End = end
+	return frag, nil
+}
+
+// LinkICEServers - ICE servers for Link headers of WHIP and WHEP responses
+func LinkICEServers(servers []webrtc.ICEServer) (links []string) {
+	for _, server := range servers {
+		for _, url := range server.URLs {
+			link := "<" + url + `>; rel="ice-server"`
+			if server.Username != "" {
+				link += fmt.Sprintf(`; username="%s"; credential="%v"; credential-type="password"`, server.Username, server.Credential)
+			}
+			links = append(links, link)
+		}
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDPFrag(t *testing.T) {
+	// https://www.rfc-editor.org/rfc/rfc9725.html#section-4.3.1
+	s := "a=ice-ufrag:EsAw\r\n" +
+		"a=ice-pwd:P2uYro0UCOQ4zxjKXaWCBui1\r\n" +
+		"m=audio 9 RTP/AVP 0\r\n" +
+		"a=mid:0\r\n" +
+		"a=candidate:1387637174 1 udp 2122260223 192.0.2.1 61764 typ host generation 0 ufrag EsAw network-id 1\r\n" +
+		"a=candidate:3471623853 1 udp 2122194687 198.51.100.2 61765 typ host generation 0 ufrag EsAw network-id 2\r\n" +
+		"a=candidate:473322822 1 tcp 1518280447 192.0.2.1 9 typ host tcptype active generation 0 ufrag EsAw network-id 1\r\n" +
+		"a=candidate:2154773085 1 tcp 1518214911 198.51.100.2 9 typ host tcptype active generation 0 ufrag EsAw network-id 2\r\n" +
+		"a=end-of-candidates\r\n"
+
+	frag := ParseSDPFrag(s)
+	require.Equal(t, "EsAw", frag.Ufrag)
+	require.Equal(t, "P2uYro0UCOQ4zxjKXaWCBui1", frag.Pwd)
+	require.Equal(t, "0", frag.Mid)
+	require.Len(t, frag.Candidates, 4)
+	require.Equal(t, "candidate:1387637174 1 udp 2122260223 192.0.2.1 61764 typ host generation 0 ufrag EsAw network-id 1", frag.Candidates[0])
+	require.True(t, frag.End)
+
+	require.Equal(t, s, frag.Marshal())
+
+	frag = &SDPFrag{Ufrag: "EsAw", Pwd: "P2uYro0UCOQ4zxjKXaWCBui1"}
+	require.Equal(t, "a=ice-ufrag:EsAw\r\na=ice-pwd:P2uYro0UCOQ4zxjKXaWCBui1\r\n", frag.Marshal())
+}
+
+func TestLinkICEServers(t *testing.T) {
+	links := LinkICEServers([]webrtc.ICEServer{
+		{URLs: []string{"stun:stun.l.google.com:19302"}},
+		{URLs: []string{"turn:turn.example.net?transport=udp"}, Username: "user", Credential: "pass"},
+	})
+	require.Equal(t, []string{
+		`<stun:stun.l.google.com:19302>; rel="ice-server"`,
+		`<turn:turn.example.net?transport=udp>; rel="ice-server"; username="user"; credential="pass"; credential-type="password"`,
+	}, links)
+}
+
+func TestRestartICE(t *testing.T) {
+	api, err := NewAPI()
+	require.Nil(t, err)
+
+	pc1, err := api.NewPeerConnection(webrtc.Configuration{})
+	require.Nil(t, err)
+	defer pc1.Close()
+
+	client := NewConn(pc1)
+	offer, err := client.CreateCompleteOffer([]*core.Media{
+		{Kind: core.KindVideo, Direction: core.DirectionRecvonly},
+	})
+	require.Nil(t, err)
+
+	pc2, err := api.NewPeerConnection(webrtc.Configuration{})
+	require.Nil(t, err)
+	defer pc2.Close()
+
+	server := NewConn(pc2)
+	require.Nil(t, server.SetOffer(offer))
+
+	_, err = server.GetCompleteAnswer(nil, nil)
+	require.Nil(t, err)
+
+	local := server.LocalICE()
+	require.NotEmpty(t, local.Ufrag)
+	require.NotEmpty(t, local.Pwd)
+	require.Equal(t, "0", local.Mid)
+
+	frag, err := server.RestartICE("newufrag", "newpassword0123456789012", nil, nil)
+	require.Nil(t, err)
+	require.NotEqual(t, local.Ufrag, frag.Ufrag)
+	require.Equal(t, frag.Ufrag, server.LocalICE().Ufrag)
+	require.True(t, frag.End)
+
+	require.Contains(t, pc2.RemoteDescription().SDP, "a=ice-ufrag:newufrag\r\n")
+}
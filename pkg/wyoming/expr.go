@@ -62,6 +62,10 @@ func (s *satellite) handleScript(evt *Event) {
 
 	s.srv.Trace("event=%s data=%s payload size=%d", evt.Type, evt.Data, len(evt.Payload))
 
+	if s.srv.EventHandler != nil {
+		s.srv.EventHandler(evt)
+	}
+
 	if script == "" {
 		s.handleEvent(evt)
 		return
@@ -20,8 +20,9 @@ type Server struct {
 	VADThreshold int16
 	WakeURI      string
 
-	MicHandler func(cons core.Consumer) error
-	SndHandler func(prod core.Producer) error
+	MicHandler   func(cons core.Consumer) error
+	SndHandler   func(prod core.Producer) error
+	EventHandler func(evt *Event)
 
 	Trace func(format string, v ...any)
 	Error func(format string, v ...any)
@@ -487,7 +487,22 @@ export class VideoRTC extends HTMLElement {
     }
 
     onwebrtc() {
-        const pc = new RTCPeerConnection(this.pcConfig);
+        // ask built-in TURN server before the offer, so the first connection can use it
+        this.onmessage['webrtc'] = msg => {
+            if (msg.type === 'webrtc/ice_servers') this.onwebrtcoffer(msg.value);
+        };
+        this.send({type: 'webrtc/ice_servers'});
+
+        this.pcState = WebSocket.CONNECTING;
+    }
+
+    /**
+     * @param turnServers {RTCIceServer[]|null} built-in TURN server from go2rtc
+     */
+    onwebrtcoffer(turnServers) {
+        const pc = new RTCPeerConnection(turnServers
+            ? {...this.pcConfig, iceServers: this.pcConfig.iceServers.concat(turnServers)}
+            : this.pcConfig);
 
         pc.addEventListener('icecandidate', ev => {
             if (ev.candidate && this.mode.includes('webrtc/tcp') && ev.candidate.protocol === 'udp') return;
@@ -524,19 +539,19 @@ export class VideoRTC extends HTMLElement {
                         console.warn(er);
                     });
                     break;
-                case 'webrtc/answer':
-                    pc.setRemoteDescription({type: 'answer', sdp: msg.value}).catch(er => {
+                case 'webrtc':
+                    pc.setRemoteDescription({type: 'answer', sdp: msg.value.sdp}).catch(er => {
                         console.warn(er);
                     });
                     break;
                 case 'error':
-                    if (!msg.value.includes('webrtc/offer')) return;
+                    if (!msg.value.startsWith('webrtc:')) return;
                     pc.close();
             }
         };
 
         this.createOffer(pc).then(offer => {
-            this.send({type: 'webrtc/offer', value: offer.sdp});
+            this.send({type: 'webrtc', value: {type: 'offer', sdp: offer.sdp}});
         });
 
         this.pcState = WebSocket.CONNECTING;